@@ -12,6 +12,23 @@ type Config struct {
 	File        FileConfig
 	GitReposDir string       // Git仓库目录
 	Deploy      DeployConfig // 部署配置
+	Build       BuildConfig  // H5构建流水线配置
+	// 配置漂移检查间隔(分钟)，0表示不做定时检查
+	DriftCheckInterval int
+	// 文件回滚预写日志目录，为空时不记录
+	RollbackJournalDir string
+	// 启动时是否自动回滚上次进程遗留的未完成事务
+	RollbackAutoRecover bool
+	// 回滚时单个目录快照的大小上限(MB)，0表示不限制
+	RollbackSnapshotLimitMB int
+	// 等待工作区锁的超时时间(秒)
+	WorkspaceLockTimeout int
+	// 同时执行的异步任务总数
+	TaskMaxConcurrent int
+	// 排队任务数上限，超过时拒绝新任务
+	TaskMaxQueued int
+	// 各任务类型同时执行的数量上限，如 build=1 表示同一时间只执行一个构建
+	TaskTypeLimits map[string]int
 }
 
 // DatabaseConfig 数据库配置
@@ -54,6 +71,32 @@ type DeployConfig struct {
 	DeployTimeout  int    // 部署超时时间(秒)，建议30-120秒
 }
 
+// 构建模式
+const (
+	BuildModeNative = "native" // Go实现的构建流水线
+	BuildModeScript = "script" // 回退到 h5_novel_build_linux.sh
+)
+
+// BuildConfig H5构建流水线配置
+type BuildConfig struct {
+	Mode             string // 构建模式：native 或 script
+	WorkspaceDir     string // 构建工作目录，存放源码检出、发布仓库和构建产物
+	SourceRepo       string // funNovel源码仓库地址，工作目录中没有源码时克隆
+	PublishRepo      string // 构建产物发布仓库地址（master、release等环境）
+	NodeBinDir       string // Node.js的bin目录，存在时加入PATH最前面
+	GitBin           string // git命令
+	YarnBin          string // yarn命令
+	RemoteBasePath   string // local环境部署到远程服务器的目录
+	GitUserName      string // 提交发布仓库使用的用户名
+	GitUserEmail     string // 提交发布仓库使用的邮箱
+	ArtifactDir      string // 构建产物归档目录
+	ArtifactKeep     int    // 每个项目每个环境保留的产物数量，0表示不限制
+	ArtifactMaxAge   int    // 产物保留天数，0表示不限制；每个项目每个环境最新的产物始终保留
+	LogRulesFile     string // 构建和部署输出分类规则文件，不存在时使用内置规则
+	SchedulerEnabled bool   // 是否执行定时构建（多实例部署时只在一个实例上开启）
+	SecretKey        string // 加密保存到数据库的定时构建SSH密码使用的密钥
+}
+
 // GetLocalScriptPath 获取本地脚本路径
 func (c *Config) GetLocalScriptPath(scriptName string) string {
 	// 如果是构建脚本，放在build子目录下
@@ -93,21 +136,7 @@ func Load() *Config {
 			Port: getEnv("PORT", "8080"),
 			Mode: getEnv("GIN_MODE", "debug"),
 		},
-		File: FileConfig{
-			BasePath:       basePath,
-			ProjectRoot:    filepath.Join(basePath, "funNovel"),
-			ConfigDir:      filepath.Join(basePath, "funNovel/src/appConfig"),
-			PrebuildDir:    filepath.Join(basePath, "funNovel/prebuild/build"),
-			StaticDir:      filepath.Join(basePath, "funNovel/src/static"),
-			ViteConfigFile: filepath.Join(basePath, "funNovel/vite.config.js"),
-			PackageFile:    filepath.Join(basePath, "funNovel/package.json"),
-			// 新增配置目录
-			BaseConfigsDir:   filepath.Join(basePath, "funNovel/src/appConfig/baseConfigs"),
-			CommonConfigsDir: filepath.Join(basePath, "funNovel/src/appConfig/commonConfigs"),
-			PayConfigsDir:    filepath.Join(basePath, "funNovel/src/appConfig/payConfigs"),
-			UIConfigsDir:     filepath.Join(basePath, "funNovel/src/appConfig/uiConfigs"),
-			LocalConfigsDir:  filepath.Join(basePath, "funNovel/src/appConfig/localConfigs"),
-		},
+		File: newFileConfig(basePath),
 		// 进行分支管理的仓库
 		GitReposDir: filepath.Join(projectRoot, "repo-branches"),
 		Deploy: DeployConfig{
@@ -116,9 +145,61 @@ func Load() *Config {
 			SSHTimeout:     10,                                                       // SSH连接超时时间(秒)
 			DeployTimeout:  30,                                                       // 部署超时时间(秒)
 		},
+		Build: BuildConfig{
+			Mode:             getEnv("BUILD_MODE", BuildModeScript),
+			WorkspaceDir:     getEnv("BUILD_WORKSPACE", filepath.Join(basePath, "workspace")),
+			SourceRepo:       getEnv("BUILD_SOURCE_REPO", ""),
+			PublishRepo:      getEnv("BUILD_PUBLISH_REPO", ""),
+			NodeBinDir:       getEnv("BUILD_NODE_BIN_DIR", ""),
+			GitBin:           getEnv("BUILD_GIT_BIN", "git"),
+			YarnBin:          getEnv("BUILD_YARN_BIN", "yarn"),
+			RemoteBasePath:   getEnv("BUILD_REMOTE_BASE_PATH", "/opt/website"),
+			GitUserName:      getEnv("BUILD_GIT_USER_NAME", "aogb"),
+			GitUserEmail:     getEnv("BUILD_GIT_USER_EMAIL", "aogb@example.com"),
+			ArtifactDir:      getEnv("BUILD_ARTIFACT_DIR", filepath.Join(projectRoot, "build-artifacts")),
+			ArtifactKeep:     getEnvInt("BUILD_ARTIFACT_KEEP", 10),
+			ArtifactMaxAge:   getEnvInt("BUILD_ARTIFACT_MAX_AGE_DAYS", 30),
+			LogRulesFile:     getEnv("BUILD_LOG_RULES_FILE", filepath.Join(projectRoot, "log-rules.json")),
+			SchedulerEnabled: getEnvBool("BUILD_SCHEDULER_ENABLED", true),
+			SecretKey:        getEnv("BUILD_SECRET_KEY", ""),
+		},
+		DriftCheckInterval:      getEnvInt("DRIFT_CHECK_INTERVAL", 30),
+		RollbackJournalDir:      getEnv("ROLLBACK_JOURNAL_DIR", filepath.Join(projectRoot, "rollback-journal")),
+		RollbackAutoRecover:     getEnvBool("ROLLBACK_AUTO_RECOVER", true),
+		RollbackSnapshotLimitMB: getEnvInt("ROLLBACK_SNAPSHOT_LIMIT_MB", 500),
+		WorkspaceLockTimeout:    getEnvInt("WORKSPACE_LOCK_TIMEOUT", 60),
+		TaskMaxConcurrent:       getEnvInt("TASK_MAX_CONCURRENT", 10),
+		TaskMaxQueued:           getEnvInt("TASK_MAX_QUEUED", 100),
+		TaskTypeLimits:          getEnvIntMap("TASK_TYPE_LIMITS", "build=1,deploy=3"),
 	}
 }
 
+// newFileConfig 根据funNovel的父目录生成文件操作配置
+func newFileConfig(basePath string) FileConfig {
+	return FileConfig{
+		BasePath:       basePath,
+		ProjectRoot:    filepath.Join(basePath, "funNovel"),
+		ConfigDir:      filepath.Join(basePath, "funNovel/src/appConfig"),
+		PrebuildDir:    filepath.Join(basePath, "funNovel/prebuild/build"),
+		StaticDir:      filepath.Join(basePath, "funNovel/src/static"),
+		ViteConfigFile: filepath.Join(basePath, "funNovel/vite.config.js"),
+		PackageFile:    filepath.Join(basePath, "funNovel/package.json"),
+		// 新增配置目录
+		BaseConfigsDir:   filepath.Join(basePath, "funNovel/src/appConfig/baseConfigs"),
+		CommonConfigsDir: filepath.Join(basePath, "funNovel/src/appConfig/commonConfigs"),
+		PayConfigsDir:    filepath.Join(basePath, "funNovel/src/appConfig/payConfigs"),
+		UIConfigsDir:     filepath.Join(basePath, "funNovel/src/appConfig/uiConfigs"),
+		LocalConfigsDir:  filepath.Join(basePath, "funNovel/src/appConfig/localConfigs"),
+	}
+}
+
+// WithBasePath 复制配置并将所有文件路径切换到新的基础路径下（用于暂存目录预演）
+func (c *Config) WithBasePath(basePath string) *Config {
+	clone := *c
+	clone.File = newFileConfig(basePath)
+	return &clone
+}
+
 // GetConfigPath 获取配置文件路径
 func (c *Config) GetConfigPath(configType, brandCode string) string {
 	return filepath.Join(c.File.ConfigDir, configType+"Configs", brandCode+".js")
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 )
 
 // getEnv 获取环境变量，如果不存在则返回默认值
@@ -11,3 +13,34 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt 获取整数类型的环境变量，不存在或格式错误时返回默认值
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvBool 获取布尔类型的环境变量，不存在或格式错误时返回默认值
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvIntMap 获取 key=value 逗号分隔的整数映射，格式错误的项会被忽略
+func getEnvIntMap(key string, defaultValue string) map[string]int {
+	result := make(map[string]int)
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		if number, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && number > 0 {
+			result[strings.TrimSpace(name)] = number
+		}
+	}
+	return result
+}
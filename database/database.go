@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"brand-config-api/config"
+	"brand-config-api/models"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -50,5 +51,17 @@ func InitDB() {
 	// 	log.Fatal("Failed to migrate database:", err)
 	// }
 
+	// 新增的表使用自动迁移
+	if err := DB.AutoMigrate(
+		&models.Platform{},
+		&models.ConfigRevision{},
+		&models.TaskRecord{},
+		&models.BuildArtifact{},
+		&models.BuildSchedule{},
+		&models.BuildScheduleRun{},
+	); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
 	log.Println("Database connected and migrated successfully")
 }
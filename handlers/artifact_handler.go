@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"brand-config-api/models"
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArtifactHandler 构建产物控制器
+type ArtifactHandler struct {
+	artifactService *services.ArtifactService
+}
+
+// NewArtifactHandler 创建构建产物控制器
+func NewArtifactHandler() *ArtifactHandler {
+	return &ArtifactHandler{
+		artifactService: services.NewArtifactService(),
+	}
+}
+
+// GetArtifacts 查询构建产物，支持按 project、environment、branch 筛选和 page、page_size 分页
+func (h *ArtifactHandler) GetArtifacts(c *gin.Context) {
+	query := services.ArtifactQuery{
+		Project:     c.Query("project"),
+		Environment: c.Query("environment"),
+		Branch:      c.Query("branch"),
+	}
+
+	var err error
+	if query.Page, err = parseTaskInt(c.Query("page")); err != nil {
+		utils.BadRequest(c, "无效的页码")
+		return
+	}
+	if query.PageSize, err = parseTaskInt(c.Query("page_size")); err != nil {
+		utils.BadRequest(c, "无效的每页数量")
+		return
+	}
+
+	artifacts, total, err := h.artifactService.ListArtifacts(query)
+	if err != nil {
+		utils.InternalServerError(c, "获取构建产物列表失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  artifacts,
+		"total": total,
+	}, "获取构建产物列表成功")
+}
+
+// GetArtifact 获取单个构建产物
+func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
+	artifact, ok := h.loadArtifact(c)
+	if !ok {
+		return
+	}
+	utils.Success(c, artifact, "获取构建产物成功")
+}
+
+// DownloadArtifact 下载构建产物的zip归档
+func (h *ArtifactHandler) DownloadArtifact(c *gin.Context) {
+	artifact, ok := h.loadArtifact(c)
+	if !ok {
+		return
+	}
+
+	path := h.artifactService.ArtifactFilePath(artifact)
+	if _, err := os.Stat(path); err != nil {
+		utils.NotFound(c, "构建产物文件不存在")
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s-%s-%d.zip", artifact.Environment, artifact.Project, artifact.Version, artifact.ID)
+	c.Header("X-Checksum-SHA256", artifact.Checksum)
+	c.FileAttachment(path, filename)
+}
+
+// loadArtifact 按路径参数 id 读取构建产物，失败时已写入响应
+func (h *ArtifactHandler) loadArtifact(c *gin.Context) (*models.BuildArtifact, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的构建产物ID")
+		return nil, false
+	}
+
+	artifact, err := h.artifactService.GetArtifact(id)
+	if err != nil {
+		if err == services.ErrArtifactNotFound {
+			utils.NotFound(c, "构建产物不存在")
+			return nil, false
+		}
+		utils.InternalServerError(c, "获取构建产物失败: "+err.Error())
+		return nil, false
+	}
+	return artifact, true
+}
@@ -70,6 +70,7 @@ func (h *BaseConfigHandler) CreateBaseConfig(c *gin.Context) {
 		utils.InternalServerError(c, "创建基础配置失败")
 		return
 	}
+	publishConfigUpdated(c, config.ClientID, "create", models.ConfigSectionBase)
 
 	utils.Created(c, gin.H{"data": config}, "基础配置创建成功")
 }
@@ -88,6 +89,7 @@ func (h *BaseConfigHandler) DeleteBaseConfigByClientID(c *gin.Context) {
 		utils.InternalServerError(c, "删除配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientID, "delete", models.ConfigSectionBase)
 
 	utils.Success(c, gin.H{
 		"message":   "基础配置删除成功",
@@ -113,11 +115,12 @@ func (h *BaseConfigHandler) UpdateBaseConfigByClientID(c *gin.Context) {
 	}
 
 	// 更新配置（使用新的回滚服务）
-	err = h.baseConfigService.UpdateBaseConfigByClientID(clientID, baseConfig)
+	err = h.baseConfigService.UpdateBaseConfigByClientID(clientID, baseConfig, getOperator(c))
 	if err != nil {
 		utils.InternalServerError(c, "更新基础配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientID, "update", models.ConfigSectionBase)
 
 	utils.Success(c, gin.H{
 		"message":   "基础配置更新成功",
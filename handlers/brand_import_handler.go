@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BrandImportHandler 品牌导入控制器
+type BrandImportHandler struct {
+	importService *services.BrandImportService
+}
+
+// NewBrandImportHandler 创建品牌导入控制器
+func NewBrandImportHandler() *BrandImportHandler {
+	return &BrandImportHandler{
+		importService: services.NewBrandImportService(),
+	}
+}
+
+// ImportBrands 从funNovel项目导入已有品牌（支持 dry_run 预演，建议先预演确认冲突）
+func (h *BrandImportHandler) ImportBrands(c *gin.Context) {
+	var req services.BrandImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		req.DryRun = true
+	}
+
+	report, err := h.importService.ImportBrands(req)
+	if err != nil {
+		utils.InternalServerError(c, "导入品牌失败: "+err.Error())
+		return
+	}
+
+	message := "导入品牌成功"
+	if report.DryRun {
+		message = "导入预演完成"
+	}
+	utils.Success(c, gin.H{"data": report}, message)
+}
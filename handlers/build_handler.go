@@ -3,26 +3,30 @@ package handlers
 import (
 	"brand-config-api/services"
 	"brand-config-api/utils"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
 // BuildHandler 构建处理器
 type BuildHandler struct {
-	wsManager   *utils.WebSocketManager
-	taskManager *utils.TaskManager
+	wsManager      *utils.WebSocketManager
+	taskManager    *utils.TaskManager
+	projectService *services.BuildProjectService
 }
 
 // NewBuildHandler 创建构建处理器
 func NewBuildHandler(wsManager *utils.WebSocketManager, taskManager *utils.TaskManager) *BuildHandler {
 	return &BuildHandler{
-		wsManager:   wsManager,
-		taskManager: taskManager,
+		wsManager:      wsManager,
+		taskManager:    taskManager,
+		projectService: services.NewBuildProjectService(),
 	}
 }
 
@@ -60,8 +64,27 @@ func (h *BuildHandler) BuildH5(c *gin.Context) {
 		return
 	}
 
-	// 创建任务
-	task, err := h.taskManager.CreateTask()
+	// 验证项目：必须是已知且可构建的项目，见 GET /api/build/projects
+	problems, err := h.projectService.ValidateProjects(config.Projects)
+	if err != nil {
+		log.Printf("❌ 获取可构建项目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取可构建项目失败: " + err.Error(),
+		})
+		return
+	}
+	if len(problems) > 0 {
+		log.Printf("❌ 构建项目验证失败: %v", problems)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success":  false,
+			"error":    "无效的构建项目: " + strings.Join(problems, "; "),
+			"problems": problems,
+		})
+		return
+	}
+
+	task, err := h.startBuild(config, getOperator(c), nil)
 	if err != nil {
 		log.Printf("❌ 创建任务失败: %v", err)
 		c.JSON(http.StatusTooManyRequests, gin.H{
@@ -70,24 +93,89 @@ func (h *BuildHandler) BuildH5(c *gin.Context) {
 		})
 		return
 	}
-	taskID := task.ID
-	log.Printf("🏗️ 创建H5构建任务: %s", taskID)
 
 	// 立即返回任务ID
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"taskId":  taskID,
+		"taskId":  task.ID,
 		"message": "构建任务已创建，请通过WebSocket连接获取实时进度",
 	})
+}
 
-	// 异步执行构建
-	go func() {
-		defer func() {
-			h.taskManager.CompleteTask(taskID, "构建任务结束")
-		}()
+// StartScheduledBuild 启动定时构建，与 BuildH5 使用相同的校验、任务队列和构建流程
+func (h *BuildHandler) StartScheduledBuild(build services.ScheduledBuild, onFinish func(status utils.TaskStatus, errMsg string)) (string, error) {
+	config := H5BuildConfig{
+		Branch:          build.Branch,
+		Version:         build.Version,
+		Environments:    build.Environments,
+		Projects:        build.Projects,
+		ForceForeignNet: build.ForceForeignNet,
+		SSHHost:         build.SSHHost,
+		SSHUser:         build.SSHUser,
+		SSHPassword:     build.SSHPassword,
+	}
+	if err := h.validateBuildConfig(config); err != nil {
+		return "", err
+	}
+	problems, err := h.projectService.ValidateProjects(config.Projects)
+	if err != nil {
+		return "", fmt.Errorf("获取可构建项目失败: %v", err)
+	}
+	if len(problems) > 0 {
+		return "", fmt.Errorf("无效的构建项目: %s", strings.Join(problems, "; "))
+	}
+
+	task, err := h.startBuild(config, build.Requester, onFinish)
+	if err != nil {
+		return "", fmt.Errorf("任务创建失败: %v", err)
+	}
+	return task.ID, nil
+}
 
+// BuildStatus 获取构建任务的当前状态
+func (h *BuildHandler) BuildStatus(taskID string) (utils.TaskStatus, bool) {
+	task, exists := h.taskManager.GetTask(taskID)
+	if !exists {
+		return "", false
+	}
+	return task.Status, true
+}
+
+// startBuild 创建构建任务并排队执行（配置需已校验），onFinish 不为空时在构建结束后调用
+func (h *BuildHandler) startBuild(config H5BuildConfig, requester string, onFinish func(status utils.TaskStatus, errMsg string)) (*utils.Task, error) {
+	task, err := h.taskManager.CreateTask(utils.TaskInfo{
+		Type:        utils.TaskTypeBuild,
+		Requester:   requester,
+		Params:      config,
+		Cancellable: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	taskID := task.ID
+	log.Printf("🏗️ 创建H5构建任务: %s", taskID)
+
+	// 排队执行构建（同一时间只执行有限个构建，见 TASK_TYPE_LIMITS）
+	h.taskManager.Enqueue(taskID, func() {
 		// 开始任务
+		ctx := h.taskManager.CancelContext(taskID)
 		h.taskManager.StartTask(taskID, "开始H5项目构建...")
+		publishTaskEvent(h.taskManager, utils.Event{
+			Type:   utils.EventBuildStarted,
+			TaskID: taskID,
+			Data: gin.H{
+				"projects":     config.Projects,
+				"branch":       config.Branch,
+				"version":      config.Version,
+				"environments": config.Environments,
+			},
+		})
+
+		finish := func(status utils.TaskStatus, errMsg string) {
+			if onFinish != nil {
+				onFinish(status, errMsg)
+			}
+		}
 
 		buildService := services.NewBuildService()
 
@@ -101,10 +189,34 @@ func (h *BuildHandler) BuildH5(c *gin.Context) {
 			SSHHost:      config.SSHHost,
 			SSHUser:      config.SSHUser,
 			SSHPassword:  config.SSHPassword,
+			TaskID:       taskID,
+			Requester:    task.Requester,
+		}
+
+		// 构建过程中每完成一个项目更新一次任务结果，GET /api/build/task/:taskId 可查看已完成项目的结果
+		var partialMu sync.Mutex
+		partial := &services.BuildResult{
+			Projects: config.Projects,
+			Results:  make(map[string]services.ProjectResult),
 		}
 
 		// 执行批量构建
-		_, err := buildService.ExecuteBatchBuild(batchReq, func(progress services.BuildProgress) {
+		result, err := buildService.ExecuteBatchBuild(ctx, batchReq, func(progress services.BuildProgress) {
+			h.taskManager.AppendLog(taskID, progress.Output)
+
+			if progress.Result != nil {
+				partialMu.Lock()
+				partial.Results[progress.Result.Key()] = *progress.Result
+				h.taskManager.SetTaskResult(taskID, partial)
+				partialMu.Unlock()
+
+				h.wsManager.SendMessage(taskID, map[string]interface{}{
+					"type": "project_result",
+					"data": progress.Result,
+				})
+				return
+			}
+
 			// 发送进度到WebSocket
 			h.wsManager.SendMessage(taskID, map[string]interface{}{
 				"type": "deploy_output",
@@ -127,9 +239,25 @@ func (h *BuildHandler) BuildH5(c *gin.Context) {
 			})
 		})
 
+		if result != nil {
+			h.taskManager.SetTaskResult(taskID, result)
+		}
+
 		// 发送任务完成状态
-		if err != nil {
+		if err != nil && ctx.Err() != nil {
+			// 任务已被取消，状态已由 CancelTask 设置
+			h.taskManager.FailTask(taskID, "构建已取消")
+			sendTaskCancelled(h.wsManager, taskID, "构建已取消，构建脚本已终止")
+			finish(utils.TaskStatusCancelled, "构建已取消")
+			publishTaskEvent(h.taskManager, utils.Event{
+				Type:   utils.EventBuildFinished,
+				TaskID: taskID,
+				Status: string(utils.TaskStatusCancelled),
+				Data:   result,
+			})
+		} else if err != nil {
 			h.taskManager.FailTask(taskID, err.Error())
+			finish(utils.TaskStatusFailed, err.Error())
 			h.wsManager.SendMessage(taskID, map[string]interface{}{
 				"type": "task_status",
 				"data": map[string]interface{}{
@@ -138,8 +266,15 @@ func (h *BuildHandler) BuildH5(c *gin.Context) {
 					"error":   err.Error(),
 				},
 			})
+			publishTaskEvent(h.taskManager, utils.Event{
+				Type:   utils.EventBuildFinished,
+				TaskID: taskID,
+				Status: string(utils.TaskStatusFailed),
+				Data:   gin.H{"error": err.Error(), "result": result},
+			})
 		} else {
 			h.taskManager.CompleteTask(taskID, "H5项目构建成功完成")
+			finish(utils.TaskStatusCompleted, "")
 			h.wsManager.SendMessage(taskID, map[string]interface{}{
 				"type": "task_status",
 				"data": map[string]interface{}{
@@ -147,8 +282,16 @@ func (h *BuildHandler) BuildH5(c *gin.Context) {
 					"message": "H5项目构建成功完成",
 				},
 			})
+			publishTaskEvent(h.taskManager, utils.Event{
+				Type:   utils.EventBuildFinished,
+				TaskID: taskID,
+				Status: string(utils.TaskStatusCompleted),
+				Data:   result,
+			})
 		}
-	}()
+	})
+
+	return task, nil
 }
 
 // validateBuildConfig 验证构建配置
@@ -173,8 +316,11 @@ func (h *BuildHandler) validateBuildConfig(config H5BuildConfig) error {
 			return fmt.Errorf("无效的环境: %s", env)
 		}
 	}
+	if err := services.NewBuildService().ValidateEnvironments(config.Environments); err != nil {
+		return err
+	}
 
-	// 验证项目选择 - 只检查是否为空，不限制具体项目
+	// 验证项目选择 - 这里只检查是否为空，具体项目由 ValidateProjects 检查
 	if len(config.Projects) == 0 {
 		return fmt.Errorf("必须选择至少一个项目")
 	}
@@ -193,7 +339,21 @@ func (h *BuildHandler) validateBuildConfig(config H5BuildConfig) error {
 	return nil
 }
 
-// GetTaskStatus 获取任务状态
+// GetBuildProjects 获取全部项目及其是否可构建（数据库客户端、build脚本、prebuild目录）
+func (h *BuildHandler) GetBuildProjects(c *gin.Context) {
+	projects, err := h.projectService.ListBuildProjects()
+	if err != nil {
+		utils.InternalServerError(c, "获取可构建项目失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  projects,
+		"total": len(projects),
+	}, "获取可构建项目成功")
+}
+
+// GetTaskStatus 获取任务状态，构建任务同时返回按 环境/项目 排序的各项目结果（构建中为已完成的项目）
 func (h *BuildHandler) GetTaskStatus(c *gin.Context) {
 	taskID := c.Param("taskId")
 	if taskID == "" {
@@ -213,10 +373,17 @@ func (h *BuildHandler) GetTaskStatus(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"success": true,
 		"data":    task,
-	})
+	}
+	if task.Type == utils.TaskTypeBuild && len(task.Result) > 0 {
+		var result services.BuildResult
+		if err := json.Unmarshal(task.Result, &result); err == nil {
+			response["results"] = result.SortedResults()
+		}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // handleBuildOutput 处理构建输出
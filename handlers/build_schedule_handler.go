@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"strconv"
+
+	"brand-config-api/models"
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildScheduleHandler 定时构建控制器
+type BuildScheduleHandler struct {
+	scheduleService *services.BuildScheduleService
+}
+
+// NewBuildScheduleHandler 创建定时构建控制器
+func NewBuildScheduleHandler() *BuildScheduleHandler {
+	return &BuildScheduleHandler{
+		scheduleService: services.NewBuildScheduleService(),
+	}
+}
+
+// CronPreviewRequest cron表达式预览请求
+type CronPreviewRequest struct {
+	Cron  string `json:"cron" binding:"required"`
+	Count int    `json:"count"` // 预览的执行次数，默认5次
+}
+
+// GetSchedules 获取所有定时构建
+func (h *BuildScheduleHandler) GetSchedules(c *gin.Context) {
+	schedules, err := h.scheduleService.ListSchedules()
+	if err != nil {
+		utils.InternalServerError(c, "获取定时构建列表失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  schedules,
+		"total": len(schedules),
+	}, "获取定时构建列表成功")
+}
+
+// GetSchedule 获取单个定时构建
+func (h *BuildScheduleHandler) GetSchedule(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+	utils.Success(c, gin.H{"data": schedule}, "获取定时构建成功")
+}
+
+// CreateSchedule 创建定时构建
+func (h *BuildScheduleHandler) CreateSchedule(c *gin.Context) {
+	var req services.BuildScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := h.scheduleService.ValidateRequest(&req, true); err != nil {
+		utils.BadRequest(c, "无效的定时构建: "+err.Error())
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateSchedule(&req, getOperator(c))
+	if err != nil {
+		h.writeError(c, err, "创建定时构建失败")
+		return
+	}
+
+	utils.Created(c, gin.H{"data": schedule}, "定时构建创建成功")
+}
+
+// UpdateSchedule 更新定时构建，ssh_password 为空时保留原密码
+func (h *BuildScheduleHandler) UpdateSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的定时构建ID")
+		return
+	}
+
+	var req services.BuildScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if err := h.scheduleService.ValidateRequest(&req, false); err != nil {
+		utils.BadRequest(c, "无效的定时构建: "+err.Error())
+		return
+	}
+
+	schedule, err := h.scheduleService.UpdateSchedule(id, &req)
+	if err != nil {
+		h.writeError(c, err, "更新定时构建失败")
+		return
+	}
+
+	utils.Success(c, gin.H{"data": schedule}, "定时构建更新成功")
+}
+
+// DeleteSchedule 删除定时构建及其执行记录
+func (h *BuildScheduleHandler) DeleteSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的定时构建ID")
+		return
+	}
+
+	if err := h.scheduleService.DeleteSchedule(id); err != nil {
+		h.writeError(c, err, "删除定时构建失败")
+		return
+	}
+
+	utils.Success(c, nil, "定时构建删除成功")
+}
+
+// PauseSchedule 暂停定时构建
+func (h *BuildScheduleHandler) PauseSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的定时构建ID")
+		return
+	}
+
+	schedule, err := h.scheduleService.PauseSchedule(id)
+	if err != nil {
+		h.writeError(c, err, "暂停定时构建失败")
+		return
+	}
+
+	utils.Success(c, gin.H{"data": schedule}, "定时构建已暂停")
+}
+
+// ResumeSchedule 恢复定时构建，从当前时间起计算下次执行时间
+func (h *BuildScheduleHandler) ResumeSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的定时构建ID")
+		return
+	}
+
+	schedule, err := h.scheduleService.ResumeSchedule(id)
+	if err != nil {
+		h.writeError(c, err, "恢复定时构建失败")
+		return
+	}
+
+	utils.Success(c, gin.H{"data": schedule}, "定时构建已恢复")
+}
+
+// RunSchedule 立即执行一次定时构建（不影响下次按计划执行的时间）
+func (h *BuildScheduleHandler) RunSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的定时构建ID")
+		return
+	}
+
+	run, err := h.scheduleService.RunSchedule(id, getOperator(c))
+	if err != nil {
+		switch {
+		case err == services.ErrBuildScheduleNotFound:
+			utils.NotFound(c, "定时构建不存在")
+		case err == services.ErrBuildScheduleBusy:
+			utils.Conflict(c, "上次构建尚未结束")
+		case run != nil:
+			utils.BadRequest(c, "启动构建失败: "+err.Error())
+		default:
+			utils.InternalServerError(c, "启动构建失败: "+err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, gin.H{"data": run}, "构建任务已创建")
+}
+
+// GetScheduleRuns 分页获取定时构建的执行记录，支持 page、page_size
+func (h *BuildScheduleHandler) GetScheduleRuns(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的定时构建ID")
+		return
+	}
+
+	page, err := parseTaskInt(c.Query("page"))
+	if err != nil {
+		utils.BadRequest(c, "无效的页码")
+		return
+	}
+	pageSize, err := parseTaskInt(c.Query("page_size"))
+	if err != nil {
+		utils.BadRequest(c, "无效的每页数量")
+		return
+	}
+
+	runs, total, err := h.scheduleService.ListRuns(id, page, pageSize)
+	if err != nil {
+		h.writeError(c, err, "获取执行记录失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  runs,
+		"total": total,
+	}, "获取执行记录成功")
+}
+
+// GetScheduleNextRuns 预览定时构建接下来的执行时间，支持 count
+func (h *BuildScheduleHandler) GetScheduleNextRuns(c *gin.Context) {
+	schedule, ok := h.loadSchedule(c)
+	if !ok {
+		return
+	}
+	count, err := parseTaskInt(c.Query("count"))
+	if err != nil {
+		utils.BadRequest(c, "无效的预览次数")
+		return
+	}
+
+	times, err := h.scheduleService.PreviewCron(schedule.Cron, count)
+	if err != nil {
+		utils.InternalServerError(c, "计算执行时间失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":    times,
+		"total":   len(times),
+		"enabled": schedule.Enabled,
+	}, "获取执行时间成功")
+}
+
+// PreviewCron 预览cron表达式接下来的执行时间（用于保存前检查表达式）
+func (h *BuildScheduleHandler) PreviewCron(c *gin.Context) {
+	var req CronPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	times, err := h.scheduleService.PreviewCron(req.Cron, req.Count)
+	if err != nil {
+		utils.BadRequest(c, "无效的cron表达式: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  times,
+		"total": len(times),
+	}, "获取执行时间成功")
+}
+
+// loadSchedule 按路径参数 id 读取定时构建，失败时已写入响应
+func (h *BuildScheduleHandler) loadSchedule(c *gin.Context) (*models.BuildSchedule, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "无效的定时构建ID")
+		return nil, false
+	}
+
+	schedule, err := h.scheduleService.GetSchedule(id)
+	if err != nil {
+		h.writeError(c, err, "获取定时构建失败")
+		return nil, false
+	}
+	return schedule, true
+}
+
+// writeError 按错误类型写入响应
+func (h *BuildScheduleHandler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrBuildScheduleNotFound:
+		utils.NotFound(c, "定时构建不存在")
+	case services.ErrBuildScheduleNameExists:
+		utils.Conflict(c, "定时构建名称已存在")
+	default:
+		utils.InternalServerError(c, message+": "+err.Error())
+	}
+}
@@ -48,6 +48,7 @@ func (h *CommonConfigHandler) CreateCommonConfig(c *gin.Context) {
 		utils.InternalServerError(c, "创建通用配置失败")
 		return
 	}
+	publishConfigUpdated(c, config.ClientID, "create", models.ConfigSectionCommon)
 
 	utils.Created(c, gin.H{"data": config}, "通用配置创建成功")
 }
@@ -67,10 +68,11 @@ func (h *CommonConfigHandler) UpdateCommonConfigByClientID(c *gin.Context) {
 		return
 	}
 
-	if err := h.commonConfigService.UpdateCommonConfigByClientID(clientIDInt, config); err != nil {
+	if err := h.commonConfigService.UpdateCommonConfigByClientID(clientIDInt, config, getOperator(c)); err != nil {
 		utils.InternalServerError(c, "更新通用配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientIDInt, "update", models.ConfigSectionCommon)
 
 	utils.Success(c, gin.H{"data": config}, "通用配置更新成功")
 }
@@ -89,6 +91,7 @@ func (h *CommonConfigHandler) DeleteCommonConfigByClientID(c *gin.Context) {
 		utils.InternalServerError(c, "删除通用配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientID, "delete", models.ConfigSectionCommon)
 
 	utils.Success(c, gin.H{
 		"message":   "通用配置删除成功",
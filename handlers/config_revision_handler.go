@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"strconv"
+
+	"brand-config-api/models"
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigRevisionHandler 配置修订历史控制器
+type ConfigRevisionHandler struct {
+	revisionService *services.ConfigRevisionService
+}
+
+// NewConfigRevisionHandler 创建配置修订历史控制器
+func NewConfigRevisionHandler() *ConfigRevisionHandler {
+	return &ConfigRevisionHandler{
+		revisionService: services.NewConfigRevisionService(),
+	}
+}
+
+// RestoreRevisionRequest 恢复修订请求
+type RestoreRevisionRequest struct {
+	Section  string `json:"section" binding:"required"`
+	Revision int    `json:"revision" binding:"required"`
+}
+
+// getOperator 获取操作人，优先使用 X-Operator 请求头，没有时使用客户端IP
+func getOperator(c *gin.Context) string {
+	if operator := c.GetHeader("X-Operator"); operator != "" {
+		return operator
+	}
+	return c.ClientIP()
+}
+
+// GetRevisions 获取客户端配置修订列表
+func (h *ConfigRevisionHandler) GetRevisions(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("clientId"))
+	if err != nil {
+		utils.BadRequest(c, "无效的客户端ID")
+		return
+	}
+
+	section := c.Query("section")
+	if section != "" && !models.IsValidConfigSection(section) {
+		utils.BadRequest(c, "无效的配置块: "+section)
+		return
+	}
+
+	revisions, err := h.revisionService.GetRevisions(clientID, section)
+	if err != nil {
+		utils.InternalServerError(c, "获取配置修订列表失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  revisions,
+		"total": len(revisions),
+	}, "获取配置修订列表成功")
+}
+
+// DiffRevisions 比较两个配置修订
+func (h *ConfigRevisionHandler) DiffRevisions(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("clientId"))
+	if err != nil {
+		utils.BadRequest(c, "无效的客户端ID")
+		return
+	}
+
+	section := c.Query("section")
+	if !models.IsValidConfigSection(section) {
+		utils.BadRequest(c, "无效的配置块: "+section)
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		utils.BadRequest(c, "无效的起始修订号")
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		utils.BadRequest(c, "无效的目标修订号")
+		return
+	}
+
+	diff, err := h.revisionService.DiffRevisions(clientID, section, from, to)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"data": diff}, "获取配置修订差异成功")
+}
+
+// RestoreRevision 恢复到指定配置修订
+func (h *ConfigRevisionHandler) RestoreRevision(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("clientId"))
+	if err != nil {
+		utils.BadRequest(c, "无效的客户端ID")
+		return
+	}
+
+	var req RestoreRevisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if !models.IsValidConfigSection(req.Section) {
+		utils.BadRequest(c, "无效的配置块: "+req.Section)
+		return
+	}
+
+	revision, err := h.revisionService.RestoreRevision(clientID, req.Section, req.Revision, getOperator(c))
+	if err != nil {
+		utils.InternalServerError(c, "恢复配置修订失败: "+err.Error())
+		return
+	}
+	publishConfigUpdated(c, clientID, "restore", req.Section)
+
+	utils.Success(c, gin.H{"data": revision}, "配置修订恢复成功")
+}
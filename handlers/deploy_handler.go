@@ -36,7 +36,12 @@ func (h *DeployHandler) DeployNginx(c *gin.Context) {
 	}
 
 	// 创建任务
-	task, err := h.taskManager.CreateTask()
+	task, err := h.taskManager.CreateTask(utils.TaskInfo{
+		Type:        utils.TaskTypeDeploy,
+		Requester:   getOperator(c),
+		Params:      config,
+		Cancellable: true,
+	})
 	if err != nil {
 		c.JSON(http.StatusTooManyRequests, gin.H{
 			"error": "系统繁忙，请稍后重试",
@@ -51,39 +56,76 @@ func (h *DeployHandler) DeployNginx(c *gin.Context) {
 		"message": "部署任务已创建，请通过WebSocket连接获取实时进度",
 	})
 
-	// 启动远程部署（异步）
-	go func() {
-		defer func() {
-			h.taskManager.CompleteTask(task.ID, "部署任务结束")
-		}()
-
+	// 排队执行远程部署
+	h.taskManager.Enqueue(task.ID, func() {
 		// 更新任务状态为运行中
+		ctx := h.taskManager.CancelContext(task.ID)
 		h.taskManager.StartTask(task.ID, "开始远程部署...")
 
 		// 创建WebSocket输出适配器
 		wsOutputChan := make(chan services.OutputMessage, 100)
 
 		// 启动WebSocket消息转发器
-		go h.forwardMessagesToWebSocket(task.ID, wsOutputChan)
+		forwardDone := make(chan struct{})
+		go func() {
+			defer close(forwardDone)
+			h.forwardMessagesToWebSocket(task.ID, wsOutputChan)
+		}()
 
-		// 执行部署脚本
-		if err := h.deployService.ExecuteDeployScriptWithStream(config, wsOutputChan); err != nil {
+		// 执行部署脚本，远程输出的分类汇总作为任务结果
+		summary := utils.NewLogSummary()
+		err := h.deployService.ExecuteDeployScriptWithStream(ctx, config, wsOutputChan, summary)
+		h.taskManager.SetTaskResult(task.ID, gin.H{"log_summary": summary})
+		if err != nil {
+			if ctx.Err() != nil {
+				// 任务已被取消（状态已由 CancelTask 设置），转发完剩余输出后发送最终状态
+				h.taskManager.FailTask(task.ID, "部署已取消")
+				close(wsOutputChan)
+				<-forwardDone
+				sendTaskCancelled(h.wsManager, task.ID, "部署已取消，SSH会话已关闭")
+				h.publishDeployFinished(task.ID, config, utils.TaskStatusCancelled, nil)
+				return
+			}
 			h.taskManager.FailTask(task.ID, fmt.Sprintf("远程部署失败: %v", err))
 			wsOutputChan <- services.OutputMessage{
 				Type:    "failed",
 				Message: fmt.Sprintf("远程部署失败: %v", err),
 			}
-		} else {
-			h.taskManager.CompleteTask(task.ID, "远程部署成功完成")
+			close(wsOutputChan)
+			h.publishDeployFinished(task.ID, config, utils.TaskStatusFailed, err)
+			return
 		}
 
+		h.taskManager.CompleteTask(task.ID, "远程部署成功完成")
 		close(wsOutputChan)
-	}()
+		h.publishDeployFinished(task.ID, config, utils.TaskStatusCompleted, nil)
+	})
+}
+
+// publishDeployFinished 发布部署结束事件（不含服务器凭据）
+func (h *DeployHandler) publishDeployFinished(taskID string, config services.NginxDeployConfig, status utils.TaskStatus, err error) {
+	data := gin.H{
+		"domain":        config.Domain,
+		"port":          config.Port,
+		"location_path": config.LocationPath,
+		"server":        config.Server.Host,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	publishTaskEvent(h.taskManager, utils.Event{
+		Type:   utils.EventDeployFinished,
+		TaskID: taskID,
+		Status: string(status),
+		Data:   data,
+	})
 }
 
 // forwardMessagesToWebSocket 将输出消息转发到WebSocket
 func (h *DeployHandler) forwardMessagesToWebSocket(taskID string, outputChan <-chan services.OutputMessage) {
 	for msg := range outputChan {
+		h.taskManager.AppendLog(taskID, msg.Message)
+
 		// 发送到WebSocket
 		h.wsManager.SendMessage(taskID, gin.H{
 			"type": "deploy_output",
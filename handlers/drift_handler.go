@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"strconv"
+
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DriftHandler 配置漂移检测控制器
+type DriftHandler struct {
+	driftService *services.DriftService
+}
+
+// NewDriftHandler 创建配置漂移检测控制器
+func NewDriftHandler() *DriftHandler {
+	return &DriftHandler{
+		driftService: services.NewDriftService(),
+	}
+}
+
+// DriftActionRequest 重新生成/导入请求，sections 为空时处理所有配置块
+type DriftActionRequest struct {
+	Sections []string `json:"sections"`
+}
+
+// GetDrift 检查数据库与配置文件的差异
+// 支持 client_id 只检查单个客户端，drifted_only=true 只返回存在漂移的客户端，cached=true 返回最近一次定时检查的结果
+func (h *DriftHandler) GetDrift(c *gin.Context) {
+	var report *services.DriftReport
+	if c.Query("cached") == "true" {
+		report = h.driftService.GetLastReport()
+		if report == nil {
+			utils.NotFound(c, "尚未进行过漂移检查")
+			return
+		}
+	} else {
+		clientID := 0
+		if clientIDStr := c.Query("client_id"); clientIDStr != "" {
+			id, err := strconv.Atoi(clientIDStr)
+			if err != nil {
+				utils.BadRequest(c, "无效的客户端ID")
+				return
+			}
+			clientID = id
+		}
+
+		result, err := h.driftService.CheckDrift(clientID)
+		if err != nil {
+			utils.InternalServerError(c, "配置漂移检查失败: "+err.Error())
+			return
+		}
+		report = result
+	}
+
+	clients := report.Clients
+	if c.Query("drifted_only") == "true" {
+		clients = make([]services.ClientDrift, 0, report.DriftedClients)
+		for _, client := range report.Clients {
+			if client.Drifted {
+				clients = append(clients, client)
+			}
+		}
+	}
+
+	utils.Success(c, gin.H{
+		"data":            clients,
+		"total":           len(clients),
+		"total_clients":   report.TotalClients,
+		"drifted_clients": report.DriftedClients,
+		"checked_at":      report.CheckedAt,
+	}, "配置漂移检查完成")
+}
+
+// RegenerateFromDB 根据数据库重新生成客户端配置文件
+func (h *DriftHandler) RegenerateFromDB(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("clientId"))
+	if err != nil {
+		utils.BadRequest(c, "无效的客户端ID")
+		return
+	}
+
+	var req DriftActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := h.driftService.RegenerateClient(clientID, req.Sections)
+	if err != nil {
+		utils.InternalServerError(c, "重新生成配置文件失败: "+err.Error())
+		return
+	}
+	publishConfigUpdated(c, clientID, "regenerate", req.Sections...)
+
+	utils.Success(c, gin.H{"data": result}, "重新生成配置文件成功")
+}
+
+// ImportToDB 将配置文件中的客户端配置导入数据库
+func (h *DriftHandler) ImportToDB(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("clientId"))
+	if err != nil {
+		utils.BadRequest(c, "无效的客户端ID")
+		return
+	}
+
+	var req DriftActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := h.driftService.ImportClient(clientID, req.Sections, getOperator(c))
+	if err != nil {
+		utils.InternalServerError(c, "导入配置失败: "+err.Error())
+		return
+	}
+	publishConfigUpdated(c, clientID, "import", req.Sections...)
+
+	utils.Success(c, gin.H{"data": result}, "导入配置成功")
+}
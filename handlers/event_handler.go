@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventHandler 全局事件流控制器
+type EventHandler struct {
+	wsManager *utils.WebSocketManager
+}
+
+// NewEventHandler 创建全局事件流控制器
+func NewEventHandler(wsManager *utils.WebSocketManager) *EventHandler {
+	return &EventHandler{
+		wsManager: wsManager,
+	}
+}
+
+// HandleWebSocket 通过WebSocket订阅全局事件，?since=N 时先补发 seq 大于 N 的事件
+func (h *EventHandler) HandleWebSocket(c *gin.Context) {
+	since, err := parseEventSince(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since"})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket升级失败: %v", err)
+		return
+	}
+
+	log.Printf("事件流WebSocket连接已建立: %s", c.ClientIP())
+	h.wsManager.ServeWebSocket(utils.EventStreamID, conn, since, nil)
+	log.Printf("事件流WebSocket连接已关闭: %s", c.ClientIP())
+}
+
+// StreamEvents 通过 Server-Sent Events 订阅全局事件，支持 Last-Event-ID 或 ?since=N 续传
+func (h *EventHandler) StreamEvents(c *gin.Context) {
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("since")
+	}
+	since, err := parseEventSince(lastEventID)
+	if err != nil {
+		utils.BadRequest(c, "无效的事件ID: "+lastEventID)
+		return
+	}
+
+	sub := h.wsManager.Subscribe(utils.EventStreamID, since, nil)
+	defer h.wsManager.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭nginx缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case msg := <-sub.Messages():
+			if err := writeSSEEvent(c.Writer, msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-sub.Done():
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// parseEventSince 解析续传的起始序号
+func parseEventSince(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	since, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || since < 0 {
+		return 0, fmt.Errorf("invalid since: %s", value)
+	}
+	return since, nil
+}
+
+// publishConfigUpdated 发布配置更新事件
+func publishConfigUpdated(c *gin.Context, clientID int, action string, sections ...string) {
+	utils.PublishEvent(utils.Event{
+		Type:     utils.EventConfigUpdated,
+		Actor:    getOperator(c),
+		ClientID: clientID,
+		Data: gin.H{
+			"action":   action,
+			"sections": sections,
+		},
+	})
+}
+
+// publishTaskEvent 发布与异步任务相关的事件，操作人取任务的发起人
+func publishTaskEvent(taskManager *utils.TaskManager, event utils.Event) {
+	if task, exists := taskManager.GetTask(event.TaskID); exists {
+		event.Actor = task.Requester
+	}
+	utils.PublishEvent(event)
+}
@@ -34,6 +34,17 @@ func (h *GitHandler) CommitCode(c *gin.Context) {
 	result := h.gitService.ExecuteGitCommit(&req)
 
 	if result.Success {
+		utils.PublishEvent(utils.Event{
+			Type:  utils.EventGitPushed,
+			Actor: getOperator(c),
+			Data: gin.H{
+				"action":      "commit",
+				"branch_name": req.BranchName,
+				"remote_name": req.RemoteName,
+				"target_ref":  req.TargetRef,
+				"commit_msg":  req.CommitMsg,
+			},
+		})
 		utils.Success(c, result, "代码提交成功")
 	} else {
 		utils.InternalServerError(c, result.Error)
@@ -124,6 +135,15 @@ func (h *GitHandler) PullBranchFromRepository(c *gin.Context) {
 	}
 
 	if result.Success {
+		utils.PublishEvent(utils.Event{
+			Type:  utils.EventGitPushed,
+			Actor: getOperator(c),
+			Data: gin.H{
+				"action":         "create_branch",
+				"repository_url": req.RepositoryURL,
+				"branch_name":    req.BranchName,
+			},
+		})
 		utils.Success(c, result, "分支创建成功")
 	} else {
 		utils.InternalServerError(c, result.Message)
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogRuleHandler 构建和部署输出分类规则控制器
+type LogRuleHandler struct {
+	logRuleService *services.LogRuleService
+}
+
+// NewLogRuleHandler 创建输出分类规则控制器
+func NewLogRuleHandler() *LogRuleHandler {
+	return &LogRuleHandler{
+		logRuleService: services.NewLogRuleService(),
+	}
+}
+
+// LogRulesRequest 更新规则请求，规则按顺序匹配
+type LogRulesRequest struct {
+	Rules []utils.LogRule `json:"rules" binding:"required"`
+}
+
+// LogRuleTestRequest 规则测试请求
+type LogRuleTestRequest struct {
+	Rules  []utils.LogRule `json:"rules"`  // 为空时使用当前规则
+	Text   string          `json:"text"`   // 待分类的输出，按行分类
+	Stderr bool            `json:"stderr"` // 按错误输出分类
+}
+
+// GetLogRules 获取当前生效的规则
+func (h *LogRuleHandler) GetLogRules(c *gin.Context) {
+	rules := h.logRuleService.GetRules()
+	utils.Success(c, gin.H{
+		"data":  rules,
+		"total": len(rules),
+	}, "获取输出分类规则成功")
+}
+
+// UpdateLogRules 替换全部规则并保存到规则文件
+func (h *LogRuleHandler) UpdateLogRules(c *gin.Context) {
+	var req LogRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if _, err := utils.CompileLogRules(req.Rules); err != nil {
+		utils.BadRequest(c, "无效的规则: "+err.Error())
+		return
+	}
+
+	if err := h.logRuleService.UpdateRules(req.Rules); err != nil {
+		utils.InternalServerError(c, "保存输出分类规则失败: "+err.Error())
+		return
+	}
+
+	rules := h.logRuleService.GetRules()
+	utils.Success(c, gin.H{
+		"data":  rules,
+		"total": len(rules),
+	}, "输出分类规则更新成功")
+}
+
+// ResetLogRules 恢复内置规则
+func (h *LogRuleHandler) ResetLogRules(c *gin.Context) {
+	if err := h.logRuleService.ResetRules(); err != nil {
+		utils.InternalServerError(c, "恢复内置规则失败: "+err.Error())
+		return
+	}
+
+	rules := h.logRuleService.GetRules()
+	utils.Success(c, gin.H{
+		"data":  rules,
+		"total": len(rules),
+	}, "已恢复内置输出分类规则")
+}
+
+// TestLogRules 用规则对一段输出逐行分类，不保存规则
+func (h *LogRuleHandler) TestLogRules(c *gin.Context) {
+	var req LogRuleTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	lines, summary, err := h.logRuleService.TestRules(req.Rules, req.Text, req.Stderr)
+	if err != nil {
+		utils.BadRequest(c, "无效的规则: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":    lines,
+		"total":   len(lines),
+		"summary": summary,
+	}, "输出分类测试完成")
+}
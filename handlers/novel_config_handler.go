@@ -48,6 +48,7 @@ func (h *NovelConfigHandler) CreateNovelConfig(c *gin.Context) {
 		utils.InternalServerError(c, "创建小说配置失败")
 		return
 	}
+	publishConfigUpdated(c, config.ClientID, "create", models.ConfigSectionNovel)
 
 	utils.Created(c, gin.H{"data": config}, "小说配置创建成功")
 }
@@ -67,10 +68,11 @@ func (h *NovelConfigHandler) UpdateNovelConfigByClientID(c *gin.Context) {
 		return
 	}
 
-	if err := h.novelConfigService.UpdateNovelConfigByClientID(clientIDInt, config); err != nil {
+	if err := h.novelConfigService.UpdateNovelConfigByClientID(clientIDInt, config, getOperator(c)); err != nil {
 		utils.InternalServerError(c, "更新小说配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientIDInt, "update", models.ConfigSectionNovel)
 
 	utils.Success(c, gin.H{"data": config}, "小说配置更新成功")
 }
@@ -89,6 +91,7 @@ func (h *NovelConfigHandler) DeleteNovelConfigByClientID(c *gin.Context) {
 		utils.InternalServerError(c, "删除小说配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientID, "delete", models.ConfigSectionNovel)
 
 	utils.Success(c, gin.H{
 		"message":   "小说配置删除成功",
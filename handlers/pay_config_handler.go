@@ -48,6 +48,7 @@ func (h *PayConfigHandler) CreatePayConfig(c *gin.Context) {
 		utils.InternalServerError(c, "创建支付配置失败")
 		return
 	}
+	publishConfigUpdated(c, config.ClientID, "create", models.ConfigSectionPay)
 
 	utils.Created(c, gin.H{"data": config}, "支付配置创建成功")
 }
@@ -67,10 +68,11 @@ func (h *PayConfigHandler) UpdatePayConfigByClientID(c *gin.Context) {
 		return
 	}
 
-	if err := h.payConfigService.UpdatePayConfigByClientID(clientIDInt, config); err != nil {
+	if err := h.payConfigService.UpdatePayConfigByClientID(clientIDInt, config, getOperator(c)); err != nil {
 		utils.InternalServerError(c, "更新支付配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientIDInt, "update", models.ConfigSectionPay)
 
 	utils.Success(c, gin.H{"data": config}, "支付配置更新成功")
 }
@@ -89,6 +91,7 @@ func (h *PayConfigHandler) DeletePayConfigByClientID(c *gin.Context) {
 		utils.InternalServerError(c, "删除支付配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientID, "delete", models.ConfigSectionPay)
 
 	utils.Success(c, gin.H{
 		"message":   "支付配置删除成功",
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"strconv"
+
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlatformHandler 端类型控制器
+type PlatformHandler struct {
+	platformService *services.PlatformService
+}
+
+// NewPlatformHandler 创建端类型控制器
+func NewPlatformHandler() *PlatformHandler {
+	return &PlatformHandler{
+		platformService: services.NewPlatformService(),
+	}
+}
+
+// GetPlatforms 获取所有端类型
+func (h *PlatformHandler) GetPlatforms(c *gin.Context) {
+	platforms, err := h.platformService.GetAllPlatforms()
+	if err != nil {
+		utils.InternalServerError(c, "获取端类型列表失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  platforms,
+		"total": len(platforms),
+	}, "获取端类型列表成功")
+}
+
+// GetPlatform 获取单个端类型
+func (h *PlatformHandler) GetPlatform(c *gin.Context) {
+	id := c.Param("id")
+	platformID, err := strconv.Atoi(id)
+	if err != nil {
+		utils.BadRequest(c, "无效的端类型ID")
+		return
+	}
+
+	platform, err := h.platformService.GetPlatformByID(platformID)
+	if err != nil {
+		utils.NotFound(c, "端类型不存在")
+		return
+	}
+
+	utils.Success(c, gin.H{"data": platform}, "获取端类型成功")
+}
+
+// CreatePlatform 创建端类型
+func (h *PlatformHandler) CreatePlatform(c *gin.Context) {
+	var req services.PlatformRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	platform, err := h.platformService.CreatePlatform(&req)
+	if err != nil {
+		utils.Conflict(c, err.Error())
+		return
+	}
+
+	utils.Created(c, gin.H{"data": platform}, "端类型创建成功")
+}
+
+// UpdatePlatform 更新端类型
+func (h *PlatformHandler) UpdatePlatform(c *gin.Context) {
+	id := c.Param("id")
+	platformID, err := strconv.Atoi(id)
+	if err != nil {
+		utils.BadRequest(c, "无效的端类型ID")
+		return
+	}
+
+	var req services.PlatformRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	updatedPlatform, err := h.platformService.UpdatePlatform(platformID, &req)
+	if err == services.ErrPlatformNotFound {
+		utils.NotFound(c, "端类型不存在")
+		return
+	}
+	if err != nil {
+		utils.Conflict(c, err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"data": updatedPlatform}, "端类型更新成功")
+}
+
+// DeletePlatform 删除端类型
+func (h *PlatformHandler) DeletePlatform(c *gin.Context) {
+	id := c.Param("id")
+	platformID, err := strconv.Atoi(id)
+	if err != nil {
+		utils.BadRequest(c, "无效的端类型ID")
+		return
+	}
+
+	if err := h.platformService.DeletePlatform(platformID); err != nil {
+		if err == services.ErrPlatformNotFound {
+			utils.NotFound(c, "端类型不存在")
+			return
+		}
+		utils.Conflict(c, err.Error())
+		return
+	}
+
+	utils.Success(c, nil, "端类型删除成功")
+}
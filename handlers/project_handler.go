@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectHandler funNovel项目文件控制器
+type ProjectHandler struct {
+	fileService *services.FileService
+}
+
+// NewProjectHandler 创建项目文件控制器
+func NewProjectHandler() *ProjectHandler {
+	return &ProjectHandler{
+		fileService: services.NewFileService(),
+	}
+}
+
+// GetBasePathMap 获取vite.config.js中basePathMap的所有记录
+func (h *ProjectHandler) GetBasePathMap(c *gin.Context) {
+	entries, err := h.fileService.GetBasePathMap()
+	if err != nil {
+		utils.InternalServerError(c, "获取basePathMap失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  entries,
+		"total": len(entries),
+	}, "获取basePathMap成功")
+}
+
+// GetLocks 获取工作区锁的当前持有者和等待者
+func (h *ProjectHandler) GetLocks(c *gin.Context) {
+	status := utils.GetWorkspaceLockManager().Status()
+
+	utils.Success(c, gin.H{
+		"data": status,
+	}, "获取工作区锁状态成功")
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RollbackHandler 文件回滚日志控制器
+type RollbackHandler struct {
+	rollbackService *services.RollbackService
+}
+
+// NewRollbackHandler 创建文件回滚日志控制器
+func NewRollbackHandler() *RollbackHandler {
+	return &RollbackHandler{
+		rollbackService: services.NewRollbackService(),
+	}
+}
+
+// ResolveRollbackRequest 处理待处理回滚日志的请求
+type ResolveRollbackRequest struct {
+	Action string `json:"action" binding:"required"` // rollback 或 discard
+}
+
+// GetPending 列出上次进程遗留或回滚失败、需要处理的文件事务
+func (h *RollbackHandler) GetPending(c *gin.Context) {
+	journals, err := h.rollbackService.ListPending()
+	if err != nil {
+		utils.InternalServerError(c, "获取待处理回滚日志失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  journals,
+		"total": len(journals),
+	}, "获取待处理回滚日志成功")
+}
+
+// ResolvePending 处理待处理的文件事务：回滚文件或保留当前文件
+func (h *RollbackHandler) ResolvePending(c *gin.Context) {
+	var req ResolveRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+	if req.Action != services.RollbackResolveRollback && req.Action != services.RollbackResolveDiscard {
+		utils.BadRequest(c, "无效的处理动作: "+req.Action)
+		return
+	}
+
+	if err := h.rollbackService.ResolvePending(c.Param("id"), req.Action); err != nil {
+		if err == services.ErrJournalNotFound {
+			utils.NotFound(c, "回滚日志不存在")
+			return
+		}
+		utils.InternalServerError(c, "处理回滚日志失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, nil, "回滚日志处理完成")
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"brand-config-api/services"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskHandler 任务历史控制器
+type TaskHandler struct {
+	wsManager   *utils.WebSocketManager
+	taskManager *utils.TaskManager
+	taskService *services.TaskService
+}
+
+// NewTaskHandler 创建任务历史控制器
+func NewTaskHandler(wsManager *utils.WebSocketManager, taskManager *utils.TaskManager) *TaskHandler {
+	return &TaskHandler{
+		wsManager:   wsManager,
+		taskManager: taskManager,
+		taskService: services.NewTaskService(),
+	}
+}
+
+// GetTasks 查询任务历史，支持按 type、status、from、to（创建时间）筛选和 page、page_size 分页
+func (h *TaskHandler) GetTasks(c *gin.Context) {
+	query := services.TaskQuery{
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+	}
+
+	var err error
+	if query.From, err = parseTaskTime(c.Query("from"), false); err != nil {
+		utils.BadRequest(c, "无效的开始时间: "+err.Error())
+		return
+	}
+	if query.To, err = parseTaskTime(c.Query("to"), true); err != nil {
+		utils.BadRequest(c, "无效的结束时间: "+err.Error())
+		return
+	}
+	if query.Page, err = parseTaskInt(c.Query("page")); err != nil {
+		utils.BadRequest(c, "无效的页码")
+		return
+	}
+	if query.PageSize, err = parseTaskInt(c.Query("page_size")); err != nil {
+		utils.BadRequest(c, "无效的每页数量")
+		return
+	}
+
+	tasks, total, err := h.taskService.ListTasks(query)
+	if err != nil {
+		utils.InternalServerError(c, "获取任务列表失败: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"data":  tasks,
+		"total": total,
+	}, "获取任务列表成功")
+}
+
+// GetTask 获取单个任务的状态、参数和结果
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	task, exists := h.taskManager.GetTask(c.Param("id"))
+	if !exists {
+		utils.NotFound(c, "任务不存在")
+		return
+	}
+	utils.Success(c, task, "获取任务成功")
+}
+
+// GetTaskLog 下载任务的完整输出日志
+func (h *TaskHandler) GetTaskLog(c *gin.Context) {
+	taskID := c.Param("id")
+	if _, exists := h.taskManager.GetTask(taskID); !exists {
+		utils.NotFound(c, "任务不存在")
+		return
+	}
+
+	content, err := h.taskManager.GetTaskLog(taskID)
+	if err != nil {
+		if err == utils.ErrTaskNotFound {
+			utils.NotFound(c, "任务不存在")
+			return
+		}
+		utils.InternalServerError(c, "获取任务日志失败: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=task-%s.log", taskID))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content))
+}
+
+// CancelTask 取消排队中的任务，或正在执行的构建、部署任务
+func (h *TaskHandler) CancelTask(c *gin.Context) {
+	taskID := c.Param("id")
+	dequeued, err := h.taskManager.CancelTask(taskID)
+	if err != nil {
+		switch err {
+		case utils.ErrTaskNotFound:
+			utils.NotFound(c, "任务不存在")
+		case utils.ErrTaskFinished:
+			utils.Conflict(c, "任务已结束，无法取消")
+		case utils.ErrTaskNotCancellable:
+			utils.BadRequest(c, "该类型的任务不支持取消")
+		default:
+			utils.InternalServerError(c, "取消任务失败: "+err.Error())
+		}
+		return
+	}
+
+	// 排队中的任务没有执行方，由这里发送最终状态；执行中的任务在停止后由执行方发送
+	if dequeued {
+		sendTaskCancelled(h.wsManager, taskID, "任务已取消，已移出队列")
+	}
+
+	task, _ := h.taskManager.GetTask(taskID)
+	utils.Success(c, task, "任务已取消")
+}
+
+// SSE 推送参数
+const (
+	sseHeartbeatInterval = 15 * time.Second // 心跳注释的发送间隔，防止代理断开空闲连接
+	sseFinishCheck       = time.Second      // 检查任务是否结束的间隔
+)
+
+// StreamTaskEvents 以 Server-Sent Events 推送任务消息（与 /ws 相同的消息和历史）
+// 支持 Last-Event-ID 请求头或 ?since=N 断点续传，任务结束且消息发送完后发送 end 事件并关闭连接
+func (h *TaskHandler) StreamTaskEvents(c *gin.Context) {
+	taskID := c.Param("id")
+	task, exists := h.taskManager.GetTask(taskID)
+	if !exists {
+		utils.NotFound(c, "任务不存在")
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("since")
+	}
+	var since int64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || parsed < 0 {
+			utils.BadRequest(c, "无效的事件ID: "+lastEventID)
+			return
+		}
+		since = parsed
+	}
+
+	sub := h.wsManager.Subscribe(taskID, since, gin.H{
+		"type": "task_status",
+		"data": task,
+	})
+	defer h.wsManager.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭nginx缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	finishCheck := time.NewTicker(sseFinishCheck)
+	defer finishCheck.Stop()
+
+	// 任务结束后再等一个检查周期，确保结束后发送的结果消息也能送达
+	finishedChecks := 0
+	for {
+		select {
+		case msg := <-sub.Messages():
+			if err := writeSSEEvent(c.Writer, msg); err != nil {
+				return
+			}
+		case <-finishCheck.C:
+			if len(sub.Messages()) > 0 || !h.taskManager.IsTaskFinished(taskID) {
+				finishedChecks = 0
+				continue
+			}
+			if finishedChecks++; finishedChecks < 2 {
+				continue
+			}
+			final, _ := h.taskManager.GetTask(taskID)
+			data, _ := json.Marshal(gin.H{"type": "end", "data": final})
+			writeSSEEvent(c.Writer, utils.StreamMessage{Type: "end", Data: data})
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-sub.Done():
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEEvent 写入一条SSE事件，历史消息带 id 以便断点续传
+func writeSSEEvent(w gin.ResponseWriter, msg utils.StreamMessage) error {
+	var b strings.Builder
+	if msg.Seq > 0 {
+		fmt.Fprintf(&b, "id: %d\n", msg.Seq)
+	}
+	if msg.Type != "" {
+		fmt.Fprintf(&b, "event: %s\n", msg.Type)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", msg.Data)
+
+	if _, err := w.WriteString(b.String()); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// parseTaskTime 解析时间筛选参数，支持 RFC3339 和 2006-01-02；
+// 只给日期的结束时间包含当天
+func parseTaskTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// parseTaskInt 解析可选的整数参数
+func parseTaskInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// sendTaskCancelled 发送任务已取消的最终WebSocket消息
+func sendTaskCancelled(wsManager *utils.WebSocketManager, taskID string, message string) {
+	wsManager.SendMessage(taskID, gin.H{
+		"type": "task_status",
+		"data": gin.H{
+			"status":  string(utils.TaskStatusCancelled),
+			"message": message,
+		},
+	})
+}
@@ -48,6 +48,7 @@ func (h *UIConfigHandler) CreateUIConfig(c *gin.Context) {
 		utils.InternalServerError(c, "创建UI配置失败")
 		return
 	}
+	publishConfigUpdated(c, config.ClientID, "create", models.ConfigSectionUI)
 
 	utils.Created(c, gin.H{"data": config}, "UI配置创建成功")
 }
@@ -67,10 +68,11 @@ func (h *UIConfigHandler) UpdateUIConfigByClientID(c *gin.Context) {
 		return
 	}
 
-	if err := h.uiConfigService.UpdateUIConfigByClientID(clientIDInt, config); err != nil {
+	if err := h.uiConfigService.UpdateUIConfigByClientID(clientIDInt, config, getOperator(c)); err != nil {
 		utils.InternalServerError(c, "更新UI配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientIDInt, "update", models.ConfigSectionUI)
 
 	utils.Success(c, gin.H{"data": config}, "UI配置更新成功")
 }
@@ -89,6 +91,7 @@ func (h *UIConfigHandler) DeleteUIConfigByClientID(c *gin.Context) {
 		utils.InternalServerError(c, "删除UI配置失败: "+err.Error())
 		return
 	}
+	publishConfigUpdated(c, clientID, "delete", models.ConfigSectionUI)
 
 	utils.Success(c, gin.H{
 		"message":   "UI配置删除成功",
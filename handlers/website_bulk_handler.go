@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"brand-config-api/services"
+	"brand-config-api/types"
+	"brand-config-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkManifestSize 批量创建清单文件的大小上限
+const maxBulkManifestSize = 10 << 20
+
+// BulkCreateWebsites 按CSV/XLSX清单批量创建网站
+// 表单字段：file 清单文件；mode 执行模式（all_or_nothing 默认 / continue_on_error）
+// dry_run=true 时只解析和校验清单，不创建任务
+func (h *WebsiteHandler) BulkCreateWebsites(c *gin.Context) {
+	mode := c.DefaultPostForm("mode", c.DefaultQuery("mode", services.BulkModeAllOrNothing))
+	if mode != services.BulkModeAllOrNothing && mode != services.BulkModeContinueOnError {
+		utils.BadRequest(c, "无效的执行模式: "+mode)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.BadRequest(c, "请上传清单文件: "+err.Error())
+		return
+	}
+	if fileHeader.Size > maxBulkManifestSize {
+		utils.BadRequest(c, fmt.Sprintf("清单文件不能超过 %dMB", maxBulkManifestSize>>20))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.BadRequest(c, "读取清单文件失败: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.BadRequest(c, "读取清单文件失败: "+err.Error())
+		return
+	}
+
+	// 先同步解析并校验所有行
+	manifest, err := h.websiteService.ParseBulkManifest(fileHeader.Filename, data)
+	if err != nil {
+		utils.BadRequest(c, "解析清单失败: "+err.Error())
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		utils.Success(c, gin.H{"data": manifest}, "清单校验完成")
+		return
+	}
+
+	if mode == services.BulkModeAllOrNothing && manifest.Invalid > 0 {
+		c.JSON(http.StatusBadRequest, utils.Response{
+			Success: false,
+			Error:   fmt.Sprintf("清单中有 %d 行校验失败", manifest.Invalid),
+			Data:    gin.H{"data": manifest},
+		})
+		return
+	}
+	if manifest.Valid == 0 {
+		utils.BadRequest(c, "清单中没有可创建的网站")
+		return
+	}
+
+	// 创建任务
+	task, err := h.taskManager.CreateTask(utils.TaskInfo{
+		Type:      utils.TaskTypeWebsiteBulk,
+		Requester: getOperator(c),
+		Params: gin.H{
+			"file":    fileHeader.Filename,
+			"mode":    mode,
+			"valid":   manifest.Valid,
+			"invalid": manifest.Invalid,
+		},
+	})
+	if err != nil {
+		utils.InternalServerError(c, "创建任务失败: "+err.Error())
+		return
+	}
+
+	// 立即返回任务ID和校验结果
+	utils.Created(c, gin.H{
+		"message": "任务已创建，正在处理...",
+		"data": gin.H{
+			"task_id":  task.ID,
+			"mode":     mode,
+			"manifest": manifest,
+		},
+	}, "任务已创建")
+
+	// 排队执行批量创建
+	h.enqueueWebsiteTask(task.ID, "开始批量创建网站...", func(progressCallback types.ProgressCallback) (map[string]interface{}, error) {
+		result, err := h.websiteService.CreateWebsitesBulk(manifest, mode, progressCallback)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"mode":      result.Mode,
+			"total":     result.Total,
+			"succeeded": result.Succeeded,
+			"failed":    result.Failed,
+			"rows":      result.Rows,
+		}, nil
+	})
+}
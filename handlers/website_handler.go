@@ -4,6 +4,7 @@ import (
 	"brand-config-api/services"
 	"brand-config-api/types"
 	"brand-config-api/utils"
+	"fmt"
 	"log"
 	"strconv"
 	"time"
@@ -35,8 +36,69 @@ func (h *WebsiteHandler) CreateWebsite(c *gin.Context) {
 		return
 	}
 
+	// 预演模式：在暂存目录中执行并回滚，同步返回文件差异和数据库记录
+	if c.Query("dry_run") == "true" {
+		preview, err := h.websiteService.PreviewCreateWebsite(&req)
+		if err != nil {
+			utils.BadRequest(c, "网站创建预演失败: "+err.Error())
+			return
+		}
+		utils.Success(c, gin.H{"data": preview}, "网站创建预演完成")
+		return
+	}
+
+	// 创建任务
+	task, err := h.taskManager.CreateTask(utils.TaskInfo{
+		Type:      utils.TaskTypeWebsiteCreate,
+		Requester: getOperator(c),
+		Params:    req,
+	})
+	if err != nil {
+		utils.InternalServerError(c, "创建任务失败: "+err.Error())
+		return
+	}
+
+	// 立即返回任务ID
+	utils.Created(c, gin.H{
+		"message": "任务已创建，正在处理...",
+		"data": gin.H{
+			"task_id": task.ID,
+		},
+	}, "任务已创建")
+
+	// 排队执行网站创建
+	h.taskManager.Enqueue(task.ID, func() {
+		h.executeWebsiteCreation(task.ID, &req)
+	})
+}
+
+// CloneWebsite 以已有网站为模板克隆到新品牌/端
+func (h *WebsiteHandler) CloneWebsite(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("clientId"))
+	if err != nil {
+		utils.BadRequest(c, "无效的客户端ID")
+		return
+	}
+
+	var req services.CloneWebsiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 先同步生成克隆计划，源网站不存在或覆盖参数错误时直接返回
+	plan, err := h.websiteService.BuildCloneRequest(clientID, &req)
+	if err != nil {
+		utils.BadRequest(c, "生成克隆请求失败: "+err.Error())
+		return
+	}
+
 	// 创建任务
-	task, err := h.taskManager.CreateTask()
+	task, err := h.taskManager.CreateTask(utils.TaskInfo{
+		Type:      utils.TaskTypeWebsiteClone,
+		Requester: getOperator(c),
+		Params:    gin.H{"source_client_id": clientID, "request": req},
+	})
 	if err != nil {
 		utils.InternalServerError(c, "创建任务失败: "+err.Error())
 		return
@@ -50,12 +112,29 @@ func (h *WebsiteHandler) CreateWebsite(c *gin.Context) {
 		},
 	}, "任务已创建")
 
-	// 异步执行网站创建
-	go h.executeWebsiteCreation(task.ID, &req)
+	// 排队执行网站克隆
+	h.enqueueWebsiteTask(task.ID, "开始克隆网站...", func(progressCallback types.ProgressCallback) (map[string]interface{}, error) {
+		return h.websiteService.CloneWebsite(plan, progressCallback)
+	})
 }
 
 // executeWebsiteCreation 异步执行网站创建
 func (h *WebsiteHandler) executeWebsiteCreation(taskID string, req *services.CreateWebsiteRequest) {
+	// 调用实际的网站创建服务（带真实进度）
+	h.runWebsiteTask(taskID, "开始创建网站...", func(progressCallback types.ProgressCallback) (map[string]interface{}, error) {
+		return h.websiteService.CreateWebsite(req, progressCallback)
+	})
+}
+
+// enqueueWebsiteTask 将网站创建类任务加入任务队列
+func (h *WebsiteHandler) enqueueWebsiteTask(taskID string, startText string, run func(types.ProgressCallback) (map[string]interface{}, error)) {
+	h.taskManager.Enqueue(taskID, func() {
+		h.runWebsiteTask(taskID, startText, run)
+	})
+}
+
+// runWebsiteTask 执行网站创建类任务，通过WebSocket推送进度和结果
+func (h *WebsiteHandler) runWebsiteTask(taskID string, startText string, run func(types.ProgressCallback) (map[string]interface{}, error)) {
 	// 开始任务
 	h.taskManager.StartTask(taskID)
 
@@ -67,7 +146,7 @@ func (h *WebsiteHandler) executeWebsiteCreation(taskID string, req *services.Cre
 		"data": gin.H{
 			"percentage": 0,
 			"status":     "running",
-			"text":       "开始创建网站...",
+			"text":       startText,
 			"details":    []gin.H{},
 		},
 	})
@@ -77,8 +156,10 @@ func (h *WebsiteHandler) executeWebsiteCreation(taskID string, req *services.Cre
 		h.updateProgress(taskID, percentage, text, detail)
 	})
 
-	// 调用实际的网站创建服务（带真实进度）
-	result, err := h.websiteService.CreateWebsite(req, progressCallback)
+	result, err := run(progressCallback)
+	if result != nil {
+		h.taskManager.SetTaskResult(taskID, result)
+	}
 
 	if err != nil {
 		// 任务失败
@@ -116,12 +197,21 @@ func (h *WebsiteHandler) executeWebsiteCreation(taskID string, req *services.Cre
 		"type": "result",
 		"data": result,
 	})
+
+	clientID, _ := result["client_id"].(int) // 批量创建没有单一的客户端，见结果中的 rows
+	publishTaskEvent(h.taskManager, utils.Event{
+		Type:     utils.EventWebsiteCreated,
+		ClientID: clientID,
+		TaskID:   taskID,
+		Data:     result,
+	})
 }
 
 // updateProgress 更新进度
 func (h *WebsiteHandler) updateProgress(taskID string, percentage int, text string, detail string) {
 	log.Printf("🔄 更新进度 [%s]: %d%% - %s: %s", taskID, percentage, text, detail)
 	h.taskManager.UpdateTaskProgress(taskID, percentage, text)
+	h.taskManager.AppendLog(taskID, fmt.Sprintf("[%d%%] %s: %s", percentage, text, detail))
 	h.wsManager.SendMessage(taskID, gin.H{
 		"type": "progress",
 		"data": gin.H{
@@ -175,6 +265,11 @@ func (h *WebsiteHandler) DeleteWebsite(c *gin.Context) {
 		utils.InternalServerError(c, "删除网站失败: "+err.Error())
 		return
 	}
+	utils.PublishEvent(utils.Event{
+		Type:     utils.EventWebsiteDeleted,
+		Actor:    getOperator(c),
+		ClientID: clientID,
+	})
 
 	utils.Success(c, gin.H{
 		"message":   "网站删除成功",
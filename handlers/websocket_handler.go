@@ -4,6 +4,7 @@ import (
 	"brand-config-api/utils"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -30,6 +31,7 @@ func NewWebSocketHandler(wsManager *utils.WebSocketManager, taskManager *utils.T
 }
 
 // HandleWebSocket 处理WebSocket连接
+// 同一任务可以有多个连接；连接后先补发历史消息，?since=N 时只补发 seq 大于 N 的消息
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 	taskID := c.Query("taskId")
 	if taskID == "" {
@@ -37,6 +39,16 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	var since int64
+	if value := c.Query("since"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since"})
+			return
+		}
+		since = parsed
+	}
+
 	// 检查任务是否存在
 	task, exists := h.taskManager.GetTask(taskID)
 	if !exists {
@@ -51,30 +63,13 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	// 添加连接到管理器
-	h.wsManager.AddConnection(taskID, conn)
+	log.Printf("WebSocket连接已建立: %s", taskID)
 
-	// 发送初始任务状态
-	h.wsManager.SendMessage(taskID, gin.H{
+	// 订阅任务消息直到连接断开，连接建立时先单独发送当前任务状态
+	h.wsManager.ServeWebSocket(taskID, conn, since, gin.H{
 		"type": "task_status",
 		"data": task,
 	})
 
-	log.Printf("WebSocket连接已建立: %s", taskID)
-
-	// 处理连接关闭
-	defer func() {
-		h.wsManager.RemoveConnection(taskID)
-		log.Printf("WebSocket连接已关闭: %s", taskID)
-	}()
-
-	// 保持连接活跃
-	for {
-		// 读取消息（这里主要是为了检测连接是否断开）
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			log.Printf("WebSocket读取错误: %v", err)
-			break
-		}
-	}
+	log.Printf("WebSocket连接已关闭: %s", taskID)
 }
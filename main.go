@@ -8,6 +8,7 @@ import (
 	"brand-config-api/database"
 	"brand-config-api/middleware"
 	"brand-config-api/routes"
+	"brand-config-api/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,6 +23,28 @@ func main() {
 	// 初始化数据库
 	database.InitDB()
 
+	// 初始化端类型注册表
+	if err := services.NewPlatformService().InitRegistry(); err != nil {
+		log.Fatal("Failed to init platform registry:", err)
+	}
+
+	// 加载构建和部署输出分类规则，规则文件无效时继续使用内置规则
+	if err := services.NewLogRuleService().LoadRules(); err != nil {
+		log.Printf("⚠️ 加载输出分类规则失败，使用内置规则: %v", err)
+	}
+
+	// 处理上次进程中断时遗留的文件事务
+	services.NewRollbackService().RecoverOnStartup()
+
+	// 上次进程退出时仍在运行的任务标记为已中断
+	services.NewTaskService().MarkInterruptedTasks()
+
+	// 按保留策略清理过期的构建产物
+	services.NewArtifactService().CleanupArtifacts()
+
+	// 启动配置漂移定时检查
+	services.NewDriftService().StartReconciliationJob()
+
 	// 设置路由
 	r := routes.SetupRoutes()
 
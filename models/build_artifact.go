@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// BuildArtifact 构建产物记录，归档文件按校验和存放（内容相同的产物共用一个文件）
+type BuildArtifact struct {
+	ID          int       `json:"id" gorm:"primaryKey"`
+	Project     string    `json:"project" gorm:"column:project;type:varchar(100);not null;index:idx_artifact_project_env"` // 如 tth5-xingchen
+	Environment string    `json:"environment" gorm:"column:environment;type:varchar(32);not null;index:idx_artifact_project_env"`
+	Branch      string    `json:"branch" gorm:"column:branch;type:varchar(255);default:''"`
+	CommitSHA   string    `json:"commit_sha" gorm:"column:commit_sha;type:varchar(40);default:''"`
+	Version     string    `json:"version" gorm:"column:version;type:varchar(32);default:''"`
+	Size        int64     `json:"size" gorm:"column:size"`                                      // 归档文件大小（字节）
+	Checksum    string    `json:"checksum" gorm:"column:checksum;type:char(64);not null;index"` // 归档文件的SHA-256
+	Path        string    `json:"-" gorm:"column:path;type:varchar(500);not null"`              // 归档文件在产物目录中的路径
+	TaskID      string    `json:"task_id" gorm:"column:task_id;type:varchar(36);default:''"`
+	CreatedBy   string    `json:"created_by" gorm:"column:created_by;type:varchar(100);default:''"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at;index"`
+}
+
+// TableName 指定表名
+func (BuildArtifact) TableName() string {
+	return "build_artifacts"
+}
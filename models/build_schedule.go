@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// BuildSchedule 定时构建：按cron表达式定时构建一组项目，与手动构建使用相同的构建流程
+type BuildSchedule struct {
+	ID              int        `json:"id" gorm:"primaryKey"`
+	Name            string     `json:"name" gorm:"column:name;type:varchar(100);not null;uniqueIndex"`
+	Cron            string     `json:"cron" gorm:"column:cron;type:varchar(100);not null"` // 分 时 日 月 周，按服务器本地时间
+	Projects        []string   `json:"projects" gorm:"column:projects;type:text;serializer:json"`
+	Branch          string     `json:"branch" gorm:"column:branch;type:varchar(255);not null"`
+	Environments    []string   `json:"environments" gorm:"column:environments;type:varchar(100);serializer:json"`
+	VersionTemplate string     `json:"version_template" gorm:"column:version_template;type:varchar(100);not null"` // 如 1.0.{date}
+	ForceForeignNet bool       `json:"force_foreign_net" gorm:"column:force_foreign_net;not null"`
+	SSHHost         string     `json:"ssh_host" gorm:"column:ssh_host;type:varchar(255);not null"`
+	SSHUser         string     `json:"ssh_user" gorm:"column:ssh_user;type:varchar(100);not null"`
+	SSHPassword     string     `json:"-" gorm:"column:ssh_password;type:varchar(512);not null"` // AES-GCM加密，见 utils.EncryptSecret，不随接口返回
+	Enabled         bool       `json:"enabled" gorm:"column:enabled;not null;index"`
+	NextRunAt       *time.Time `json:"next_run_at" gorm:"column:next_run_at;index"` // 暂停时为空
+	LastRunAt       *time.Time `json:"last_run_at" gorm:"column:last_run_at"`
+	LastTaskID      string     `json:"last_task_id" gorm:"column:last_task_id;type:varchar(36);default:''"`
+	RunCount        int        `json:"run_count" gorm:"column:run_count;not null;default:0"` // 已启动的构建次数，版本模板中的 {run}
+	CreatedBy       string     `json:"created_by" gorm:"column:created_by;type:varchar(100);default:''"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// TableName 指定表名
+func (BuildSchedule) TableName() string {
+	return "build_schedules"
+}
+
+// BuildScheduleRun 定时构建的执行记录，状态与构建任务一致，另有 skipped（未启动构建）
+type BuildScheduleRun struct {
+	ID          int        `json:"id" gorm:"primaryKey"`
+	ScheduleID  int        `json:"schedule_id" gorm:"column:schedule_id;not null;index"`
+	Trigger     string     `json:"trigger" gorm:"column:trigger_type;type:varchar(20);not null"` // schedule 或 manual
+	TaskID      string     `json:"task_id" gorm:"column:task_id;type:varchar(36);default:''"`
+	Version     string     `json:"version" gorm:"column:version;type:varchar(32);default:''"`
+	Status      string     `json:"status" gorm:"column:status;type:varchar(20);not null"`
+	Error       string     `json:"error" gorm:"column:error;type:text"`
+	ScheduledAt *time.Time `json:"scheduled_at" gorm:"column:scheduled_at"` // 按cron计划的执行时间，手动执行时为空
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at;index"`
+	FinishedAt  *time.Time `json:"finished_at" gorm:"column:finished_at"`
+}
+
+// TableName 指定表名
+func (BuildScheduleRun) TableName() string {
+	return "build_schedule_runs"
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// 配置块标识
+const (
+	ConfigSectionBase   = "base"
+	ConfigSectionCommon = "common"
+	ConfigSectionPay    = "pay"
+	ConfigSectionUI     = "ui"
+	ConfigSectionNovel  = "novel"
+)
+
+// 修订记录的操作类型
+const (
+	RevisionActionBaseline = "baseline" // 首次修改前记录的原始状态
+	RevisionActionUpdate   = "update"
+	RevisionActionRestore  = "restore"
+	RevisionActionImport   = "import" // 从配置文件导入
+)
+
+// ConfigRevision 配置修订记录（只追加，不修改）
+type ConfigRevision struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	ClientID  int       `json:"client_id" gorm:"column:client_id;not null;uniqueIndex:idx_client_section_revision"`
+	Section   string    `json:"section" gorm:"column:section;type:varchar(20);not null;uniqueIndex:idx_client_section_revision"` // base/common/pay/ui/novel
+	Revision  int       `json:"revision" gorm:"column:revision;not null;uniqueIndex:idx_client_section_revision"`                // 同一客户端同一配置块内递增
+	Action    string    `json:"action" gorm:"column:action;type:varchar(20);not null"`
+	Operator  string    `json:"operator" gorm:"column:operator;type:varchar(100);default:''"`
+	Remark    string    `json:"remark" gorm:"column:remark;type:varchar(255);default:''"`
+	Content   string    `json:"content" gorm:"column:content;type:text"` // 修改后的配置快照（JSON）
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+// TableName 指定表名
+func (ConfigRevision) TableName() string {
+	return "config_revisions"
+}
+
+// IsValidConfigSection 判断配置块标识是否有效
+func IsValidConfigSection(section string) bool {
+	switch section {
+	case ConfigSectionBase, ConfigSectionCommon, ConfigSectionPay, ConfigSectionUI, ConfigSectionNovel:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Platform 端类型（host）注册信息
+type Platform struct {
+	ID               int       `json:"id" gorm:"primaryKey"`
+	Code             string    `json:"code" gorm:"column:code;type:varchar(20);uniqueIndex;not null"`     // 端标识，即client.host：h5/tth5/ksh5/tt/ks
+	Name             string    `json:"name" gorm:"column:name;type:varchar(50);not null"`                 // 展示名称
+	UniPlatform      string    `json:"uni_platform" gorm:"column:uni_platform;type:varchar(50);not null"` // package.json中的UNI_PLATFORM
+	DefineMacros     []string  `json:"define_macros" gorm:"column:define_macros;type:text;serializer:json"`
+	ExtraHost        string    `json:"extra_host" gorm:"column:extra_host;type:varchar(20);default:''"`             // 伴随创建的额外端，如 tth5 -> tt
+	IsExtra          bool      `json:"is_extra" gorm:"column:is_extra;default:false"`                               // 仅作为额外端存在，不能直接创建网站
+	RequiredSections []string  `json:"required_sections" gorm:"column:required_sections;type:text;serializer:json"` // 创建网站时必填的配置块，如 novel_config
+	RequiredFields   []string  `json:"required_fields" gorm:"column:required_fields;type:text;serializer:json"`     // 创建网站时必填的字段，如 novel_config.tt_jump_home_url
+	Enabled          bool      `json:"enabled" gorm:"column:enabled;default:true"`
+	CreatedAt        time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt        time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+// TableName 指定表名
+func (Platform) TableName() string {
+	return "platforms"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskRecord 异步任务记录（网站创建、构建、部署等），服务重启后仍可查询
+type TaskRecord struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Type       string     `json:"type" gorm:"column:type;type:varchar(32);not null;default:'';index"`
+	Requester  string     `json:"requester" gorm:"column:requester;type:varchar(100);default:''"`
+	Params     string     `json:"params" gorm:"column:params;type:text"` // 任务参数（JSON，敏感字段已隐藏）
+	Status     string     `json:"status" gorm:"column:status;type:varchar(20);not null;index"`
+	Progress   int        `json:"progress" gorm:"column:progress;default:0"`
+	Message    string     `json:"message" gorm:"column:message;type:varchar(500);default:''"`
+	Error      string     `json:"error" gorm:"column:error;type:text"`
+	Result     string     `json:"result" gorm:"column:result;type:text"` // 任务结果（JSON）
+	Log        string     `json:"-" gorm:"column:log;type:longtext"`     // 完整输出日志，单独下载
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;index"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"column:updated_at"`
+	StartedAt  *time.Time `json:"started_at" gorm:"column:started_at"`
+	FinishedAt *time.Time `json:"finished_at" gorm:"column:finished_at"`
+}
+
+// TableName 指定表名
+func (TaskRecord) TableName() string {
+	return "tasks"
+}
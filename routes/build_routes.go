@@ -2,6 +2,7 @@ package routes
 
 import (
 	"brand-config-api/handlers"
+	"brand-config-api/services"
 	"brand-config-api/utils"
 
 	"github.com/gin-gonic/gin"
@@ -10,11 +11,36 @@ import (
 // SetupBuildRoutes 设置构建相关路由
 func SetupBuildRoutes(router *gin.Engine, wsManager *utils.WebSocketManager, taskManager *utils.TaskManager) {
 	buildHandler := handlers.NewBuildHandler(wsManager, taskManager)
+	artifactHandler := handlers.NewArtifactHandler()
+	scheduleHandler := handlers.NewBuildScheduleHandler()
+
+	// 定时构建通过构建控制器执行，与 POST /api/build/h5 使用相同的流程
+	services.SetBuildRunner(buildHandler)
+	services.NewBuildScheduleService().StartScheduler()
 
 	// 构建API路由组
 	build := router.Group("/api/build")
 	{
 		build.POST("/h5", buildHandler.BuildH5)                // H5项目构建
+		build.GET("/projects", buildHandler.GetBuildProjects)  // 可构建的项目
 		build.GET("/task/:taskId", buildHandler.GetTaskStatus) // 获取任务状态
+
+		// 构建产物
+		build.GET("/artifacts", artifactHandler.GetArtifacts)
+		build.GET("/artifacts/:id", artifactHandler.GetArtifact)
+		build.GET("/artifacts/:id/download", artifactHandler.DownloadArtifact)
+
+		// 定时构建
+		build.GET("/schedules", scheduleHandler.GetSchedules)
+		build.POST("/schedules", scheduleHandler.CreateSchedule)
+		build.POST("/schedules/preview", scheduleHandler.PreviewCron) // 预览cron表达式的执行时间
+		build.GET("/schedules/:id", scheduleHandler.GetSchedule)
+		build.PUT("/schedules/:id", scheduleHandler.UpdateSchedule)
+		build.DELETE("/schedules/:id", scheduleHandler.DeleteSchedule)
+		build.POST("/schedules/:id/pause", scheduleHandler.PauseSchedule)
+		build.POST("/schedules/:id/resume", scheduleHandler.ResumeSchedule)
+		build.POST("/schedules/:id/run", scheduleHandler.RunSchedule)              // 立即执行一次
+		build.GET("/schedules/:id/next-runs", scheduleHandler.GetScheduleNextRuns) // 接下来的执行时间
+		build.GET("/schedules/:id/runs", scheduleHandler.GetScheduleRuns)          // 执行记录
 	}
 }
@@ -1,8 +1,13 @@
 package routes
 
 import (
+	"fmt"
+	"time"
+
+	"brand-config-api/config"
 	"brand-config-api/database"
 	"brand-config-api/handlers"
+	"brand-config-api/services"
 	"brand-config-api/utils"
 
 	"github.com/gin-contrib/cors"
@@ -21,13 +26,29 @@ func SetupRoutes() *gin.Engine {
 
 	// 初始化全局管理器
 	wsManager = utils.NewWebSocketManager()
-	taskManager = utils.NewTaskManager(10) // 最多10个并发任务
+	wsManager.StartCleanup(10*time.Minute, time.Hour) // 任务消息历史在最后一条消息后保留1小时
+	utils.SetEventManager(wsManager)
+	cfg := config.Load()
+	taskManager = utils.NewTaskManager(int32(cfg.TaskMaxConcurrent), cfg.TaskMaxQueued, cfg.TaskTypeLimits)
+	taskManager.SetStore(services.NewTaskService())
+	taskManager.SetQueueNotifier(func(taskID string, position int, queueLength int) {
+		wsManager.SendMessage(taskID, gin.H{
+			"type": "queue_position",
+			"data": gin.H{
+				"status":       string(utils.TaskStatusPending),
+				"position":     position,
+				"queue_length": queueLength,
+				"message":      fmt.Sprintf("任务排队中，前面还有 %d 个任务", position-1),
+			},
+		})
+	})
+	taskManager.StartCleanup(10*time.Minute, time.Hour) // 已结束的任务在内存中保留1小时，之后从数据库查询
 
 	// CORS配置
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Operator", "Last-Event-ID"}
 	r.Use(cors.New(config))
 
 	// 创建控制器实例
@@ -40,10 +61,20 @@ func SetupRoutes() *gin.Engine {
 	payConfigHandler := handlers.NewPayConfigHandler()
 	uiConfigHandler := handlers.NewUIConfigHandler()
 	novelConfigHandler := handlers.NewNovelConfigHandler()
+	platformHandler := handlers.NewPlatformHandler()
+	logRuleHandler := handlers.NewLogRuleHandler()
+	configRevisionHandler := handlers.NewConfigRevisionHandler()
+	projectHandler := handlers.NewProjectHandler()
+	driftHandler := handlers.NewDriftHandler()
+	brandImportHandler := handlers.NewBrandImportHandler()
+	rollbackHandler := handlers.NewRollbackHandler()
+	taskHandler := handlers.NewTaskHandler(wsManager, taskManager)
 	websocketHandler := handlers.NewWebSocketHandler(wsManager, taskManager)
+	eventHandler := handlers.NewEventHandler(wsManager)
 
 	// WebSocket路由
 	r.GET("/ws", websocketHandler.HandleWebSocket)
+	r.GET("/ws/events", eventHandler.HandleWebSocket)
 
 	// API路由组
 	api := r.Group("/api")
@@ -66,6 +97,33 @@ func SetupRoutes() *gin.Engine {
 			brands.POST("", brandHandler.CreateBrand)
 			brands.PUT("/:id", brandHandler.UpdateBrand)
 			brands.DELETE("/:id", brandHandler.DeleteBrand)
+			brands.POST("/import", brandImportHandler.ImportBrands)
+		}
+
+		// 端类型注册路由
+		platforms := api.Group("/platforms")
+		{
+			platforms.GET("", platformHandler.GetPlatforms)
+			platforms.GET("/:id", platformHandler.GetPlatform)
+			platforms.POST("", platformHandler.CreatePlatform)
+			platforms.PUT("/:id", platformHandler.UpdatePlatform)
+			platforms.DELETE("/:id", platformHandler.DeletePlatform)
+		}
+
+		// 构建和部署输出分类规则路由
+		logRules := api.Group("/log-rules")
+		{
+			logRules.GET("", logRuleHandler.GetLogRules)
+			logRules.PUT("", logRuleHandler.UpdateLogRules)
+			logRules.DELETE("", logRuleHandler.ResetLogRules)
+			logRules.POST("/test", logRuleHandler.TestLogRules)
+		}
+
+		// 项目文件路由
+		project := api.Group("/project")
+		{
+			project.GET("/base-path-map", projectHandler.GetBasePathMap)
+			project.GET("/locks", projectHandler.GetLocks)
 		}
 
 		// 客户端相关路由
@@ -130,14 +188,56 @@ func SetupRoutes() *gin.Engine {
 			novelConfigs.DELETE("/client/:client_id", novelConfigHandler.DeleteNovelConfigByClientID)
 		}
 
+		// 配置修订历史路由
+		configRevisions := api.Group("/config-revisions")
+		{
+			configRevisions.GET("/:clientId", configRevisionHandler.GetRevisions)
+			configRevisions.GET("/:clientId/diff", configRevisionHandler.DiffRevisions)
+			configRevisions.POST("/:clientId/restore", configRevisionHandler.RestoreRevision)
+		}
+
+		// 配置漂移检测路由
+		drift := api.Group("/drift")
+		{
+			drift.GET("", driftHandler.GetDrift)
+			drift.POST("/:clientId/regenerate", driftHandler.RegenerateFromDB)
+			drift.POST("/:clientId/import", driftHandler.ImportToDB)
+		}
+
+		// 文件回滚日志路由
+		rollback := api.Group("/rollback")
+		{
+			rollback.GET("/pending", rollbackHandler.GetPending)
+			rollback.POST("/pending/:id/resolve", rollbackHandler.ResolvePending)
+		}
+
+		// 任务历史路由
+		tasks := api.Group("/tasks")
+		{
+			tasks.GET("", taskHandler.GetTasks)
+			tasks.GET("/:id", taskHandler.GetTask)
+			tasks.GET("/:id/log", taskHandler.GetTaskLog)
+			tasks.GET("/:id/events", taskHandler.StreamTaskEvents)
+			tasks.POST("/:id/cancel", taskHandler.CancelTask)
+		}
+
+		// 全局事件流路由（SSE，与 /ws/events 推送相同的事件）
+		api.GET("/events", eventHandler.StreamEvents)
+
 		// 网站创建路由
 		api.POST("/create-website", websiteHandler.CreateWebsite)
 
+		// 网站批量创建路由（CSV/XLSX清单）
+		api.POST("/create-website/bulk", websiteHandler.BulkCreateWebsites)
+
 		// 网站配置查询路由
 		api.GET("/website-config/:clientId", websiteHandler.GetWebsiteConfig)
 
 		// 网站删除路由
 		api.DELETE("/website/:clientId", websiteHandler.DeleteWebsite)
+
+		// 网站克隆路由
+		api.POST("/website/:clientId/clone", websiteHandler.CloneWebsite)
 	}
 
 	// 设置测试管理路由
@@ -74,6 +74,65 @@ log_error() { log_msg "error" "$1" "❌ ERROR:"; }
 log_success() { log_msg "info" "$1" "✅"; }
 log_warning() { log_msg "info" "$1" "⚠️ WARNING:"; }
 
+# 结构化构建结果：每个项目处理完输出一行 "##RESULT {json}"，由服务端解析为各项目结果
+RESULT_PREFIX="##RESULT"
+
+# 转义JSON字符串
+json_escape() {
+    local s="$1"
+    s="${s//\\/\\\\}"
+    s="${s//\"/\\\"}"
+    s="${s//$'\t'/\\t}"
+    s="${s//$'\r'/}"
+    s="${s//$'\n'/\\n}"
+    printf '%s' "$s"
+}
+
+# 当前毫秒时间戳
+now_ms() {
+    date +%s%3N
+}
+
+# 输出项目结果，错误摘要取本项目开始后新增的错误日志（最后20行）
+emit_result() {
+    local proj=$1 env=$2 start_ms=$3 exit_code=$4 error=$5 artifact=$6 error_log_start=$7
+    local end_ms
+    end_ms=$(now_ms)
+    local success="true"
+    local excerpt=""
+    if [ "${exit_code}" -ne 0 ]; then
+        success="false"
+        if [ -f "${WORKSPACE}/error.log" ]; then
+            excerpt=$(tail -n "+$((error_log_start + 1))" "${WORKSPACE}/error.log" | tail -n 20)
+        fi
+    fi
+
+    local line
+    line=$(printf '%s {"project":"%s","environment":"%s","success":%s,"start_time":"%s","end_time":"%s","duration_ms":%d,"exit_code":%d,"error":"%s","error_excerpt":"%s","artifact":"%s"}' \
+        "${RESULT_PREFIX}" \
+        "$(json_escape "${proj}")" \
+        "$(json_escape "${env}")" \
+        "${success}" \
+        "$(date -d "@$((start_ms / 1000))" '+%Y-%m-%d %H:%M:%S')" \
+        "$(date -d "@$((end_ms / 1000))" '+%Y-%m-%d %H:%M:%S')" \
+        "$((end_ms - start_ms))" \
+        "${exit_code}" \
+        "$(json_escape "${error}")" \
+        "$(json_escape "${excerpt}")" \
+        "$(json_escape "${artifact}")")
+    echo "${line}"
+    echo "${line}" >> "${WORKSPACE}/realtime.log"
+}
+
+# 错误日志当前行数
+error_log_lines() {
+    if [ -f "${WORKSPACE}/error.log" ]; then
+        wc -l < "${WORKSPACE}/error.log"
+    else
+        echo 0
+    fi
+}
+
 # SSH部署相关日志函数
 log_info() {
     local message="$1"
@@ -783,6 +842,11 @@ main_build() {
             log_output ""
             log_output "🔄 处理项目: ${proj} (platform: ${platform}, website: ${website})"
 
+            local start_ms error_log_start
+            start_ms=$(now_ms)
+            error_log_start=$(error_log_lines)
+            local exit_code=0 error="" artifact=""
+
             # 每个项目都清理工作区
             git_checkout_and_clean "${BRANCH}"
 
@@ -802,18 +866,34 @@ main_build() {
 
                     # 如果不是local环境，发布到远程仓库
                     if [ "${env}" != "local" ]; then
-                        publish_to_remote "${proj}" "${website}" "${platform}" "${env}" "${VERSION}"
-                    elif [ "${AUTO_DEPLOY}" = "true" ]; then
-                        # local环境且开启自动部署
-                        deploy_to_local "${proj}"
+                        artifact="${WORKSPACE}/dist_backup/${env}-${proj}"
+                        publish_to_remote "${proj}" "${website}" "${platform}" "${env}" "${VERSION}" || {
+                            exit_code=$?
+                            error="发布到远程仓库失败"
+                        }
+                    else
+                        artifact="${WORKSPACE}/dist_backup/${proj}.zip"
+                        if [ "${AUTO_DEPLOY}" = "true" ]; then
+                            # local环境且开启自动部署
+                            deploy_to_local "${proj}" || {
+                                exit_code=$?
+                                error="部署到远程服务器失败"
+                            }
+                        fi
                     fi
                 else
+                    exit_code=$?
+                    error="拷贝构建产物失败"
                     write_log 'log' 'build' "${proj}" "${env}" "fail"
                 fi
             else
+                exit_code=$?
+                error="项目构建失败"
                 log_error "项目构建失败: ${proj}"
                 write_log 'log' 'build' "${proj}" "${env}" "fail"
             fi
+
+            emit_result "${proj}" "${env}" "${start_ms}" "${exit_code}" "${error}" "${artifact}" "${error_log_start}"
         done
     done
 }
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"brand-config-api/config"
+	"brand-config-api/database"
+	"brand-config-api/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrArtifactNotFound 构建产物不存在
+var ErrArtifactNotFound = errors.New("build artifact not found")
+
+// 产物列表分页参数
+const (
+	ArtifactListDefaultPageSize = 20
+	ArtifactListMaxPageSize     = 100
+)
+
+// ArtifactQuery 产物列表查询条件
+type ArtifactQuery struct {
+	Project     string
+	Environment string
+	Branch      string
+	Page        int
+	PageSize    int
+}
+
+// ArtifactInfo 归档产物时记录的构建信息
+type ArtifactInfo struct {
+	Project     string
+	Environment string
+	Branch      string
+	CommitSHA   string
+	Version     string
+	TaskID      string
+	CreatedBy   string
+}
+
+// ArtifactService 构建产物归档服务
+type ArtifactService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewArtifactService 创建构建产物归档服务实例
+func NewArtifactService() *ArtifactService {
+	return &ArtifactService{
+		db:     database.DB,
+		config: config.Load(),
+	}
+}
+
+// ArchiveArtifact 归档构建产物（目录压缩为zip，zip文件按相同规则重新打包）并记录，之后按保留策略清理旧产物
+// 归档文件按SHA-256存放在 <产物目录>/<前两位>/<校验和>.zip，内容相同的产物共用一个文件
+func (s *ArtifactService) ArchiveArtifact(source string, info ArtifactInfo) (*models.BuildArtifact, error) {
+	dir := s.config.Build.ArtifactDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建产物目录失败: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".archive-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("构建产物不存在: %v", err)
+	}
+	if sourceInfo.IsDir() {
+		err = zipDirectory(source, tmpPath)
+	} else {
+		err = repackZip(source, tmpPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打包构建产物失败: %v", err)
+	}
+
+	checksum, size, err := fileChecksum(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("计算校验和失败: %v", err)
+	}
+
+	relPath := filepath.ToSlash(filepath.Join(checksum[:2], checksum+".zip"))
+	fullPath := filepath.Join(dir, filepath.FromSlash(relPath))
+	created := false
+	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return nil, fmt.Errorf("创建产物目录失败: %v", err)
+		}
+		if err := os.Rename(tmpPath, fullPath); err != nil {
+			return nil, fmt.Errorf("保存构建产物失败: %v", err)
+		}
+		created = true
+	}
+
+	artifact := &models.BuildArtifact{
+		Project:     info.Project,
+		Environment: info.Environment,
+		Branch:      info.Branch,
+		CommitSHA:   info.CommitSHA,
+		Version:     info.Version,
+		Size:        size,
+		Checksum:    checksum,
+		Path:        relPath,
+		TaskID:      info.TaskID,
+		CreatedBy:   info.CreatedBy,
+	}
+	if err := s.db.Create(artifact).Error; err != nil {
+		if created {
+			os.Remove(fullPath)
+		}
+		return nil, fmt.Errorf("记录构建产物失败: %v", err)
+	}
+	log.Printf("📦 构建产物已归档: #%d %s (%s) %s", artifact.ID, artifact.Project, artifact.Environment, checksum)
+
+	if err := s.applyRetention(artifact.Project, artifact.Environment); err != nil {
+		log.Printf("⚠️ 清理旧构建产物失败: %v", err)
+	}
+	return artifact, nil
+}
+
+// ListArtifacts 按项目、环境、分支筛选产物，按创建时间倒序分页返回
+func (s *ArtifactService) ListArtifacts(query ArtifactQuery) ([]models.BuildArtifact, int64, error) {
+	db := s.db.Model(&models.BuildArtifact{})
+	if query.Project != "" {
+		db = db.Where("project = ?", query.Project)
+	}
+	if query.Environment != "" {
+		db = db.Where("environment = ?", query.Environment)
+	}
+	if query.Branch != "" {
+		db = db.Where("branch = ?", query.Branch)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count artifacts: %v", err)
+	}
+
+	page, pageSize := query.Page, query.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = ArtifactListDefaultPageSize
+	}
+	if pageSize > ArtifactListMaxPageSize {
+		pageSize = ArtifactListMaxPageSize
+	}
+
+	var artifacts []models.BuildArtifact
+	if err := db.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&artifacts).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list artifacts: %v", err)
+	}
+	return artifacts, total, nil
+}
+
+// GetArtifact 获取产物记录
+func (s *ArtifactService) GetArtifact(id int) (*models.BuildArtifact, error) {
+	var artifact models.BuildArtifact
+	if err := s.db.First(&artifact, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArtifactNotFound
+		}
+		return nil, fmt.Errorf("failed to get artifact: %v", err)
+	}
+	return &artifact, nil
+}
+
+// ArtifactFilePath 产物归档文件的本地路径
+func (s *ArtifactService) ArtifactFilePath(artifact *models.BuildArtifact) string {
+	return filepath.Join(s.config.Build.ArtifactDir, filepath.FromSlash(artifact.Path))
+}
+
+// CleanupArtifacts 对所有项目和环境执行保留策略（启动时调用）
+func (s *ArtifactService) CleanupArtifacts() {
+	var groups []struct {
+		Project     string
+		Environment string
+	}
+	if err := s.db.Model(&models.BuildArtifact{}).Distinct("project", "environment").Find(&groups).Error; err != nil {
+		log.Printf("⚠️ 查询构建产物失败: %v", err)
+		return
+	}
+	for _, group := range groups {
+		if err := s.applyRetention(group.Project, group.Environment); err != nil {
+			log.Printf("⚠️ 清理旧构建产物失败 [%s/%s]: %v", group.Environment, group.Project, err)
+		}
+	}
+}
+
+// applyRetention 删除超出保留数量或保留天数的产物，最新的产物始终保留
+func (s *ArtifactService) applyRetention(project, environment string) error {
+	keep, maxAge := s.config.Build.ArtifactKeep, s.config.Build.ArtifactMaxAge
+	if keep <= 0 && maxAge <= 0 {
+		return nil
+	}
+
+	var artifacts []models.BuildArtifact
+	if err := s.db.Where("project = ? AND environment = ?", project, environment).
+		Order("created_at DESC, id DESC").
+		Find(&artifacts).Error; err != nil {
+		return fmt.Errorf("failed to list artifacts: %v", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -maxAge)
+	for i := 1; i < len(artifacts); i++ {
+		expired := maxAge > 0 && artifacts[i].CreatedAt.Before(cutoff)
+		if (keep > 0 && i >= keep) || expired {
+			if err := s.deleteArtifact(&artifacts[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteArtifact 删除产物记录，没有其他记录引用时同时删除归档文件
+func (s *ArtifactService) deleteArtifact(artifact *models.BuildArtifact) error {
+	if err := s.db.Delete(artifact).Error; err != nil {
+		return fmt.Errorf("failed to delete artifact: %v", err)
+	}
+
+	var refs int64
+	if err := s.db.Model(&models.BuildArtifact{}).Where("path = ?", artifact.Path).Count(&refs).Error; err != nil {
+		return fmt.Errorf("failed to count artifact references: %v", err)
+	}
+	if refs == 0 {
+		if err := os.Remove(s.ArtifactFilePath(artifact)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除归档文件失败: %v", err)
+		}
+	}
+	log.Printf("🗑️ 已清理构建产物: #%d %s (%s)", artifact.ID, artifact.Project, artifact.Environment)
+	return nil
+}
+
+// fileChecksum 计算文件的SHA-256和大小
+func fileChecksum(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// repackZip 按 zipDirectory 的规则（路径排序、固定修改时间）重新打包zip，使校验和只取决于文件内容
+func repackZip(src, dst string) error {
+	reader, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	files := make([]*zip.File, 0, len(reader.File))
+	for _, f := range reader.File {
+		if !f.FileInfo().IsDir() {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, k int) bool {
+		return files[i].Name < files[k].Name
+	})
+
+	file, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, f := range files {
+		if err := copyZipEntry(writer, f); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// copyZipEntry 将zip中的一个文件写入新的zip
+func copyZipEntry(writer *zip.Writer, f *zip.File) error {
+	header, err := zip.FileInfoHeader(f.FileInfo())
+	if err != nil {
+		return err
+	}
+	header.Name = f.Name
+	header.Method = zip.Deflate
+	header.Modified = zipModTime
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
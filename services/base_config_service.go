@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"brand-config-api/config"
 	"brand-config-api/database"
@@ -158,6 +159,13 @@ func (s *BaseConfigService) generateConfigFile(ctx *rollback.TransactionContext,
 
 // DeleteBaseConfigByClientID 根据client_id删除基础配置（独立事务）
 func (s *BaseConfigService) DeleteBaseConfigByClientID(clientID int) error {
+	// 锁定品牌配置文件，避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "DeleteBaseConfigByClientID", false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
@@ -191,85 +199,124 @@ func (s *BaseConfigService) deleteBaseConfigInternal(ctx *rollback.TransactionCo
 	return nil
 }
 
-// BaseConfigFieldUpdate 基础配置字段更新结构
-func (s *BaseConfigService) UpdateBaseConfigByClientID(clientID int, baseConfig models.BaseConfig) error {
+// UpdateBaseConfigByClientID 根据client_id更新基础配置，并记录修订历史
+func (s *BaseConfigService) UpdateBaseConfigByClientID(clientID int, baseConfig models.BaseConfig, operator string) error {
+	// 锁定品牌配置文件，避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "UpdateBaseConfigByClientID", false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
 	return rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
-		// 获取客户端信息
-		var client models.Client
-		if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
-			return fmt.Errorf("failed to find client: %v", err)
+		revisionService := NewConfigRevisionService()
+		if err := revisionService.EnsureBaseline(ctx, clientID, models.ConfigSectionBase); err != nil {
+			return err
 		}
 
-		// 获取品牌信息
-		var brand models.Brand
-		if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
-			return fmt.Errorf("failed to find brand: %v", err)
+		if err := s.updateBaseConfigInternal(ctx, clientID, baseConfig, false); err != nil {
+			return err
 		}
 
-		log.Printf("🔄 开始更新基础配置: brand=%s, host=%s", brand.Code, client.Host)
+		return revisionService.RecordRevision(ctx, clientID, models.ConfigSectionBase, models.RevisionActionUpdate, operator, "")
+	}, nil)
+}
 
-		// 内联验证和更新数据库逻辑
-		if baseConfig.AppName == "" {
-			return fmt.Errorf("app_name is required")
-		}
-		if baseConfig.Platform == "" {
-			return fmt.Errorf("platform is required")
-		}
-		if baseConfig.AppCode == "" {
-			return fmt.Errorf("app_code is required")
-		}
-		if baseConfig.Product == "" {
-			return fmt.Errorf("product is required")
+// baseConfigColumns 基础配置的可更新字段
+var baseConfigColumns = []string{"platform", "app_name", "app_code", "product", "customer", "appid", "version", "cl", "uc", "updated_at"}
+
+// updateBaseConfigInternal 内部更新基础配置方法（不管理事务）
+// overwrite 为 true 时按传入的配置写入所有字段（包括空值，恢复历史修订时使用），否则只更新非空字段
+func (s *BaseConfigService) updateBaseConfigInternal(ctx *rollback.TransactionContext, clientID int, baseConfig models.BaseConfig, overwrite bool) error {
+	// 获取客户端信息
+	var client models.Client
+	if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
+		return fmt.Errorf("failed to find client: %v", err)
+	}
+
+	// 获取品牌信息
+	var brand models.Brand
+	if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
+		return fmt.Errorf("failed to find brand: %v", err)
+	}
+
+	log.Printf("🔄 开始更新基础配置: brand=%s, host=%s", brand.Code, client.Host)
+
+	// 内联验证和更新数据库逻辑
+	if baseConfig.AppName == "" {
+		return fmt.Errorf("app_name is required")
+	}
+	if baseConfig.Platform == "" {
+		return fmt.Errorf("platform is required")
+	}
+	if baseConfig.AppCode == "" {
+		return fmt.Errorf("app_code is required")
+	}
+	if baseConfig.Product == "" {
+		return fmt.Errorf("product is required")
+	}
+	if baseConfig.Customer == "" {
+		return fmt.Errorf("customer is required")
+	}
+	if baseConfig.CL == "" {
+		return fmt.Errorf("cl is required")
+	}
+
+	// 检查是否已存在基础配置记录
+	var existingBaseConfig models.BaseConfig
+	err := ctx.DB.Where("client_id = ?", clientID).First(&existingBaseConfig).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// 记录不存在，创建新记录
+			log.Printf("📝 基础配置记录不存在，创建新记录")
+			baseConfig.ClientID = clientID
+			if err := ctx.DB.Create(&baseConfig).Error; err != nil {
+				return fmt.Errorf("failed to create base config in database: %v", err)
+			}
+			log.Printf("✅ 数据库记录创建成功")
+		} else {
+			return fmt.Errorf("failed to check existing base config: %v", err)
 		}
-		if baseConfig.Customer == "" {
-			return fmt.Errorf("customer is required")
+	} else {
+		// 记录存在，更新记录
+		log.Printf("📝 基础配置记录已存在，更新记录")
+		baseConfig.UpdatedAt = time.Now()
+
+		// 恢复历史修订时使用 Select 写入所有字段（包括空值），普通更新不覆盖未传入的字段
+		db := ctx.DB.Model(&existingBaseConfig)
+		if overwrite {
+			db = db.Select(baseConfigColumns)
 		}
-		if baseConfig.CL == "" {
-			return fmt.Errorf("cl is required")
+		if err := db.Updates(&baseConfig).Error; err != nil {
+			return fmt.Errorf("failed to update base config in database: %v", err)
 		}
+		log.Printf("✅ 数据库记录更新成功")
 
-		// 检查是否已存在基础配置记录
-		var existingBaseConfig models.BaseConfig
-		err := ctx.DB.Where("client_id = ?", clientID).First(&existingBaseConfig).Error
-
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 记录不存在，创建新记录
-				log.Printf("📝 基础配置记录不存在，创建新记录")
-				baseConfig.ClientID = clientID
-				if err := ctx.DB.Create(&baseConfig).Error; err != nil {
-					return fmt.Errorf("failed to create base config in database: %v", err)
-				}
-				log.Printf("✅ 数据库记录创建成功")
-			} else {
-				return fmt.Errorf("failed to check existing base config: %v", err)
-			}
-		} else {
-			// 记录存在，更新记录
-			log.Printf("📝 基础配置记录已存在，更新记录")
-			if err := ctx.DB.Model(&existingBaseConfig).Updates(baseConfig).Error; err != nil {
-				return fmt.Errorf("failed to update base config in database: %v", err)
-			}
-			log.Printf("✅ 数据库记录更新成功")
+		// 配置文件按更新后的完整记录生成
+		var updatedBaseConfig models.BaseConfig
+		if err := ctx.DB.First(&updatedBaseConfig, existingBaseConfig.ID).Error; err != nil {
+			return fmt.Errorf("failed to reload base config: %v", err)
 		}
+		baseConfig = updatedBaseConfig
+	}
 
-		// 更新本地配置文件
-		configFile := filepath.Join(s.config.File.BaseConfigsDir, brand.Code+".js")
-		hostConfig := s.FormatBaseConfig(baseConfig)
+	// 更新本地配置文件
+	configFile := filepath.Join(s.config.File.BaseConfigsDir, brand.Code+".js")
+	hostConfig := s.FormatBaseConfig(baseConfig)
 
-		configFileUtils := utils.NewConfigFileUtils()
-		if err := configFileUtils.UpdateConfigFileHost(ctx, configFile, hostConfig, client.Host); err != nil {
-			return fmt.Errorf("failed to update config file host: %v", err)
-		}
+	configFileUtils := utils.NewConfigFileUtils()
+	if err := configFileUtils.UpdateConfigFileHost(ctx, configFile, hostConfig, client.Host); err != nil {
+		return fmt.Errorf("failed to update config file host: %v", err)
+	}
 
-		log.Printf("📝 调用writeBaseConfigToFile...")
-		log.Printf("📝 准备写入配置文件: %s", configFile)
-		log.Printf("📖 读取现有配置文件...")
+	log.Printf("📝 调用writeBaseConfigToFile...")
+	log.Printf("📝 准备写入配置文件: %s", configFile)
+	log.Printf("📖 读取现有配置文件...")
 
-		log.Printf("✅ 基础配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
-		return nil
-	}, nil)
+	log.Printf("✅ 基础配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
+	return nil
 }
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"brand-config-api/config"
+	"brand-config-api/database"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+	"brand-config-api/utils/rollback"
+
+	"gorm.io/gorm"
+)
+
+// 导入项的处理方式
+const (
+	BrandImportActionCreate  = "create"  // 创建客户端及全部配置
+	BrandImportActionPartial = "partial" // 客户端已存在，只补充缺少的配置
+	BrandImportActionSkip    = "skip"    // 没有可导入的内容
+)
+
+// BrandImportRequest 品牌导入请求
+type BrandImportRequest struct {
+	TypeID uint     `json:"type_id" binding:"required"` // 新建品牌使用的类型
+	Brands []string `json:"brands"`                     // 只导入指定品牌，为空时导入全部
+	DryRun bool     `json:"dry_run"`                    // 只生成报告，不写入数据库
+}
+
+// BrandImportConflict 导入冲突（已存在的数据不会被覆盖）
+type BrandImportConflict struct {
+	Section string `json:"section,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// BrandImportItem 单个品牌+端的导入计划
+type BrandImportItem struct {
+	BrandCode      string                `json:"brand_code"`
+	Host           string                `json:"host"`
+	Action         string                `json:"action"`
+	BrandExists    bool                  `json:"brand_exists"`
+	ClientExists   bool                  `json:"client_exists"`
+	ClientID       int                   `json:"client_id,omitempty"`
+	Sections       []string              `json:"sections"`        // 将要创建的配置块
+	ViteBasePath   bool                  `json:"vite_base_path"`  // vite.config.js 中存在 basePathMap 记录
+	PackageScripts bool                  `json:"package_scripts"` // package.json 中存在 dev/build 脚本
+	Conflicts      []BrandImportConflict `json:"conflicts"`
+
+	blocks map[string]map[string]interface{}
+}
+
+// BrandImportReport 品牌导入报告
+type BrandImportReport struct {
+	DryRun         bool              `json:"dry_run"`
+	NewBrands      []string          `json:"new_brands"`
+	Items          []BrandImportItem `json:"items"`
+	CreatedClients int               `json:"created_clients"`
+	CreatedConfigs int               `json:"created_configs"`
+	Conflicts      int               `json:"conflicts"`
+	Errors         []string          `json:"errors"` // 无法解析的文件
+}
+
+// BrandImportService 从funNovel项目导入已有品牌的服务
+type BrandImportService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewBrandImportService 创建品牌导入服务实例
+func NewBrandImportService() *BrandImportService {
+	return &BrandImportService{
+		db:     database.DB,
+		config: config.Load(),
+	}
+}
+
+// ImportBrands 扫描funNovel项目中的配置文件，为每个品牌+端创建品牌、客户端和配置记录
+// 已存在的记录不会被覆盖，而是作为冲突报告；DryRun 时只返回导入计划
+func (s *BrandImportService) ImportBrands(req BrandImportRequest) (*BrandImportReport, error) {
+	var typeData models.Type
+	if err := s.db.First(&typeData, req.TypeID).Error; err != nil {
+		return nil, fmt.Errorf("type not found: %d", req.TypeID)
+	}
+
+	// 导入会读取整个工作区的配置文件，执行时锁定工作区，保证读取和写入数据库期间文件不变
+	if !req.DryRun {
+		release, err := acquireWorkspaceLock(s.config, "ImportBrands", utils.LockKeyWorkspace)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	report, err := s.plan(req.Brands)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = req.DryRun
+	if req.DryRun {
+		log.Printf("🧪 品牌导入预演完成: %d 个品牌+端, %d 个冲突", len(report.Items), report.Conflicts)
+		return report, nil
+	}
+
+	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		brandIDs := make(map[string]int)
+		for i := range report.Items {
+			if err := s.importItem(ctx, &report.Items[i], req.TypeID, brandIDs, report); err != nil {
+				return fmt.Errorf("failed to import %s-%s: %v", report.Items[i].Host, report.Items[i].BrandCode, err)
+			}
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ 品牌导入完成: 新建品牌 %d 个, 客户端 %d 个, 配置 %d 条, 冲突 %d 个",
+		len(report.NewBrands), report.CreatedClients, report.CreatedConfigs, report.Conflicts)
+	return report, nil
+}
+
+// plan 扫描配置文件并与数据库比较，生成导入计划
+func (s *BrandImportService) plan(brandFilter []string) (*BrandImportReport, error) {
+	report := &BrandImportReport{
+		NewBrands: []string{},
+		Items:     []BrandImportItem{},
+		Errors:    []string{},
+	}
+
+	// brandCode -> host -> section -> 配置块
+	found := make(map[string]map[string]map[string]map[string]interface{})
+	addBlock := func(brandCode, host, section string, block interface{}) {
+		blockMap, ok := block.(map[string]interface{})
+		if !ok {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s config of %s-%s is not an object", section, host, brandCode))
+			return
+		}
+		if found[brandCode] == nil {
+			found[brandCode] = make(map[string]map[string]map[string]interface{})
+		}
+		if found[brandCode][host] == nil {
+			found[brandCode][host] = make(map[string]map[string]interface{})
+		}
+		found[brandCode][host][section] = blockMap
+	}
+
+	// 1. 各品牌的配置文件：<dir>/<brandCode>.js，顶层key为host
+	for section, dir := range map[string]string{
+		models.ConfigSectionBase:   s.config.File.BaseConfigsDir,
+		models.ConfigSectionCommon: s.config.File.CommonConfigsDir,
+		models.ConfigSectionPay:    s.config.File.PayConfigsDir,
+		models.ConfigSectionUI:     s.config.File.UIConfigsDir,
+	} {
+		files, err := filepath.Glob(filepath.Join(dir, "*.js"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %v", dir, err)
+		}
+		for _, file := range files {
+			brandCode := strings.TrimSuffix(filepath.Base(file), ".js")
+			data, err := s.readConfigFile(file)
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+				continue
+			}
+			for host, block := range data {
+				addBlock(brandCode, host, section, block)
+			}
+		}
+	}
+
+	// 2. novelConfig.js：brandCode -> host
+	novelConfigFile := filepath.Join(s.config.File.LocalConfigsDir, "novelConfig.js")
+	if data, err := s.readConfigFile(novelConfigFile); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else {
+		for brandCode, brandConfig := range data {
+			hosts, ok := brandConfig.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for host, block := range hosts {
+				addBlock(brandCode, host, models.ConfigSectionNovel, block)
+			}
+		}
+	}
+
+	// 3. vite.config.js 和 package.json 中已注册的平台
+	viteKeys := make(map[string]bool)
+	if content, err := os.ReadFile(s.config.File.ViteConfigFile); err == nil {
+		if editor, err := utils.NewBasePathMapEditor(string(content)); err == nil {
+			entries, _ := editor.List()
+			for _, entry := range entries {
+				viteKeys[entry.Key] = true
+			}
+		} else {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+
+	var packageEditor *utils.JSONEditor
+	if content, err := os.ReadFile(s.config.File.PackageFile); err == nil {
+		if packageEditor, err = utils.NewJSONEditor(string(content)); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to parse package.json: %v", err))
+		}
+	}
+
+	// 4. 与数据库比较
+	filter := make(map[string]bool)
+	for _, brandCode := range brandFilter {
+		filter[brandCode] = true
+	}
+
+	brandCodes := make([]string, 0, len(found))
+	for brandCode := range found {
+		if len(filter) == 0 || filter[brandCode] {
+			brandCodes = append(brandCodes, brandCode)
+		}
+	}
+	sort.Strings(brandCodes)
+
+	for _, brandCode := range brandCodes {
+		var brand models.Brand
+		brandErr := s.db.Where("code = ?", brandCode).First(&brand).Error
+		if brandErr != nil && !errors.Is(brandErr, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to query brand %s: %v", brandCode, brandErr)
+		}
+		brandExists := brandErr == nil
+		if !brandExists {
+			report.NewBrands = append(report.NewBrands, brandCode)
+		}
+
+		hosts := make([]string, 0, len(found[brandCode]))
+		for host := range found[brandCode] {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+
+		for _, host := range hosts {
+			platformKey := fmt.Sprintf("%s-%s", host, brandCode)
+			item := BrandImportItem{
+				BrandCode:    brandCode,
+				Host:         host,
+				Action:       BrandImportActionCreate,
+				BrandExists:  brandExists,
+				Sections:     []string{},
+				ViteBasePath: viteKeys[platformKey],
+				Conflicts:    []BrandImportConflict{},
+				blocks:       found[brandCode][host],
+			}
+			if packageEditor != nil {
+				item.PackageScripts = packageEditor.Has("scripts", "dev:"+platformKey)
+			}
+
+			if err := s.planItem(&item, brand.ID); err != nil {
+				return nil, err
+			}
+			report.Conflicts += len(item.Conflicts)
+			report.Items = append(report.Items, item)
+		}
+	}
+
+	return report, nil
+}
+
+// planItem 确定单个品牌+端需要创建的配置块和冲突
+func (s *BrandImportService) planItem(item *BrandImportItem, brandID int) error {
+	if _, ok := utils.GetPlatformRegistry().Get(item.Host); !ok {
+		item.Action = BrandImportActionSkip
+		item.Conflicts = append(item.Conflicts, BrandImportConflict{Reason: "未注册的端类型: " + item.Host})
+		return nil
+	}
+
+	if item.BrandExists {
+		var client models.Client
+		err := s.db.Where("brand_id = ? AND host = ?", brandID, item.Host).First(&client).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to query client %s-%s: %v", item.Host, item.BrandCode, err)
+		}
+		if err == nil {
+			item.ClientExists = true
+			item.ClientID = client.ID
+			item.Action = BrandImportActionPartial
+		}
+	}
+
+	for _, section := range driftSections {
+		if _, ok := item.blocks[section]; !ok {
+			continue
+		}
+
+		if item.ClientExists {
+			record, err := newSectionModel(section)
+			if err != nil {
+				return err
+			}
+			var count int64
+			if err := s.db.Model(record).Where("client_id = ?", item.ClientID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to query %s config: %v", section, err)
+			}
+			if count > 0 {
+				item.Conflicts = append(item.Conflicts, BrandImportConflict{Section: section, Reason: "数据库中已存在该配置，不会覆盖"})
+				continue
+			}
+		}
+		item.Sections = append(item.Sections, section)
+	}
+
+	if item.ClientExists && len(item.Sections) == 0 {
+		item.Action = BrandImportActionSkip
+	}
+	return nil
+}
+
+// importItem 在事务中创建品牌、客户端和配置记录
+func (s *BrandImportService) importItem(ctx *rollback.TransactionContext, item *BrandImportItem, typeID uint, brandIDs map[string]int, report *BrandImportReport) error {
+	if item.Action == BrandImportActionSkip {
+		return nil
+	}
+
+	// 品牌：同一次导入中只创建一次
+	brandID, exists := brandIDs[item.BrandCode]
+	if !exists {
+		var brand models.Brand
+		err := ctx.DB.Where("code = ?", item.BrandCode).First(&brand).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			brand = models.Brand{Code: item.BrandCode, TypeID: typeID}
+			if err := ctx.DB.Create(&brand).Error; err != nil {
+				return fmt.Errorf("failed to create brand: %v", err)
+			}
+			log.Printf("📝 导入品牌: %s", item.BrandCode)
+		} else if err != nil {
+			return fmt.Errorf("failed to query brand: %v", err)
+		}
+		brandID = brand.ID
+		brandIDs[item.BrandCode] = brandID
+	}
+
+	if !item.ClientExists {
+		client := models.Client{BrandID: brandID, Host: item.Host}
+		if err := ctx.DB.Create(&client).Error; err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+		item.ClientID = client.ID
+		report.CreatedClients++
+	}
+
+	for _, section := range item.Sections {
+		record, err := newSectionModel(section)
+		if err != nil {
+			return err
+		}
+		applyConfigBlock(record, item.ClientID, item.blocks[section])
+		if err := createImportedRecord(ctx.DB, record); err != nil {
+			return fmt.Errorf("failed to create %s config: %v", section, err)
+		}
+		report.CreatedConfigs++
+	}
+
+	log.Printf("✅ 导入客户端: brand=%s, host=%s, client=%d, sections=%s",
+		item.BrandCode, item.Host, item.ClientID, strings.Join(item.Sections, ","))
+	return nil
+}
+
+// readConfigFile 读取并解析配置文件，文件不存在时返回空配置
+func (s *BrandImportService) readConfigFile(configFile string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %v", configFile, err)
+	}
+
+	data, err := utils.ParseJSConfig(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", configFile, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,916 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"brand-config-api/config"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+)
+
+// 原生流水线的进度区间：准备阶段到 20%，各项目平分 20%-95%
+const (
+	pipelinePreparedPercentage = 20
+	pipelineDonePercentage     = 95
+)
+
+// buildJob 流水线中的一个构建项：某个环境下的某个项目
+type buildJob struct {
+	env      string // master、release、local 等
+	project  string // 如 tth5-xingchen
+	host     string // 如 tth5
+	platform string // 端的额外端，如 tt；没有额外端时与 host 相同
+	website  string // 如 xingchen
+	err      error  // 项目名称无法解析时的错误
+}
+
+// key 构建结果中的键
+func (j buildJob) key() string {
+	return j.env + "/" + j.project
+}
+
+// isLocal local环境打包为zip并部署到远程服务器，其他环境发布到发布仓库
+func (j buildJob) isLocal() bool {
+	return j.env == "local"
+}
+
+// commandError 流水线中的命令执行失败，保留退出码和输出用于项目结果
+type commandError struct {
+	command  string
+	exitCode int
+	output   string
+	err      error
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("%s 执行失败: %v", e.command, e.err)
+}
+
+// buildStep 项目构建中的一个步骤
+type buildStep struct {
+	name string
+	run  func() error
+}
+
+// buildPipeline 原生构建流水线：检出分支、yarn install，
+// 再逐个项目修改配置、yarn build:<项目>、打包，最后发布到发布仓库或部署到远程服务器
+type buildPipeline struct {
+	service  *BuildService
+	cfg      config.BuildConfig
+	req      *BatchBuildRequest
+	progress ProgressCallback
+
+	artifacts  *ArtifactService
+	commitSHA  string            // 检出的提交，记录到构建产物
+	logSummary *utils.LogSummary // 命令输出的分类汇总
+
+	env  []string // 命令的环境变量
+	git  string
+	yarn string
+
+	sourceDir  string // 源码检出目录
+	publishDir string // 发布仓库检出目录
+	distDir    string // 构建产物目录
+	logPath    string
+
+	logFile *os.File
+	logMu   sync.Mutex
+}
+
+// executePipelineBuild 通过原生流水线执行构建，结果按 环境/项目 写入 result
+func (s *BuildService) executePipelineBuild(ctx context.Context, req *BatchBuildRequest, result *BuildResult, progressCallback ProgressCallback) error {
+	p, err := s.newBuildPipeline(req, progressCallback)
+	if err != nil {
+		if progressCallback != nil {
+			progressCallback(BuildProgress{
+				Percentage: 5,
+				Status:     "failed",
+				Text:       "环境验证失败",
+				Detail:     err.Error(),
+			})
+		}
+		return fmt.Errorf("build environment validation failed: %v", err)
+	}
+	result.OutputPath = p.distDir
+	result.LogPath = p.logPath
+	p.logSummary = result.LogSummary
+
+	defer p.close()
+
+	if err := p.prepare(ctx); err != nil {
+		if ctx.Err() != nil {
+			return p.cancelled(ctx)
+		}
+		p.emit(BuildProgress{
+			Percentage: 0,
+			Status:     "failed",
+			Text:       "准备构建环境失败",
+			Detail:     err.Error(),
+			Output:     fmt.Sprintf("❌ 准备构建环境失败: %v", err),
+		})
+		return err
+	}
+
+	jobs := expandBuildJobs(p.req)
+	for i, job := range jobs {
+		percentage := pipelinePreparedPercentage + i*(pipelineDonePercentage-pipelinePreparedPercentage)/len(jobs)
+		projectResult := p.buildProject(ctx, job, percentage)
+		result.Results[job.key()] = projectResult
+		if !projectResult.Success {
+			result.Success = false
+		}
+		if ctx.Err() != nil {
+			return p.cancelled(ctx)
+		}
+	}
+
+	return nil
+}
+
+// newBuildPipeline 检查工具链并创建构建流水线
+func (s *BuildService) newBuildPipeline(req *BatchBuildRequest, progressCallback ProgressCallback) (*buildPipeline, error) {
+	cfg := s.config.Build
+	p := &buildPipeline{
+		service:    s,
+		artifacts:  NewArtifactService(),
+		cfg:        cfg,
+		req:        req,
+		progress:   progressCallback,
+		env:        append(buildCommandEnv(cfg.NodeBinDir), "GIT_TERMINAL_PROMPT=0"),
+		sourceDir:  filepath.Join(cfg.WorkspaceDir, "funNovel"),
+		publishDir: filepath.Join(cfg.WorkspaceDir, "publish"),
+		distDir:    filepath.Join(cfg.WorkspaceDir, "dist_backup"),
+		logPath:    filepath.Join(cfg.WorkspaceDir, "realtime.log"),
+	}
+
+	var err error
+	if p.git, err = p.lookTool(cfg.GitBin); err != nil {
+		return nil, err
+	}
+	if p.yarn, err = p.lookTool(cfg.YarnBin); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// lookTool 查找命令：带路径的直接检查，否则依次在 Node.js 的bin目录和 PATH 中查找
+func (p *buildPipeline) lookTool(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) {
+		if _, err := os.Stat(name); err != nil {
+			return "", fmt.Errorf("未找到命令 %s: %v", name, err)
+		}
+		return name, nil
+	}
+
+	if p.cfg.NodeBinDir != "" {
+		candidate := filepath.Join(p.cfg.NodeBinDir, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	found, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("未找到命令 %s，请检查 BUILD_NODE_BIN_DIR 和 BUILD_*_BIN 配置: %v", name, err)
+	}
+	return found, nil
+}
+
+// prepare 初始化工作目录，准备源码仓库，检出分支并安装依赖
+func (p *buildPipeline) prepare(ctx context.Context) error {
+	p.emit(BuildProgress{
+		Percentage: 6,
+		Status:     "running",
+		Text:       "初始化工作目录...",
+		Detail:     p.cfg.WorkspaceDir,
+	})
+	if err := os.MkdirAll(p.cfg.WorkspaceDir, 0755); err != nil {
+		return fmt.Errorf("创建工作目录失败: %v", err)
+	}
+	if err := os.RemoveAll(p.distDir); err != nil {
+		return fmt.Errorf("清理构建产物目录失败: %v", err)
+	}
+	if err := os.MkdirAll(p.distDir, 0755); err != nil {
+		return fmt.Errorf("创建构建产物目录失败: %v", err)
+	}
+	logFile, err := os.Create(p.logPath)
+	if err != nil {
+		return fmt.Errorf("创建构建日志失败: %v", err)
+	}
+	p.logFile = logFile
+
+	if !utils.IsGitRepository(p.sourceDir) {
+		if p.cfg.SourceRepo == "" {
+			return fmt.Errorf("工作目录中没有源码仓库 %s，且未配置 BUILD_SOURCE_REPO", p.sourceDir)
+		}
+		p.emit(BuildProgress{
+			Percentage: 8,
+			Status:     "running",
+			Text:       "克隆源码仓库...",
+			Detail:     redactURL(p.cfg.SourceRepo),
+		})
+		if err := os.RemoveAll(p.sourceDir); err != nil {
+			return fmt.Errorf("清理源码目录失败: %v", err)
+		}
+		if err := p.run(ctx, p.cfg.WorkspaceDir, p.git, "clone", p.cfg.SourceRepo, p.sourceDir); err != nil {
+			return err
+		}
+	}
+
+	p.emit(BuildProgress{
+		Percentage: 10,
+		Status:     "running",
+		Text:       "检出分支...",
+		Detail:     p.req.Branch,
+	})
+	if err := p.run(ctx, p.sourceDir, p.git, "fetch", "origin", p.req.Branch); err != nil {
+		return err
+	}
+	if err := p.run(ctx, p.sourceDir, p.git, "checkout", "-f", "-B", p.req.Branch, "origin/"+p.req.Branch); err != nil {
+		return err
+	}
+	if err := p.run(ctx, p.sourceDir, p.git, "clean", "-df"); err != nil {
+		return err
+	}
+	commitSHA, err := exec.CommandContext(ctx, p.git, "-C", p.sourceDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return fmt.Errorf("获取当前提交失败: %v", err)
+	}
+	p.commitSHA = strings.TrimSpace(string(commitSHA))
+
+	p.emit(BuildProgress{
+		Percentage: 14,
+		Status:     "running",
+		Text:       "安装依赖...",
+		Detail:     "yarn install",
+	})
+	if err := p.run(ctx, p.sourceDir, p.yarn, "install"); err != nil {
+		return err
+	}
+
+	p.emit(BuildProgress{
+		Percentage: pipelinePreparedPercentage,
+		Status:     "running",
+		Text:       "构建环境准备完成",
+		Detail:     fmt.Sprintf("分支 %s，%d 个项目", p.req.Branch, len(p.req.Projects)),
+	})
+	return nil
+}
+
+// expandBuildJobs 展开环境和项目：环境在外层，项目在内层
+func expandBuildJobs(req *BatchBuildRequest) []buildJob {
+	var jobs []buildJob
+	for _, env := range strings.Split(req.Environment, ",") {
+		env = strings.TrimSpace(env)
+		if env == "" {
+			continue
+		}
+		for _, project := range req.Projects {
+			job := buildJob{env: env, project: project}
+			job.host, job.platform, job.website, job.err = parseBuildProject(project)
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// parseBuildProject 解析项目名称，如 tth5-xingchen 解析为端 tth5、平台 tt（端的额外端）、站点 xingchen
+func parseBuildProject(project string) (host string, platform string, website string, err error) {
+	host, website, ok := strings.Cut(project, "-")
+	if !ok || host == "" || website == "" {
+		return "", "", "", fmt.Errorf("无效的项目名称: %s，应为 <端>-<站点>", project)
+	}
+	registry := utils.GetPlatformRegistry()
+	if _, exists := registry.Get(host); !exists {
+		return "", "", "", fmt.Errorf("无效的项目名称: %s，端 %s 未注册", project, host)
+	}
+	platform = registry.ExtraHost(host)
+	if platform == "" {
+		platform = host
+	}
+	return host, platform, website, nil
+}
+
+// hasExtraHost 端是否有额外端（如 tth5 的 tt）
+func (j buildJob) hasExtraHost() bool {
+	return j.platform != j.host
+}
+
+// buildProject 构建单个项目，记录每个步骤的耗时和错误，某一步失败时跳过后续步骤
+func (p *buildPipeline) buildProject(ctx context.Context, job buildJob, percentage int) ProjectResult {
+	start := time.Now()
+	result := ProjectResult{
+		Project:     job.project,
+		Environment: job.env,
+		StartTime:   start.Format("2006-01-02 15:04:05"),
+	}
+
+	p.emit(BuildProgress{
+		Percentage: percentage,
+		Status:     "running",
+		Text:       fmt.Sprintf("构建项目 %s", job.project),
+		Detail:     fmt.Sprintf("环境: %s", job.env),
+		Project:    job.project,
+		Output:     fmt.Sprintf("🔄 处理项目: %s (环境: %s)", job.project, job.env),
+	})
+
+	steps := []buildStep{
+		{"checkout", func() error {
+			if job.err != nil {
+				return job.err
+			}
+			return p.resetSource(ctx)
+		}},
+		{"config", func() error { return p.modifyConfig(job) }},
+		{"build", func() error { return p.runBuildScript(ctx, job) }},
+		{"package", func() error {
+			artifact, err := p.packageArtifact(job)
+			result.Artifact = artifact
+			return err
+		}},
+		// 归档失败不影响发布和部署
+		{"archive", func() error {
+			artifact, err := p.archive(job, result.Artifact)
+			if err != nil {
+				log.Printf("⚠️ 构建产物归档失败: %s (%s) - %v", job.project, job.env, err)
+				p.emit(BuildProgress{
+					Percentage: -999,
+					Status:     "running",
+					Project:    job.project,
+					Output:     fmt.Sprintf("⚠️ 构建产物归档失败，继续执行: %v", err),
+				})
+				return nil
+			}
+			result.ArtifactID = artifact.ID
+			return nil
+		}},
+	}
+	if job.isLocal() {
+		steps = append(steps, buildStep{"deploy", func() error { return p.deploy(ctx, job, result.Artifact) }})
+	} else {
+		steps = append(steps, buildStep{"publish", func() error { return p.publish(ctx, job, result.Artifact) }})
+	}
+
+	for _, step := range steps {
+		stepStart := time.Now()
+		err := step.run()
+		stepResult := BuildStepResult{
+			Name:     step.name,
+			Success:  err == nil,
+			Duration: time.Since(stepStart).Round(time.Millisecond).String(),
+		}
+		if err != nil {
+			stepResult.Error = err.Error()
+			result.Error = fmt.Sprintf("%s: %v", step.name, err)
+		}
+		result.Steps = append(result.Steps, stepResult)
+		if err != nil {
+			var cmdErr *commandError
+			if errors.As(err, &cmdErr) {
+				result.ExitCode = cmdErr.exitCode
+				result.ErrorExcerpt = tailLines(cmdErr.output, errorExcerptLines)
+			} else {
+				result.ExitCode = resultExitFailed
+			}
+			break
+		}
+	}
+
+	elapsed := time.Since(start)
+	result.Success = result.Error == ""
+	result.EndTime = time.Now().Format("2006-01-02 15:04:05")
+	result.Duration = elapsed.Round(time.Millisecond).String()
+	result.DurationMs = elapsed.Milliseconds()
+
+	if result.Success {
+		p.emit(BuildProgress{
+			Percentage: -999,
+			Status:     "running",
+			Project:    job.project,
+			Output:     fmt.Sprintf("✅ 项目构建成功: %s (环境: %s, 耗时: %s)", job.project, job.env, result.Duration),
+		})
+	} else {
+		p.emit(BuildProgress{
+			Percentage: -999,
+			Status:     "running",
+			Project:    job.project,
+			Output:     fmt.Sprintf("ERROR: 项目构建失败: %s (环境: %s): %s", job.project, job.env, result.Error),
+		})
+	}
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     FormatResultLine(result),
+		Result:     &result,
+	})
+	return result
+}
+
+// resetSource 撤销上一个项目对源码的修改
+func (p *buildPipeline) resetSource(ctx context.Context) error {
+	if err := p.run(ctx, p.sourceDir, p.git, "reset", "--hard", "HEAD"); err != nil {
+		return err
+	}
+	return p.run(ctx, p.sourceDir, p.git, "clean", "-df")
+}
+
+// versionPattern baseConfigs 中的版本号
+var versionPattern = regexp.MustCompile(`"version": ".*"`)
+
+const (
+	testAdSlot        = "tt_h5_xingchen_product_test" // 本地测试使用的测试策略广告位
+	douyinUniPlatform = "mp-toutiao"                  // 加载抖音开放平台SDK的额外端
+)
+
+// modifyConfig 按环境和平台修改源码中的配置（版本号、测试开关、测试广告位等）
+func (p *buildPipeline) modifyConfig(job buildJob) error {
+	version := p.req.Version
+	testIcon := `<view v-if="test_enabled" class="absolute testIcon">测试环境</view>`
+
+	type edit struct {
+		file     string
+		old, new string
+	}
+	var edits []edit
+
+	if job.env == "master" || job.env == "local" {
+		label := "测试环境" + version
+		if job.env == "local" {
+			label = "公司内网-测试环境" + version
+		}
+		edits = append(edits,
+			edit{"src/appConfig/localConfigs/base.js", `"webLogin": false`, `"webLogin": true`},
+			edit{"src/appConfig/localConfigs/base.js", `"test_enabled": false`, `"test_enabled": true`},
+			edit{"src/modules/base/antiDebug.js", `const vconsole_enabled = ret != '' ? ret : false`, `const vconsole_enabled = true`},
+			edit{"src/pages/readerPage/readerPage.vue", testIcon, strings.Replace(testIcon, "测试环境", label, 1)},
+			edit{"src/pages/userInfo/userInfo.vue", testIcon, strings.Replace(testIcon, "测试环境", label, 1)},
+		)
+	}
+	if job.isLocal() {
+		if p.req.ForceForeign {
+			edits = append(edits, edit{"src/appConfig/localConfigs/base.js", `"force_foreign": false,`, `"force_foreign": true,`})
+		}
+		// 替换为测试策略广告位，广告位按额外端区分
+		if job.hasExtraHost() {
+			commonConfig := filepath.ToSlash(filepath.Join("src/appConfig/commonConfigs", job.website+".js"))
+			edits = append(edits, edit{commonConfig, job.platform + "_h5_xingchen_business_type", testAdSlot})
+		}
+	}
+	// 额外端不是抖音小程序时不加载抖音开放平台SDK
+	if job.hasExtraHost() && utils.GetPlatformRegistry().UniPlatform(job.platform) != douyinUniPlatform {
+		edits = append(edits, edit{"index.html", `<script src="/douyin_open.umd.js"></script>`, `<!-- <script src="/douyin_open.umd.js"></script> -->`})
+	}
+
+	baseConfig := filepath.Join(p.sourceDir, "src/appConfig/baseConfigs", job.website+".js")
+	if err := editSourceFile(baseConfig, func(content string) string {
+		return versionPattern.ReplaceAllString(content, fmt.Sprintf(`"version": "%s"`, version))
+	}); err != nil {
+		return err
+	}
+	for _, e := range edits {
+		if err := editSourceFile(filepath.Join(p.sourceDir, e.file), func(content string) string {
+			return strings.ReplaceAll(content, e.old, e.new)
+		}); err != nil {
+			return err
+		}
+	}
+
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     fmt.Sprintf("⚙️ 配置修改完成: %s (%s) - %s，版本 %s", job.website, job.platform, job.env, version),
+	})
+	return nil
+}
+
+// editSourceFile 读取并改写源码文件
+func editSourceFile(file string, edit func(string) string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	if err := os.WriteFile(file, []byte(edit(string(data))), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	return nil
+}
+
+// runBuildScript 执行 package.json 中的 build:<项目> 脚本（prebuild 后 uni build --minify，与构建脚本一致）
+func (p *buildPipeline) runBuildScript(ctx context.Context, job buildJob) error {
+	if err := os.RemoveAll(filepath.Join(p.sourceDir, "dist")); err != nil {
+		return fmt.Errorf("清理dist目录失败: %v", err)
+	}
+	return p.run(ctx, p.sourceDir, p.yarn, "run", "build:"+job.project)
+}
+
+// packageArtifact 将 dist/build/<站点>/h5 拷贝到构建产物目录，local环境再压缩为zip，返回产物路径
+func (p *buildPipeline) packageArtifact(job buildJob) (string, error) {
+	buildDir := filepath.Join(p.sourceDir, "dist", "build", job.website, "h5")
+	entries, err := os.ReadDir(buildDir)
+	if err != nil || len(entries) == 0 {
+		return "", fmt.Errorf("构建产物 dist/build/%s/h5 不存在或为空", job.website)
+	}
+
+	name := job.env + "-" + job.project
+	if job.isLocal() {
+		name = job.project
+	}
+	artifactDir := filepath.Join(p.distDir, name)
+	if err := os.RemoveAll(artifactDir); err != nil {
+		return "", fmt.Errorf("清理构建产物目录失败: %v", err)
+	}
+	if err := os.MkdirAll(artifactDir, 0755); err != nil {
+		return "", fmt.Errorf("创建构建产物目录失败: %v", err)
+	}
+	if err := utils.NewFileUtils().CopyDirectory(buildDir, artifactDir); err != nil {
+		return "", fmt.Errorf("拷贝构建产物失败: %v", err)
+	}
+
+	if !job.isLocal() {
+		return artifactDir, nil
+	}
+
+	zipPath := artifactDir + ".zip"
+	if err := zipDirectory(artifactDir, zipPath); err != nil {
+		return "", fmt.Errorf("创建zip包失败: %v", err)
+	}
+	os.RemoveAll(artifactDir)
+
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     fmt.Sprintf("📦 zip包创建成功: %s", zipPath),
+	})
+	return zipPath, nil
+}
+
+// zipModTime zip中所有文件的修改时间，相同的构建输出生成相同的zip（校验和可用于识别和去重）
+var zipModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// zipDirectory 将目录内容压缩为zip文件（不含目录本身），文件按路径排序并使用固定的修改时间
+func zipDirectory(src, dst string) error {
+	var files []string
+	err := filepath.WalkDir(src, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	file, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, name := range files {
+		if err := addZipFile(writer, filepath.Join(src, filepath.FromSlash(name)), name); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// addZipFile 将一个文件写入zip
+func addZipFile(writer *zip.Writer, filePath, name string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	header.Modified = zipModTime
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// archive 将打包后的构建产物归档到产物库
+func (p *buildPipeline) archive(job buildJob, artifactPath string) (*models.BuildArtifact, error) {
+	artifact, err := p.artifacts.ArchiveArtifact(artifactPath, ArtifactInfo{
+		Project:     job.project,
+		Environment: job.env,
+		Branch:      p.req.Branch,
+		CommitSHA:   p.commitSHA,
+		Version:     p.req.Version,
+		TaskID:      p.req.TaskID,
+		CreatedBy:   p.req.Requester,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     fmt.Sprintf("🗄️ 构建产物已归档: #%d (sha256 %s, %d 字节)", artifact.ID, artifact.Checksum, artifact.Size),
+	})
+	return artifact, nil
+}
+
+// legacyPublishBranches 发布仓库中早于命名规则创建的分支，键为 <环境>/<项目>（与原构建脚本保持一致）
+var legacyPublishBranches = map[string]string{
+	"master/tth5-xingchen":  "master",
+	"release/tth5-xingchen": "releaase",
+	"master/ksh5-xingchen":  "master_ks",
+	"release/ksh5-xingchen": "release_ks",
+}
+
+// publishBranch 发布仓库中的目标分支：<环境>_<平台>_<站点>
+func publishBranch(job buildJob) string {
+	if branch, ok := legacyPublishBranches[job.env+"/"+job.project]; ok {
+		return branch
+	}
+	return fmt.Sprintf("%s_%s_%s", job.env, job.platform, job.website)
+}
+
+// publish 将构建产物提交并推送到发布仓库的目标分支
+func (p *buildPipeline) publish(ctx context.Context, job buildJob, artifactDir string) error {
+	if p.cfg.PublishRepo == "" {
+		return fmt.Errorf("未配置 BUILD_PUBLISH_REPO，无法发布 %s 环境", job.env)
+	}
+
+	branch := publishBranch(job)
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     fmt.Sprintf("🚀 发布到远程仓库: %s -> %s", job.project, branch),
+	})
+
+	if err := os.RemoveAll(p.publishDir); err != nil {
+		return fmt.Errorf("清理发布目录失败: %v", err)
+	}
+	if err := p.run(ctx, p.cfg.WorkspaceDir, p.git, "clone", "--depth=1", "-b", branch, p.cfg.PublishRepo, p.publishDir); err != nil {
+		return err
+	}
+
+	distDir := filepath.Join(p.publishDir, "dist")
+	if err := os.RemoveAll(distDir); err != nil {
+		return fmt.Errorf("清理发布仓库dist目录失败: %v", err)
+	}
+	if err := os.MkdirAll(distDir, 0755); err != nil {
+		return fmt.Errorf("创建发布仓库dist目录失败: %v", err)
+	}
+	if err := utils.NewFileUtils().CopyDirectory(artifactDir, distDir); err != nil {
+		return fmt.Errorf("拷贝构建产物到发布仓库失败: %v", err)
+	}
+
+	if err := p.run(ctx, p.publishDir, p.git, "add", "-A"); err != nil {
+		return err
+	}
+	changed, err := p.hasStagedChanges(ctx)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := p.run(ctx, p.publishDir, p.git,
+			"-c", "user.name="+p.cfg.GitUserName,
+			"-c", "user.email="+p.cfg.GitUserEmail,
+			"commit", "-m", p.req.Version); err != nil {
+			return err
+		}
+	} else {
+		p.emit(BuildProgress{
+			Percentage: -999,
+			Status:     "running",
+			Project:    job.project,
+			Output:     "⚠️ WARNING: 没有变更需要提交",
+		})
+	}
+
+	return p.run(ctx, p.publishDir, p.git, "push", "origin", branch)
+}
+
+// hasStagedChanges 发布仓库是否有已暂存的变更
+func (p *buildPipeline) hasStagedChanges(ctx context.Context) (bool, error) {
+	cmd := exec.CommandContext(ctx, p.git, "diff", "--cached", "--quiet")
+	cmd.Dir = p.publishDir
+	cmd.Env = p.env
+	err := cmd.Run()
+	if err == nil {
+		return false, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, fmt.Errorf("检查发布仓库变更失败: %v", err)
+}
+
+// deploy 通过SSH上传zip包并在远程服务器解压到 <远程目录>/<项目>/dist
+func (p *buildPipeline) deploy(ctx context.Context, job buildJob, zipPath string) error {
+	cfg := p.service.config
+	server := ServerInfo{
+		Host:     p.req.SSHHost,
+		Port:     cfg.Deploy.DefaultSSHPort,
+		Username: p.req.SSHUser,
+		Password: p.req.SSHPassword,
+	}
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     fmt.Sprintf("🚀 部署到远程服务器: %s@%s", server.Username, server.Host),
+	})
+
+	client, err := NewDeployService().createSSHClient(server, time.Duration(cfg.Deploy.SSHTimeout)*time.Second)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	// 取消时关闭SSH连接，结束正在执行的上传或远程命令
+	stop := context.AfterFunc(ctx, func() {
+		client.Close()
+	})
+	defer stop()
+
+	zipName := job.project + ".zip"
+	remoteZip := path.Join(p.cfg.RemoteBasePath, zipName)
+
+	// 上传zip包
+	file, err := os.Open(zipPath)
+	if err != nil {
+		return fmt.Errorf("打开zip包失败: %v", err)
+	}
+	defer file.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("创建SSH会话失败: %v", err)
+	}
+	session.Stdin = file
+	output, err := session.CombinedOutput(fmt.Sprintf("mkdir -p %s && cat > %s", shellQuote(p.cfg.RemoteBasePath), shellQuote(remoteZip)))
+	session.Close()
+	if err != nil {
+		return fmt.Errorf("上传zip包失败: %v %s", err, strings.TrimSpace(string(output)))
+	}
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     fmt.Sprintf("✅ zip包上传完成: %s:%s", server.Host, remoteZip),
+	})
+
+	// 解压到 dist 目录并验证
+	project := shellQuote(job.project)
+	script := strings.Join([]string{
+		"set -e",
+		"cd " + shellQuote(p.cfg.RemoteBasePath),
+		fmt.Sprintf("rm -rf %s 2>/dev/null || sudo rm -rf %s", project, project),
+		fmt.Sprintf("mkdir -p %s/dist", project),
+		fmt.Sprintf("unzip -q -o %s -d %s/dist", shellQuote(zipName), project),
+		"rm -f " + shellQuote(zipName),
+		fmt.Sprintf("[ -f %s/dist/index.html ] || { echo '❌ index.html 不存在于dist目录'; exit 1; }", project),
+		fmt.Sprintf(`echo "✅ 部署完成，共 $(find %s/dist -type f | wc -l) 个文件"`, project),
+	}, "\n")
+
+	session, err = client.NewSession()
+	if err != nil {
+		return fmt.Errorf("创建SSH会话失败: %v", err)
+	}
+	output, err = session.CombinedOutput(script)
+	session.Close()
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			p.emit(BuildProgress{Percentage: -999, Status: "running", Project: job.project, Output: line})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("远程解压部署失败: %v", err)
+	}
+
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Project:    job.project,
+		Output:     fmt.Sprintf("🌐 访问地址: http://%s/%s/dist/", server.Host, job.project),
+	})
+	return nil
+}
+
+// run 在指定目录执行命令，输出实时推送并写入构建日志
+func (p *buildPipeline) run(ctx context.Context, dir string, name string, args ...string) error {
+	display := make([]string, len(args))
+	for i, arg := range args {
+		display[i] = redactURL(arg)
+	}
+	commandLine := filepath.Base(name) + " " + strings.Join(display, " ")
+	p.emit(BuildProgress{
+		Percentage: -999,
+		Status:     "running",
+		Output:     "$ " + commandLine,
+	})
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = p.env
+	output, err := runStreamingCommand(ctx, cmd, p.logSummary, p.emit)
+	if err != nil {
+		exitCode := resultExitFailed
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			exitCode = exitErr.ExitCode()
+		}
+		return &commandError{command: commandLine, exitCode: exitCode, output: output, err: err}
+	}
+	return nil
+}
+
+// emit 推送进度并将输出写入构建日志
+func (p *buildPipeline) emit(progress BuildProgress) {
+	if progress.Output != "" && p.logFile != nil {
+		p.logMu.Lock()
+		fmt.Fprintf(p.logFile, "[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), progress.Output)
+		p.logMu.Unlock()
+	}
+	if p.progress != nil {
+		p.progress(progress)
+	}
+}
+
+// cancelled 发送构建已取消的进度并返回取消错误
+func (p *buildPipeline) cancelled(ctx context.Context) error {
+	p.emit(BuildProgress{
+		Percentage: 0,
+		Status:     "cancelled",
+		Text:       "构建已取消",
+		Detail:     "构建流水线已终止",
+		Output:     "⛔ 构建已取消，构建命令已终止",
+	})
+	return fmt.Errorf("build cancelled: %v", ctx.Err())
+}
+
+// close 关闭构建日志
+func (p *buildPipeline) close() {
+	if p.logFile != nil {
+		if err := p.logFile.Close(); err != nil {
+			log.Printf("⚠️ 关闭构建日志失败: %v", err)
+		}
+	}
+}
+
+// redactURL 隐藏URL中的密码，非URL原样返回
+func redactURL(value string) string {
+	if !strings.Contains(value, "://") {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	return u.Redacted()
+}
+
+// shellQuote 为远程shell命令加单引号
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package services
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 让构建脚本在独立的进程组中运行，取消时可以结束脚本启动的所有子进程
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup 结束构建脚本所在的整个进程组
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package services
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// setProcessGroup Windows下无需设置进程组，取消时通过taskkill结束进程树
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup 结束构建脚本及其启动的所有子进程
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"brand-config-api/config"
+	"brand-config-api/database"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+
+	"gorm.io/gorm"
+)
+
+// BuildProject 可构建的项目（<端>-<品牌>，即构建请求中的项目名）及其构建条件
+type BuildProject struct {
+	Name        string   `json:"name"` // 如 tth5-xingchen
+	BrandCode   string   `json:"brand_code"`
+	Host        string   `json:"host"`
+	ClientID    int      `json:"client_id,omitempty"`
+	InDatabase  bool     `json:"in_database"`  // 数据库中存在对应的品牌和客户端
+	BuildScript bool     `json:"build_script"` // package.json 中存在 build:<项目> 脚本
+	Prebuild    bool     `json:"prebuild"`     // prebuild 目录中存在该品牌的目录
+	Buildable   bool     `json:"buildable"`    // 以上条件均满足
+	Issues      []string `json:"issues"`       // 不可构建的原因
+}
+
+// BuildProjectService 根据数据库和funNovel项目文件确定可构建项目的服务
+type BuildProjectService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewBuildProjectService 创建可构建项目服务实例
+func NewBuildProjectService() *BuildProjectService {
+	return &BuildProjectService{
+		db:     database.DB,
+		config: config.Load(),
+	}
+}
+
+// ListBuildProjects 列出数据库中的客户端和 package.json 中 build 脚本对应的全部项目，按名称排序
+func (s *BuildProjectService) ListBuildProjects() ([]BuildProject, error) {
+	var clients []models.Client
+	if err := s.db.Preload("Brand").Find(&clients).Error; err != nil {
+		return nil, fmt.Errorf("failed to list clients: %v", err)
+	}
+
+	scripts, err := s.readPackageScripts()
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make(map[string]*BuildProject)
+	add := func(host, brandCode string) *BuildProject {
+		name := host + "-" + brandCode
+		if project, exists := projects[name]; exists {
+			return project
+		}
+		project := &BuildProject{Name: name, BrandCode: brandCode, Host: host}
+		projects[name] = project
+		return project
+	}
+
+	for _, client := range clients {
+		if client.Brand.Code == "" {
+			continue
+		}
+		project := add(client.Host, client.Brand.Code)
+		project.ClientID = client.ID
+		project.InDatabase = true
+	}
+
+	// build:<端>-<品牌>，只认已注册的端类型，忽略 build:h5 等通用脚本
+	for script := range scripts {
+		name, ok := strings.CutPrefix(script, "build:")
+		if !ok {
+			continue
+		}
+		host, brandCode, ok := strings.Cut(name, "-")
+		if !ok || brandCode == "" {
+			continue
+		}
+		if _, registered := utils.GetPlatformRegistry().Get(host); !registered {
+			continue
+		}
+		add(host, brandCode)
+	}
+
+	result := make([]BuildProject, 0, len(projects))
+	for _, project := range projects {
+		project.BuildScript = scripts["build:"+project.Name] != ""
+		if info, err := os.Stat(s.config.GetPrebuildPath(project.BrandCode)); err == nil && info.IsDir() {
+			project.Prebuild = true
+		}
+
+		project.Issues = []string{}
+		if !project.InDatabase {
+			project.Issues = append(project.Issues, "数据库中不存在该品牌和端的客户端")
+		}
+		if !project.BuildScript {
+			project.Issues = append(project.Issues, "package.json 中缺少 build:"+project.Name+" 脚本")
+		}
+		if !project.Prebuild {
+			project.Issues = append(project.Issues, "缺少prebuild目录: "+project.BrandCode)
+		}
+		project.Buildable = len(project.Issues) == 0
+		result = append(result, *project)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// ValidateProjects 检查构建请求中的项目，返回每个未知或不可构建项目的问题描述
+func (s *BuildProjectService) ValidateProjects(names []string) ([]string, error) {
+	projects, err := s.ListBuildProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]BuildProject, len(projects))
+	for _, project := range projects {
+		known[project.Name] = project
+	}
+
+	var problems []string
+	for _, name := range names {
+		project, exists := known[name]
+		switch {
+		case !exists:
+			problems = append(problems, fmt.Sprintf("未知项目 %s", name))
+		case !project.Buildable:
+			problems = append(problems, fmt.Sprintf("项目 %s 不可构建（%s）", name, strings.Join(project.Issues, "，")))
+		}
+	}
+	return problems, nil
+}
+
+// readPackageScripts 读取 package.json 的 scripts
+func (s *BuildProjectService) readPackageScripts() (map[string]string, error) {
+	content, err := os.ReadFile(s.config.File.PackageFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package.json: %v", err)
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse package.json: %v", err)
+	}
+	if pkg.Scripts == nil {
+		pkg.Scripts = make(map[string]string)
+	}
+	return pkg.Scripts, nil
+}
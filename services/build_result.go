@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BuildResultPrefix 构建结果行的前缀。构建脚本每处理完一个项目输出一行 "##RESULT {json}"，
+// JSON 字段与 ProjectResult 相同；原生流水线也输出同样的行，构建日志中可据此还原各项目结果
+const BuildResultPrefix = "##RESULT"
+
+// errorExcerptLines 错误摘要保留的输出行数
+const errorExcerptLines = 20
+
+// 退出码约定：0 成功；命令失败时为命令的退出码；非命令步骤失败为 1；项目未执行或未输出结果为 -1
+const (
+	resultExitFailed     = 1
+	resultExitNotStarted = -1
+)
+
+// Key 项目结果在 BuildResult.Results 中的键：环境/项目
+func (r ProjectResult) Key() string {
+	return r.Environment + "/" + r.Project
+}
+
+// ParseResultLine 解析 ##RESULT 行，不是结果行或内容无效时返回 false
+func ParseResultLine(line string) (ProjectResult, bool) {
+	payload, ok := strings.CutPrefix(strings.TrimSpace(line), BuildResultPrefix)
+	if !ok {
+		return ProjectResult{}, false
+	}
+	payload = strings.TrimSpace(payload)
+	if !strings.HasPrefix(payload, "{") {
+		return ProjectResult{}, false
+	}
+
+	var result ProjectResult
+	if err := json.Unmarshal([]byte(payload), &result); err != nil || result.Project == "" || result.Environment == "" {
+		return ProjectResult{}, false
+	}
+	if result.Duration == "" && result.DurationMs > 0 {
+		result.Duration = (time.Duration(result.DurationMs) * time.Millisecond).String()
+	}
+	return result, true
+}
+
+// FormatResultLine 生成项目结果的 ##RESULT 行
+func FormatResultLine(result ProjectResult) string {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return ""
+	}
+	return BuildResultPrefix + " " + string(data)
+}
+
+// SortedResults 按 环境/项目 排序返回各项目结果
+func (r *BuildResult) SortedResults() []ProjectResult {
+	results := make([]ProjectResult, 0, len(r.Results))
+	for _, result := range r.Results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Key() < results[j].Key()
+	})
+	return results
+}
+
+// fillMissingResults 为没有结果的项目补充失败结果，保证每个 环境/项目 都有记录
+func fillMissingResults(req *BatchBuildRequest, result *BuildResult, reason string) {
+	for _, job := range expandBuildJobs(req) {
+		if _, exists := result.Results[job.key()]; exists {
+			continue
+		}
+		result.Results[job.key()] = ProjectResult{
+			Project:     job.project,
+			Environment: job.env,
+			ExitCode:    resultExitNotStarted,
+			Error:       reason,
+		}
+		result.Success = false
+	}
+}
+
+// tailLines 返回输出的最后 n 个非空行
+func tailLines(output string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
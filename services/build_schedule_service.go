@@ -0,0 +1,696 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"brand-config-api/config"
+	"brand-config-api/database"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrBuildScheduleNotFound 定时构建不存在
+	ErrBuildScheduleNotFound = errors.New("build schedule not found")
+	// ErrBuildScheduleNameExists 定时构建名称重复
+	ErrBuildScheduleNameExists = errors.New("build schedule name already exists")
+	// ErrBuildScheduleBusy 定时构建的上次构建尚未结束
+	ErrBuildScheduleBusy = errors.New("previous build of this schedule has not finished")
+)
+
+// DefaultScheduleBranch 定时构建默认使用的分支
+const DefaultScheduleBranch = "uni/funNovel/devNew"
+
+// 定时构建的检查间隔和补执行时限
+const (
+	scheduleCheckInterval = 30 * time.Second
+	scheduleMisfireGrace  = 10 * time.Minute // 超过计划时间太久（如服务停机期间）的执行直接跳过
+)
+
+// 下次执行时间预览和执行记录的数量参数
+const (
+	SchedulePreviewDefaultCount    = 5
+	SchedulePreviewMaxCount        = 50
+	ScheduleRunListDefaultPageSize = 20
+	ScheduleRunListMaxPageSize     = 100
+)
+
+// 执行记录的触发方式
+const (
+	ScheduleTriggerCron   = "schedule"
+	ScheduleTriggerManual = "manual"
+)
+
+// ScheduleRunSkipped 未启动构建的执行记录状态（上次构建尚未结束或错过执行时间）
+const ScheduleRunSkipped = "skipped"
+
+// scheduleVersionPattern 版本模板渲染后必须是 x.x.x
+var scheduleVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// scheduleColumns 更新定时构建时写入的字段（执行信息由调度更新，不随请求覆盖）
+var scheduleColumns = []string{
+	"name",
+	"cron",
+	"projects",
+	"branch",
+	"environments",
+	"version_template",
+	"force_foreign_net",
+	"ssh_host",
+	"ssh_user",
+	"ssh_password",
+	"enabled",
+	"next_run_at",
+}
+
+// scheduleEnvironments 定时构建可选的环境，与 POST /api/build/h5 一致
+var scheduleEnvironments = map[string]bool{"master": true, "release": true, "local": true}
+
+// ScheduledBuild 定时构建启动的一次构建
+type ScheduledBuild struct {
+	Projects        []string
+	Branch          string
+	Version         string
+	Environments    []string
+	ForceForeignNet bool
+	SSHHost         string
+	SSHUser         string
+	SSHPassword     string
+	Requester       string
+}
+
+// BuildRunner 定时构建的执行方，由构建控制器实现，与 POST /api/build/h5 使用相同的校验、任务队列和构建流程
+type BuildRunner interface {
+	// StartScheduledBuild 校验并创建构建任务，返回任务ID；构建结束后调用 onFinish
+	StartScheduledBuild(build ScheduledBuild, onFinish func(status utils.TaskStatus, errMsg string)) (string, error)
+	// BuildStatus 获取构建任务的当前状态
+	BuildStatus(taskID string) (utils.TaskStatus, bool)
+}
+
+var (
+	buildRunner     BuildRunner
+	buildRunnerMu   sync.RWMutex
+	scheduleJobOnce sync.Once
+	// scheduleTriggerMu 串行化定时构建的触发，保证同一计划不会同时启动两次构建
+	scheduleTriggerMu sync.Mutex
+)
+
+// SetBuildRunner 设置定时构建的执行方（启动时由构建路由设置）
+func SetBuildRunner(runner BuildRunner) {
+	buildRunnerMu.Lock()
+	defer buildRunnerMu.Unlock()
+	buildRunner = runner
+}
+
+// getBuildRunner 获取定时构建的执行方，未设置时返回 nil
+func getBuildRunner() BuildRunner {
+	buildRunnerMu.RLock()
+	defer buildRunnerMu.RUnlock()
+	return buildRunner
+}
+
+// BuildScheduleRequest 创建或更新定时构建的请求
+type BuildScheduleRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Cron            string   `json:"cron" binding:"required"` // 如 0 2 * * * 表示每天凌晨2点
+	Projects        []string `json:"projects" binding:"required"`
+	Branch          string   `json:"branch"` // 为空时使用 uni/funNovel/devNew
+	Environments    []string `json:"environments" binding:"required"`
+	VersionTemplate string   `json:"version_template" binding:"required"` // 如 1.0.{date}，见 RenderScheduleVersion
+	ForceForeignNet bool     `json:"force_foreign_net"`
+	SSHHost         string   `json:"ssh_host" binding:"required"`
+	SSHUser         string   `json:"ssh_user" binding:"required"`
+	SSHPassword     string   `json:"ssh_password"` // 更新时为空表示不修改
+	Enabled         *bool    `json:"enabled"`      // 为空时启用
+}
+
+// BuildScheduleService 定时构建服务
+type BuildScheduleService struct {
+	db             *gorm.DB
+	config         *config.Config
+	projectService *BuildProjectService
+}
+
+// NewBuildScheduleService 创建定时构建服务实例
+func NewBuildScheduleService() *BuildScheduleService {
+	return &BuildScheduleService{
+		db:             database.DB,
+		config:         config.Load(),
+		projectService: NewBuildProjectService(),
+	}
+}
+
+// RenderScheduleVersion 渲染版本模板：{date} 为 YYYYMMDD，{time} 为 HHmm，
+// {yyyy}、{yy}、{MM}、{dd}、{HH}、{mm} 为日期时间各部分，{run} 为该定时构建的第几次构建
+func RenderScheduleVersion(template string, t time.Time, run int) string {
+	return strings.NewReplacer(
+		"{date}", t.Format("20060102"),
+		"{time}", t.Format("1504"),
+		"{yyyy}", t.Format("2006"),
+		"{yy}", t.Format("06"),
+		"{MM}", t.Format("01"),
+		"{dd}", t.Format("02"),
+		"{HH}", t.Format("15"),
+		"{mm}", t.Format("04"),
+		"{run}", fmt.Sprintf("%d", run),
+	).Replace(template)
+}
+
+// ValidateRequest 校验定时构建请求：cron表达式、项目（必须可构建）、环境、版本模板和SSH配置
+func (s *BuildScheduleService) ValidateRequest(req *BuildScheduleRequest, requirePassword bool) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+	cron, err := utils.ParseCron(req.Cron)
+	if err != nil {
+		return err
+	}
+	if cron.Next(time.Now()).IsZero() {
+		return fmt.Errorf("cron expression %q never fires", req.Cron)
+	}
+
+	if len(req.Projects) == 0 {
+		return errors.New("at least one project is required")
+	}
+	problems, err := s.projectService.ValidateProjects(req.Projects)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid projects: %s", strings.Join(problems, "; "))
+	}
+
+	if len(req.Environments) == 0 {
+		return errors.New("at least one environment is required")
+	}
+	for _, env := range req.Environments {
+		if !scheduleEnvironments[env] {
+			return fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	if version := RenderScheduleVersion(req.VersionTemplate, time.Now(), 1); !scheduleVersionPattern.MatchString(version) {
+		return fmt.Errorf("version template must render to x.x.x, got %q", version)
+	}
+
+	if strings.TrimSpace(req.SSHHost) == "" || strings.TrimSpace(req.SSHUser) == "" {
+		return errors.New("ssh host and user are required")
+	}
+	if requirePassword && strings.TrimSpace(req.SSHPassword) == "" {
+		return errors.New("ssh password is required")
+	}
+	return nil
+}
+
+// ListSchedules 获取所有定时构建
+func (s *BuildScheduleService) ListSchedules() ([]models.BuildSchedule, error) {
+	var schedules []models.BuildSchedule
+	if err := s.db.Order("id").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list build schedules: %v", err)
+	}
+	return schedules, nil
+}
+
+// GetSchedule 获取定时构建
+func (s *BuildScheduleService) GetSchedule(id int) (*models.BuildSchedule, error) {
+	var schedule models.BuildSchedule
+	if err := s.db.First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBuildScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to get build schedule: %v", err)
+	}
+	return &schedule, nil
+}
+
+// CreateSchedule 创建定时构建（请求需先经 ValidateRequest 校验）
+func (s *BuildScheduleService) CreateSchedule(req *BuildScheduleRequest, operator string) (*models.BuildSchedule, error) {
+	if err := s.checkNameUnique(req.Name, 0); err != nil {
+		return nil, err
+	}
+
+	schedule := &models.BuildSchedule{CreatedBy: operator}
+	if err := s.applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create build schedule: %v", err)
+	}
+	log.Printf("🕒 定时构建已创建: %s (%s)", schedule.Name, schedule.Cron)
+	return schedule, nil
+}
+
+// UpdateSchedule 更新定时构建（请求需先经 ValidateRequest 校验），启用时按新的cron表达式重新计算下次执行时间
+func (s *BuildScheduleService) UpdateSchedule(id int, req *BuildScheduleRequest) (*models.BuildSchedule, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNameUnique(req.Name, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(schedule).Select(scheduleColumns).Updates(schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to update build schedule: %v", err)
+	}
+	log.Printf("🕒 定时构建已更新: %s (%s)", schedule.Name, schedule.Cron)
+	return schedule, nil
+}
+
+// DeleteSchedule 删除定时构建及其执行记录（已启动的构建任务不受影响）
+func (s *BuildScheduleService) DeleteSchedule(id int) error {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.BuildScheduleRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.BuildSchedule{}, id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete build schedule: %v", err)
+	}
+	log.Printf("🗑️ 定时构建已删除: %s", schedule.Name)
+	return nil
+}
+
+// PauseSchedule 暂停定时构建，暂停期间不再按计划执行
+func (s *BuildScheduleService) PauseSchedule(id int) (*models.BuildSchedule, error) {
+	return s.setEnabled(id, false)
+}
+
+// ResumeSchedule 恢复定时构建，从当前时间起计算下次执行时间（暂停期间错过的执行不会补上）
+func (s *BuildScheduleService) ResumeSchedule(id int) (*models.BuildSchedule, error) {
+	return s.setEnabled(id, true)
+}
+
+// PreviewCron 计算cron表达式从当前时间起的后 count 次执行时间
+func (s *BuildScheduleService) PreviewCron(expr string, count int) ([]time.Time, error) {
+	cron, err := utils.ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	if count < 1 {
+		count = SchedulePreviewDefaultCount
+	}
+	if count > SchedulePreviewMaxCount {
+		count = SchedulePreviewMaxCount
+	}
+	return cron.NextN(time.Now(), count), nil
+}
+
+// RunSchedule 立即执行一次定时构建，上次构建尚未结束时返回 ErrBuildScheduleBusy；
+// 构建启动失败时同样记录执行记录并返回错误
+func (s *BuildScheduleService) RunSchedule(id int, operator string) (*models.BuildScheduleRun, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.trigger(schedule, ScheduleTriggerManual, nil, operator)
+}
+
+// ListRuns 分页获取定时构建的执行记录（按时间倒序），未结束的记录同步构建任务的最新状态
+func (s *BuildScheduleService) ListRuns(scheduleID int, page, pageSize int) ([]models.BuildScheduleRun, int64, error) {
+	if _, err := s.GetSchedule(scheduleID); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.db.Model(&models.BuildScheduleRun{}).Where("schedule_id = ?", scheduleID)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count schedule runs: %v", err)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = ScheduleRunListDefaultPageSize
+	}
+	if pageSize > ScheduleRunListMaxPageSize {
+		pageSize = ScheduleRunListMaxPageSize
+	}
+
+	var runs []models.BuildScheduleRun
+	if err := db.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&runs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list schedule runs: %v", err)
+	}
+	for i := range runs {
+		s.syncRun(&runs[i])
+	}
+	return runs, total, nil
+}
+
+// StartScheduler 启动定时构建的调度（BUILD_SCHEDULER_ENABLED 为 false 时不启动）
+func (s *BuildScheduleService) StartScheduler() {
+	scheduleJobOnce.Do(func() {
+		s.recoverRuns()
+		if !s.config.Build.SchedulerEnabled {
+			log.Printf("⏸️ 定时构建调度已关闭")
+			return
+		}
+
+		s.initNextRuns()
+		log.Printf("🕒 定时构建调度已启动，检查间隔: %s", scheduleCheckInterval)
+		go func() {
+			ticker := time.NewTicker(scheduleCheckInterval)
+			defer ticker.Stop()
+
+			for {
+				s.runDueSchedules(time.Now())
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// recoverRuns 将上次进程中尚未创建构建任务的执行记录标记为中断，避免一直被视为未结束
+func (s *BuildScheduleService) recoverRuns() {
+	now := time.Now()
+	result := s.db.Model(&models.BuildScheduleRun{}).
+		Where("status = ? AND task_id = ?", string(utils.TaskStatusPending), "").
+		Updates(map[string]interface{}{
+			"status":      string(utils.TaskStatusInterrupted),
+			"error":       "服务重启，构建未启动",
+			"finished_at": now,
+		})
+	if result.Error != nil {
+		log.Printf("❌ 恢复定时构建执行记录失败: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("🔄 %d 条未启动的定时构建执行记录已标记为中断", result.RowsAffected)
+	}
+}
+
+// initNextRuns 为缺少下次执行时间的启用中定时构建计算下次执行时间（如直接写入数据库的记录）
+func (s *BuildScheduleService) initNextRuns() {
+	var schedules []models.BuildSchedule
+	if err := s.db.Where("enabled = ? AND next_run_at IS NULL", true).Find(&schedules).Error; err != nil {
+		log.Printf("❌ 获取定时构建失败: %v", err)
+		return
+	}
+	for _, schedule := range schedules {
+		next := nextScheduleRun(schedule.Cron, time.Now())
+		if err := s.db.Model(&models.BuildSchedule{}).Where("id = ?", schedule.ID).Update("next_run_at", next).Error; err != nil {
+			log.Printf("❌ 更新定时构建 %s 的下次执行时间失败: %v", schedule.Name, err)
+		}
+	}
+}
+
+// runDueSchedules 执行所有已到期的定时构建
+func (s *BuildScheduleService) runDueSchedules(now time.Time) {
+	var schedules []models.BuildSchedule
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, now).Order("next_run_at").Find(&schedules).Error; err != nil {
+		log.Printf("❌ 获取到期的定时构建失败: %v", err)
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+		planned := *schedule.NextRunAt
+
+		// 先以计划时间为条件更新下次执行时间，保证每个计划时间只触发一次
+		result := s.db.Model(&models.BuildSchedule{}).
+			Where("id = ? AND next_run_at = ?", schedule.ID, planned).
+			Update("next_run_at", nextScheduleRun(schedule.Cron, now))
+		if result.Error != nil {
+			log.Printf("❌ 更新定时构建 %s 的下次执行时间失败: %v", schedule.Name, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if now.Sub(planned) > scheduleMisfireGrace {
+			log.Printf("⏭️ 定时构建 %s 错过执行时间 %s，已跳过", schedule.Name, planned.Format("2006-01-02 15:04"))
+			s.recordSkippedRun(schedule.ID, &planned, "错过执行时间（服务未运行）")
+			continue
+		}
+
+		log.Printf("⏰ 定时构建到期: %s (计划时间 %s)", schedule.Name, planned.Format("2006-01-02 15:04"))
+		if _, err := s.trigger(schedule, ScheduleTriggerCron, &planned, "schedule:"+schedule.Name); err != nil && err != ErrBuildScheduleBusy {
+			log.Printf("❌ 定时构建 %s 启动失败: %v", schedule.Name, err)
+		}
+	}
+}
+
+// trigger 启动一次定时构建并记录执行记录；上次构建尚未结束时返回 ErrBuildScheduleBusy，按计划触发的同时记录跳过
+func (s *BuildScheduleService) trigger(schedule *models.BuildSchedule, trigger string, scheduledAt *time.Time, requester string) (*models.BuildScheduleRun, error) {
+	scheduleTriggerMu.Lock()
+	defer scheduleTriggerMu.Unlock()
+
+	runner := getBuildRunner()
+	if runner == nil {
+		return nil, errors.New("build runner is not configured")
+	}
+
+	active, err := s.activeRun(schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		if trigger == ScheduleTriggerManual {
+			return nil, ErrBuildScheduleBusy
+		}
+		log.Printf("⏭️ 定时构建 %s 的上次构建尚未结束（任务 %s），本次已跳过", schedule.Name, active.TaskID)
+		return s.recordSkippedRun(schedule.ID, scheduledAt, "上次构建尚未结束: "+active.TaskID), ErrBuildScheduleBusy
+	}
+
+	now := time.Now()
+	run := &models.BuildScheduleRun{
+		ScheduleID:  schedule.ID,
+		Trigger:     trigger,
+		Version:     RenderScheduleVersion(schedule.VersionTemplate, now, schedule.RunCount+1),
+		Status:      string(utils.TaskStatusPending),
+		ScheduledAt: scheduledAt,
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schedule run: %v", err)
+	}
+
+	runID := run.ID
+	password, err := utils.DecryptSecret(s.config.Build.SecretKey, schedule.SSHPassword)
+	if err != nil {
+		err = fmt.Errorf("failed to decrypt ssh password: %v", err)
+		s.finishRun(runID, string(utils.TaskStatusFailed), err.Error())
+		run.Status = string(utils.TaskStatusFailed)
+		run.Error = err.Error()
+		return run, err
+	}
+
+	taskID, err := runner.StartScheduledBuild(ScheduledBuild{
+		Projects:        schedule.Projects,
+		Branch:          schedule.Branch,
+		Version:         run.Version,
+		Environments:    schedule.Environments,
+		ForceForeignNet: schedule.ForceForeignNet,
+		SSHHost:         schedule.SSHHost,
+		SSHUser:         schedule.SSHUser,
+		SSHPassword:     password,
+		Requester:       requester,
+	}, func(status utils.TaskStatus, errMsg string) {
+		s.finishRun(runID, string(status), errMsg)
+	})
+	if err != nil {
+		s.finishRun(runID, string(utils.TaskStatusFailed), err.Error())
+		run.Status = string(utils.TaskStatusFailed)
+		run.Error = err.Error()
+		return run, err
+	}
+
+	run.TaskID = taskID
+	if err := s.db.Model(&models.BuildScheduleRun{}).Where("id = ?", runID).Update("task_id", taskID).Error; err != nil {
+		log.Printf("❌ 更新执行记录 %d 的任务ID失败: %v", runID, err)
+	}
+	if err := s.db.Model(&models.BuildSchedule{}).Where("id = ?", schedule.ID).Updates(map[string]interface{}{
+		"last_run_at":  now,
+		"last_task_id": taskID,
+		"run_count":    gorm.Expr("run_count + 1"),
+	}).Error; err != nil {
+		log.Printf("❌ 更新定时构建 %s 的执行信息失败: %v", schedule.Name, err)
+	}
+	log.Printf("🏗️ 定时构建 %s 已启动: 任务 %s，版本 %s", schedule.Name, taskID, run.Version)
+	return run, nil
+}
+
+// activeRun 获取定时构建尚未结束的执行记录，没有时返回 nil
+func (s *BuildScheduleService) activeRun(scheduleID int) (*models.BuildScheduleRun, error) {
+	var runs []models.BuildScheduleRun
+	if err := s.db.Where("schedule_id = ? AND status IN ?", scheduleID, []string{
+		string(utils.TaskStatusPending), string(utils.TaskStatusRunning),
+	}).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get schedule runs: %v", err)
+	}
+
+	for i := range runs {
+		s.syncRun(&runs[i])
+		if !isRunFinished(runs[i].Status) {
+			return &runs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// syncRun 未结束的执行记录同步构建任务的最新状态（如排队中被取消、服务重启时被中断的任务不会回调 finishRun）
+func (s *BuildScheduleService) syncRun(run *models.BuildScheduleRun) {
+	if isRunFinished(run.Status) || run.TaskID == "" {
+		return
+	}
+	runner := getBuildRunner()
+	if runner == nil {
+		return
+	}
+
+	status, exists := runner.BuildStatus(run.TaskID)
+	if !exists {
+		status = utils.TaskStatusInterrupted
+	}
+	if string(status) == run.Status {
+		return
+	}
+
+	run.Status = string(status)
+	updates := map[string]interface{}{"status": run.Status}
+	if isRunFinished(run.Status) {
+		now := time.Now()
+		run.FinishedAt = &now
+		updates["finished_at"] = now
+	}
+	if err := s.db.Model(&models.BuildScheduleRun{}).Where("id = ?", run.ID).Updates(updates).Error; err != nil {
+		log.Printf("❌ 更新执行记录 %d 的状态失败: %v", run.ID, err)
+	}
+}
+
+// finishRun 记录构建结束
+func (s *BuildScheduleService) finishRun(runID int, status string, errMsg string) {
+	if err := s.db.Model(&models.BuildScheduleRun{}).Where("id = ?", runID).Updates(map[string]interface{}{
+		"status":      status,
+		"error":       errMsg,
+		"finished_at": time.Now(),
+	}).Error; err != nil {
+		log.Printf("❌ 更新执行记录 %d 的状态失败: %v", runID, err)
+	}
+}
+
+// recordSkippedRun 记录未启动构建的执行
+func (s *BuildScheduleService) recordSkippedRun(scheduleID int, scheduledAt *time.Time, reason string) *models.BuildScheduleRun {
+	now := time.Now()
+	run := &models.BuildScheduleRun{
+		ScheduleID:  scheduleID,
+		Trigger:     ScheduleTriggerCron,
+		Status:      ScheduleRunSkipped,
+		Error:       reason,
+		ScheduledAt: scheduledAt,
+		FinishedAt:  &now,
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		log.Printf("❌ 记录定时构建跳过失败: %v", err)
+	}
+	return run
+}
+
+// setEnabled 暂停或恢复定时构建
+func (s *BuildScheduleService) setEnabled(id int, enabled bool) (*models.BuildSchedule, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Enabled = enabled
+	schedule.NextRunAt = nil
+	if enabled {
+		schedule.NextRunAt = nextScheduleRun(schedule.Cron, time.Now())
+	}
+	if err := s.db.Model(&models.BuildSchedule{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"enabled":     schedule.Enabled,
+		"next_run_at": schedule.NextRunAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update build schedule: %v", err)
+	}
+
+	if enabled {
+		log.Printf("▶️ 定时构建已恢复: %s", schedule.Name)
+	} else {
+		log.Printf("⏸️ 定时构建已暂停: %s", schedule.Name)
+	}
+	return schedule, nil
+}
+
+// applyRequest 将请求写入定时构建（SSH密码用 BUILD_SECRET_KEY 加密保存），并按启用状态计算下次执行时间
+func (s *BuildScheduleService) applyRequest(schedule *models.BuildSchedule, req *BuildScheduleRequest) error {
+	schedule.Name = strings.TrimSpace(req.Name)
+	schedule.Cron = strings.TrimSpace(req.Cron)
+	schedule.Projects = req.Projects
+	schedule.Branch = strings.TrimSpace(req.Branch)
+	if schedule.Branch == "" {
+		schedule.Branch = DefaultScheduleBranch
+	}
+	schedule.Environments = req.Environments
+	schedule.VersionTemplate = req.VersionTemplate
+	schedule.ForceForeignNet = req.ForceForeignNet
+	schedule.SSHHost = req.SSHHost
+	schedule.SSHUser = req.SSHUser
+	if req.SSHPassword != "" {
+		encrypted, err := utils.EncryptSecret(s.config.Build.SecretKey, req.SSHPassword)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt ssh password: %v", err)
+		}
+		schedule.SSHPassword = encrypted
+	}
+
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+	schedule.NextRunAt = nil
+	if schedule.Enabled {
+		schedule.NextRunAt = nextScheduleRun(schedule.Cron, time.Now())
+	}
+	return nil
+}
+
+// checkNameUnique 检查名称是否已被其他定时构建使用
+func (s *BuildScheduleService) checkNameUnique(name string, excludeID int) error {
+	var count int64
+	if err := s.db.Model(&models.BuildSchedule{}).
+		Where("name = ? AND id <> ?", strings.TrimSpace(name), excludeID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check build schedule name: %v", err)
+	}
+	if count > 0 {
+		return ErrBuildScheduleNameExists
+	}
+	return nil
+}
+
+// nextScheduleRun 计算cron表达式在 after 之后的下次执行时间，表达式无效或不会再执行时返回 nil
+func nextScheduleRun(expr string, after time.Time) *time.Time {
+	cron, err := utils.ParseCron(expr)
+	if err != nil {
+		log.Printf("❌ 无效的cron表达式 %q: %v", expr, err)
+		return nil
+	}
+	next := cron.Next(after)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// isRunFinished 执行记录是否已结束
+func isRunFinished(status string) bool {
+	return status != string(utils.TaskStatusPending) && status != string(utils.TaskStatusRunning)
+}
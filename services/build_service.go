@@ -3,7 +3,8 @@ package services
 import (
 	"brand-config-api/config"
 	"brand-config-api/utils"
-	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"net"
@@ -11,6 +12,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -84,6 +86,8 @@ type BatchBuildRequest struct {
 	SSHHost      string   `json:"ssh_host"`      // SSH主机
 	SSHUser      string   `json:"ssh_user"`      // SSH用户名
 	SSHPassword  string   `json:"ssh_password"`  // SSH密码
+	TaskID       string   `json:"-"`             // 所属任务，记录到构建产物
+	Requester    string   `json:"-"`             // 发起人，记录到构建产物
 }
 
 // BuildProgress 构建进度
@@ -94,6 +98,8 @@ type BuildProgress struct {
 	Detail     string `json:"detail"`  // 详细信息
 	Project    string `json:"project"` // 当前处理的项目
 	Output     string `json:"output"`  // 实时输出
+
+	Result *ProjectResult `json:"result,omitempty"` // 项目处理结束时的结果（来自 ##RESULT 行）
 }
 
 // BuildResult 构建结果
@@ -101,34 +107,64 @@ type BuildResult struct {
 	Success    bool                     `json:"success"`
 	TotalTime  string                   `json:"total_time"`
 	Projects   []string                 `json:"projects"`
-	Results    map[string]ProjectResult `json:"results"`     // 每个项目的结果
-	OutputPath string                   `json:"output_path"` // 构建产物路径
-	LogPath    string                   `json:"log_path"`    // 日志路径
+	Results    map[string]ProjectResult `json:"results"`               // 每个项目的结果，键为 环境/项目
+	OutputPath string                   `json:"output_path"`           // 构建产物路径
+	LogPath    string                   `json:"log_path"`              // 日志路径
+	LogSummary *utils.LogSummary        `json:"log_summary,omitempty"` // 构建输出中命中错误、警告规则的汇总
+}
+
+// FailedProjects 返回构建失败的项目（Results 的键），按字母排序
+func (r *BuildResult) FailedProjects() []string {
+	var failed []string
+	for name, project := range r.Results {
+		if !project.Success {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
 }
 
-// ProjectResult 单个项目构建结果
+// ProjectResult 单个项目构建结果，也是 ##RESULT 行的JSON格式
 type ProjectResult struct {
-	Success   bool   `json:"success"`
-	StartTime string `json:"start_time"`
-	EndTime   string `json:"end_time"`
-	Duration  string `json:"duration"`
-	Error     string `json:"error,omitempty"`
+	Success      bool              `json:"success"`
+	Project      string            `json:"project,omitempty"`
+	Environment  string            `json:"environment,omitempty"`
+	StartTime    string            `json:"start_time"`
+	EndTime      string            `json:"end_time"`
+	Duration     string            `json:"duration"`
+	DurationMs   int64             `json:"duration_ms"`
+	ExitCode     int               `json:"exit_code"` // 0 成功，命令失败时为其退出码，-1 表示未执行
+	Error        string            `json:"error,omitempty"`
+	ErrorExcerpt string            `json:"error_excerpt,omitempty"` // 失败时的输出末尾
+	Steps        []BuildStepResult `json:"steps,omitempty"`         // 原生流水线的各步骤结果
+	Artifact     string            `json:"artifact,omitempty"`      // 打包后的构建产物路径
+	ArtifactID   int               `json:"artifact_id,omitempty"`   // 归档后的构建产物记录，见 /api/build/artifacts
+}
+
+// BuildStepResult 构建流水线中单个步骤的结果
+type BuildStepResult struct {
+	Name     string `json:"name"` // checkout、config、build、package、archive、publish、deploy
+	Success  bool   `json:"success"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
 }
 
 // ProgressCallback 进度回调函数类型
 type ProgressCallback func(progress BuildProgress)
 
-// ExecuteBatchBuild 执行批量构建
-func (s *BuildService) ExecuteBatchBuild(req *BatchBuildRequest, progressCallback ProgressCallback) (*BuildResult, error) {
+// ExecuteBatchBuild 执行批量构建，ctx 取消时结束构建脚本
+func (s *BuildService) ExecuteBatchBuild(ctx context.Context, req *BatchBuildRequest, progressCallback ProgressCallback) (*BuildResult, error) {
 	startTime := time.Now()
 
 	log.Printf("🚀 开始批量构建: 项目=%v, 分支=%s, 环境=%s", req.Projects, req.Branch, req.Environment)
 
 	// 初始化结果
 	result := &BuildResult{
-		Success:  true,
-		Projects: req.Projects,
-		Results:  make(map[string]ProjectResult),
+		Success:    true,
+		Projects:   req.Projects,
+		Results:    make(map[string]ProjectResult),
+		LogSummary: utils.NewLogSummary(),
 	}
 
 	// 发送开始进度
@@ -153,6 +189,7 @@ func (s *BuildService) ExecuteBatchBuild(req *BatchBuildRequest, progressCallbac
 
 	if err := s.ValidateSSHConnection(req.SSHHost, req.SSHUser, req.SSHPassword); err != nil {
 		result.Success = false
+		fillMissingResults(req, result, "SSH连接验证失败: "+err.Error())
 		if progressCallback != nil {
 			progressCallback(BuildProgress{
 				Percentage: 0,
@@ -174,20 +211,6 @@ func (s *BuildService) ExecuteBatchBuild(req *BatchBuildRequest, progressCallbac
 		})
 	}
 
-	// 第二步：验证构建环境
-	if err := s.validateBuildEnvironment(); err != nil {
-		result.Success = false
-		if progressCallback != nil {
-			progressCallback(BuildProgress{
-				Percentage: 5,
-				Status:     "failed",
-				Text:       "环境验证失败",
-				Detail:     err.Error(),
-			})
-		}
-		return result, fmt.Errorf("build environment validation failed: %v", err)
-	}
-
 	// 设置默认值
 	if req.Branch == "" {
 		req.Branch = "uni/funNovel/devNew"
@@ -199,19 +222,27 @@ func (s *BuildService) ExecuteBatchBuild(req *BatchBuildRequest, progressCallbac
 		req.Environment = "master"
 	}
 
-	// 构建项目列表字符串
-	projectsStr := strings.Join(req.Projects, ",")
-
-	// 调用构建脚本
-	buildResult, err := s.executeBuildScript(req, projectsStr, progressCallback)
+	// 第二步：执行构建流水线，BUILD_MODE=script 时回退到构建脚本
+	var err error
+	if s.config.Build.Mode == config.BuildModeScript {
+		err = s.executeScriptBuild(ctx, req, result, progressCallback)
+	} else {
+		err = s.executePipelineBuild(ctx, req, result, progressCallback)
+	}
+	switch {
+	case ctx.Err() != nil:
+		fillMissingResults(req, result, "构建已取消")
+	case err != nil:
+		fillMissingResults(req, result, err.Error())
+	default:
+		fillMissingResults(req, result, "构建未输出该项目的结果")
+	}
 	if err != nil {
 		result.Success = false
+		result.TotalTime = time.Since(startTime).String()
 		return result, err
 	}
 
-	// 解析构建结果
-	s.parseBuildResults(buildResult, result)
-
 	// 计算总耗时
 	result.TotalTime = time.Since(startTime).String()
 
@@ -233,9 +264,83 @@ func (s *BuildService) ExecuteBatchBuild(req *BatchBuildRequest, progressCallbac
 	}
 
 	log.Printf("✅ 批量构建完成: 成功=%v, 耗时=%s", result.Success, result.TotalTime)
+	if failed := result.FailedProjects(); len(failed) > 0 {
+		return result, fmt.Errorf("%d 个项目构建失败: %s", len(failed), strings.Join(failed, ", "))
+	}
 	return result, nil
 }
 
+// executeScriptBuild 通过 h5_novel_build_linux.sh 执行构建（回退模式），从脚本输出中解析各项目结果
+func (s *BuildService) executeScriptBuild(ctx context.Context, req *BatchBuildRequest, result *BuildResult, progressCallback ProgressCallback) error {
+	if err := s.validateBuildEnvironment(); err != nil {
+		if progressCallback != nil {
+			progressCallback(BuildProgress{
+				Percentage: 5,
+				Status:     "failed",
+				Text:       "环境验证失败",
+				Detail:     err.Error(),
+			})
+		}
+		return fmt.Errorf("build environment validation failed: %v", err)
+	}
+
+	// 启动脚本前已取消时不再执行
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("build cancelled: %v", err)
+	}
+
+	// 脚本中途失败时也解析已输出的结果
+	output, err := s.executeBuildScript(ctx, req, strings.Join(req.Projects, ","), result.LogSummary, progressCallback)
+	s.parseBuildResults(output, result)
+	s.archiveScriptArtifacts(req, result)
+	return err
+}
+
+// archiveScriptArtifacts 脚本结束后归档各成功项目打包的产物（##RESULT 中的 artifact），归档失败只记录日志
+func (s *BuildService) archiveScriptArtifacts(req *BatchBuildRequest, result *BuildResult) {
+	var commitSHA string
+	sourceDir := filepath.Join(s.config.Build.WorkspaceDir, "funNovel")
+	if out, err := exec.Command(s.config.Build.GitBin, "-C", sourceDir, "rev-parse", "HEAD").Output(); err == nil {
+		commitSHA = strings.TrimSpace(string(out))
+	}
+
+	artifacts := NewArtifactService()
+	for key, projectResult := range result.Results {
+		if !projectResult.Success || projectResult.Artifact == "" {
+			continue
+		}
+		artifact, err := artifacts.ArchiveArtifact(projectResult.Artifact, ArtifactInfo{
+			Project:     projectResult.Project,
+			Environment: projectResult.Environment,
+			Branch:      req.Branch,
+			CommitSHA:   commitSHA,
+			Version:     req.Version,
+			TaskID:      req.TaskID,
+			CreatedBy:   req.Requester,
+		})
+		if err != nil {
+			log.Printf("⚠️ 构建产物归档失败: %s - %v", key, err)
+			continue
+		}
+		projectResult.ArtifactID = artifact.ID
+		result.Results[key] = projectResult
+	}
+}
+
+// ValidateEnvironments 检查当前构建模式能否构建所选环境，在任务排队前调用：
+// 原生流水线发布 master、release 等环境需要配置 BUILD_PUBLISH_REPO
+func (s *BuildService) ValidateEnvironments(environments []string) error {
+	if s.config.Build.Mode == config.BuildModeScript || s.config.Build.PublishRepo != "" {
+		return nil
+	}
+	for _, env := range environments {
+		if env != "local" {
+			return fmt.Errorf("未配置 BUILD_PUBLISH_REPO，原生构建流水线无法发布 %s 环境", env)
+		}
+	}
+	return nil
+}
+
 // validateBuildEnvironment 验证构建环境
 func (s *BuildService) validateBuildEnvironment() error {
 	// 检查构建脚本是否存在 - 使用GetLocalScriptPath方法
@@ -249,9 +354,8 @@ func (s *BuildService) validateBuildEnvironment() error {
 		return fmt.Errorf("failed to make script executable: %v", err)
 	}
 
-	// 检查工作目录 - 使用File.BasePath作为项目根目录
-	workspaceDir := filepath.Join(s.config.File.BasePath, "workspace")
-	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+	// 检查工作目录，通过 -w 参数传给脚本
+	if err := os.MkdirAll(s.config.Build.WorkspaceDir, 0755); err != nil {
 		return fmt.Errorf("failed to create workspace directory: %v", err)
 	}
 
@@ -263,8 +367,8 @@ func (s *BuildService) ensureScriptExecutable(scriptPath string) error {
 	return os.Chmod(scriptPath, 0755)
 }
 
-// executeBuildScript 执行构建脚本
-func (s *BuildService) executeBuildScript(req *BatchBuildRequest, projectsStr string, progressCallback ProgressCallback) (string, error) {
+// executeBuildScript 执行构建脚本，ctx 取消时结束脚本所在的整个进程组
+func (s *BuildService) executeBuildScript(ctx context.Context, req *BatchBuildRequest, projectsStr string, summary *utils.LogSummary, progressCallback ProgressCallback) (string, error) {
 	// 使用GetLocalScriptPath方法获取构建脚本路径
 	scriptPath := s.config.GetLocalScriptPath("h5_novel_build_linux.sh")
 
@@ -274,6 +378,7 @@ func (s *BuildService) executeBuildScript(req *BatchBuildRequest, projectsStr st
 		"-v", req.Version,
 		"-e", req.Environment,
 		"-p", projectsStr,
+		"-w", s.config.Build.WorkspaceDir,
 	}
 
 	// 添加可选参数
@@ -288,7 +393,7 @@ func (s *BuildService) executeBuildScript(req *BatchBuildRequest, projectsStr st
 	if runtime.GOOS == "windows" {
 		// Windows系统使用bash执行shell脚本
 		args := append([]string{scriptPath}, scriptArgs...)
-		cmd = exec.Command("bash", args...)
+		cmd = exec.CommandContext(ctx, "bash", args...)
 
 		// 临时注释掉Windows特定字段，避免跨平台编译问题
 		// 在Windows环境下运行时，这些字段的缺失不会影响基本功能
@@ -300,59 +405,28 @@ func (s *BuildService) executeBuildScript(req *BatchBuildRequest, projectsStr st
 		log.Printf("📋 执行构建命令 (Windows): bash %s %v", scriptPath, scriptArgs)
 	} else {
 		// Unix系统直接执行脚本
-		cmd = exec.Command(scriptPath, scriptArgs...)
+		cmd = exec.CommandContext(ctx, scriptPath, scriptArgs...)
 		log.Printf("📋 执行构建命令 (Unix): %s %v", scriptPath, scriptArgs)
 	}
 
 	cmd.Dir = s.config.File.BasePath
 
 	// 设置环境变量 - 重点是禁用缓冲和SSH配置
-	env := append(os.Environ(),
-		"PYTHONUNBUFFERED=1",             // Python无缓冲输出
-		"NODE_NO_WARNINGS=1",             // 减少Node.js警告
-		"FORCE_COLOR=0",                  // 禁用彩色输出，避免ANSI转义序列干扰
-		"CI=true",                        // 设置CI环境，通常会减少缓冲
-		"TERM=dumb",                      // 设置终端类型，避免交互式提示
-		"DEBIAN_FRONTEND=noninteractive", // 非交互式模式
-		"STDBUF=--output=L",              // 强制行缓冲输出
-		"UNBUFFER=1",                     // 禁用缓冲
+	env := append(buildCommandEnv(s.config.Build.NodeBinDir),
 		// SSH配置环境变量 - 从请求中获取
 		"SSH_HOST="+req.SSHHost,
 		"SSH_USER="+req.SSHUser,
 		"SSH_PASSWORD="+req.SSHPassword,
 	)
 
-	// 根据操作系统设置不同的环境变量
+	// Windows下确保bash和相关工具可用
 	if runtime.GOOS == "windows" {
-		// Windows下确保bash和相关工具可用
 		env = append(env, "MSYSTEM=MINGW64")
 		env = append(env, "CHERE_INVOKING=1")
-	} else {
-		// Unix系统的Node.js路径
-		env = append(env,
-			"NODE_HOME=/home/fun/.nvm/versions/node/v20.18.1/bin",
-			"PATH=/home/fun/.nvm/versions/node/v20.18.1/bin:"+os.Getenv("PATH"),
-		)
 	}
 
 	cmd.Env = env
 
-	// 创建管道捕获输出
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stdout pipe: %v", err)
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stderr pipe: %v", err)
-	}
-
-	// 启动命令
-	if err := cmd.Start(); err != nil {
-		return "", fmt.Errorf("failed to start build script: %v", err)
-	}
-
 	// 发送开始消息
 	if progressCallback != nil {
 		progressCallback(BuildProgress{
@@ -362,107 +436,22 @@ func (s *BuildService) executeBuildScript(req *BatchBuildRequest, projectsStr st
 			Detail:     fmt.Sprintf("执行命令: %s %v", scriptPath, scriptArgs),
 			Output:     "🚀 构建脚本开始执行...",
 		})
-		log.Printf("✅ 构建进度回调已设置，开始捕获输出")
-	} else {
-		log.Printf("⚠️ 构建进度回调为空，无法发送实时输出")
 	}
 
-	// 使用 WaitGroup 等待所有输出处理完成
-	var wg sync.WaitGroup
-	var outputBuilder strings.Builder
-	var mu sync.Mutex
-
-	// 处理标准输出
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		scanner := bufio.NewScanner(stdout)
-		// 设置缓冲区，但不要太大以确保实时性
-		buf := make([]byte, 0, 4*1024)
-		scanner.Buffer(buf, 64*1024)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			mu.Lock()
-			outputBuilder.WriteString(line + "\n")
-			mu.Unlock()
-
-			// 发送有意义的输出到前端，但不包含进度百分比
-			if progressCallback != nil {
-				progressCallback(BuildProgress{
-					Status:     "running",
-					Text:       "",
-					Detail:     "",
-					Output:     line, // 发送原始输出用于前端日志显示
-					Percentage: -999, // 使用特殊值表示不显示进度
-				})
-			}
+	output, err := runStreamingCommand(ctx, cmd, summary, progressCallback)
 
-			log.Printf("[BUILD] %s", line)
-		}
-
-		if err := scanner.Err(); err != nil {
-			log.Printf("构建输出扫描错误: %v", err)
-		}
-	}()
-
-	// 处理标准错误输出
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		scanner := bufio.NewScanner(stderr)
-		// 设置缓冲区，但不要太大以确保实时性
-		buf := make([]byte, 0, 4*1024)
-		scanner.Buffer(buf, 64*1024)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			// 智能判断是否为真正的错误
-			isError := utils.IsRealError(line)
-
-			mu.Lock()
-			if isError {
-				outputBuilder.WriteString("ERROR: " + line + "\n")
-			} else {
-				outputBuilder.WriteString(line + "\n")
-			}
-			mu.Unlock()
-
-			// 发送有意义的错误输出到前端，但不包含进度百分比
-			if progressCallback != nil {
-				var outputLine string
-				if isError {
-					outputLine = "ERROR: " + line
-				} else {
-					outputLine = line
-				}
-
-				progressCallback(BuildProgress{
-					Status:     "running",
-					Text:       "",
-					Detail:     "",
-					Output:     outputLine, // 发送处理后的输出用于前端日志显示
-					Percentage: -999,       // 使用特殊值表示不显示进度
-				})
-			}
-
-			log.Printf("[BUILD-STDERR] %s", line)
-		}
-
-		if err := scanner.Err(); err != nil {
-			log.Printf("构建错误输出扫描错误: %v", err)
+	if ctx.Err() != nil {
+		if progressCallback != nil {
+			progressCallback(BuildProgress{
+				Percentage: 0,
+				Status:     "cancelled",
+				Text:       "构建已取消",
+				Detail:     "构建脚本已终止",
+				Output:     "⛔ 构建已取消，构建脚本已终止",
+			})
 		}
-	}()
-
-	// 等待命令完成
-	err = cmd.Wait()
-
-	// 等待所有输出处理完成
-	wg.Wait()
-
-	output := outputBuilder.String()
+		return output, fmt.Errorf("build cancelled: %v", ctx.Err())
+	}
 
 	if err != nil {
 		if progressCallback != nil {
@@ -480,49 +469,145 @@ func (s *BuildService) executeBuildScript(req *BatchBuildRequest, projectsStr st
 	return output, nil
 }
 
-// parseBuildResults 解析构建结果
-func (s *BuildService) parseBuildResults(output string, result *BuildResult) {
-	lines := strings.Split(output, "\n")
-
-	for _, line := range lines {
-		// 解析项目构建结果
-		if strings.Contains(line, "build:") && strings.Contains(line, "success") {
-			// 示例: "build: tth5-xingchen, master, success"
-			parts := strings.Split(line, ",")
-			if len(parts) >= 3 {
-				// 安全地解析项目名称
-				projectPart := strings.TrimSpace(parts[1])
-				projectColonParts := strings.Split(projectPart, ":")
-				if len(projectColonParts) >= 2 {
-					project := strings.TrimSpace(projectColonParts[1])
-					result.Results[project] = ProjectResult{
-						Success: true,
-					}
-				}
+// buildCommandEnv 构建命令的环境变量：禁用缓冲和交互，Node.js的bin目录存在时加入PATH
+func buildCommandEnv(nodeBinDir string) []string {
+	env := append(os.Environ(),
+		"PYTHONUNBUFFERED=1",             // Python无缓冲输出
+		"NODE_NO_WARNINGS=1",             // 减少Node.js警告
+		"FORCE_COLOR=0",                  // 禁用彩色输出，避免ANSI转义序列干扰
+		"CI=true",                        // 设置CI环境，通常会减少缓冲
+		"TERM=dumb",                      // 设置终端类型，避免交互式提示
+		"DEBIAN_FRONTEND=noninteractive", // 非交互式模式
+		"STDBUF=--output=L",              // 强制行缓冲输出
+		"UNBUFFER=1",                     // 禁用缓冲
+	)
+	if nodeBinDir != "" {
+		if info, err := os.Stat(nodeBinDir); err == nil && info.IsDir() {
+			env = append(env,
+				"NODE_HOME="+nodeBinDir,
+				"PATH="+nodeBinDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+			)
+		}
+	}
+	return env
+}
+
+// runStreamingCommand 执行命令并逐行推送标准输出和错误输出，返回完整输出
+// 每行按输出分类规则分类，命中错误规则的行加 "ERROR: " 前缀，并记录到 summary（可为nil）
+// ctx 取消时结束命令所在的整个进程组，避免其启动的git、yarn、ssh等子进程继续运行
+func runStreamingCommand(ctx context.Context, cmd *exec.Cmd, summary *utils.LogSummary, progressCallback ProgressCallback) (string, error) {
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = 10 * time.Second
+
+	// 按行处理输出；由 exec 负责拷贝输出，Wait 会等待拷贝结束（超过 WaitDelay 时不再等待子进程的输出）
+	var outputBuilder strings.Builder
+	var mu sync.Mutex
+	handleLine := func(line string, isStderr bool) {
+		result, isResult := ParseResultLine(line)
+		if !isResult {
+			classification := utils.GetLogClassifier().Classify(line, isStderr)
+			summary.Add(line, classification)
+			if classification.Severity == utils.LogSeverityError && !strings.HasPrefix(line, "ERROR: ") {
+				line = "ERROR: " + line
 			}
-		} else if strings.Contains(line, "build:") && strings.Contains(line, "fail") {
-			// 示例: "build: tth5-xingchen, master, fail"
-			parts := strings.Split(line, ",")
-			if len(parts) >= 3 {
-				// 安全地解析项目名称
-				projectPart := strings.TrimSpace(parts[1])
-				projectColonParts := strings.Split(projectPart, ":")
-				if len(projectColonParts) >= 2 {
-					project := strings.TrimSpace(projectColonParts[1])
-					result.Results[project] = ProjectResult{
-						Success: false,
-						Error:   "Build failed",
-					}
-					result.Success = false
-				}
+		}
+
+		mu.Lock()
+		outputBuilder.WriteString(line + "\n")
+		mu.Unlock()
+
+		// 发送原始输出用于前端日志显示，但不包含进度百分比
+		if progressCallback != nil {
+			progress := BuildProgress{
+				Status:     "running",
+				Output:     line,
+				Percentage: -999, // 使用特殊值表示不显示进度
 			}
+			if isResult {
+				progress.Project = result.Project
+				progress.Result = &result
+			}
+			progressCallback(progress)
+		}
+
+		if isStderr {
+			log.Printf("[BUILD-STDERR] %s", line)
+		} else {
+			log.Printf("[BUILD] %s", line)
+		}
+	}
+	stdout := &lineWriter{onLine: func(line string) { handleLine(line, false) }}
+	stderr := &lineWriter{onLine: func(line string) { handleLine(line, true) }}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// 启动命令
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start %s: %v", filepath.Base(cmd.Path), err)
+	}
+
+	// 等待命令完成和输出处理完成
+	err := cmd.Wait()
+	stdout.flush()
+	stderr.flush()
+
+	return outputBuilder.String(), err
+}
+
+// lineWriter 将写入的内容按行切分，超长的行按 maxLineLength 截断
+type lineWriter struct {
+	buf    []byte
+	onLine func(line string)
+}
+
+// maxLineLength 单行输出的最大长度
+const maxLineLength = 64 * 1024
+
+// Write 实现 io.Writer，每遇到完整的一行调用一次 onLine
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			if len(w.buf) >= maxLineLength {
+				w.onLine(string(w.buf[:maxLineLength]))
+				w.buf = w.buf[maxLineLength:]
+				continue
+			}
+			return len(p), nil
+		}
+		w.onLine(strings.TrimRight(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// flush 输出最后不以换行结尾的内容
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.onLine(strings.TrimRight(string(w.buf), "\r"))
+		w.buf = nil
+	}
+}
+
+// parseBuildResults 从构建脚本输出的 ##RESULT 行解析各项目结果，按 环境/项目 写入 result
+func (s *BuildService) parseBuildResults(output string, result *BuildResult) {
+	for _, line := range strings.Split(output, "\n") {
+		projectResult, ok := ParseResultLine(line)
+		if !ok {
+			continue
+		}
+		result.Results[projectResult.Key()] = projectResult
+		if !projectResult.Success {
+			result.Success = false
 		}
 	}
 
 	// 设置输出路径
-	workspaceDir := filepath.Join(s.config.File.BasePath, "workspace")
-	result.OutputPath = filepath.Join(workspaceDir, "dist_backup")
-	result.LogPath = filepath.Join(workspaceDir, "realtime.log")
+	result.OutputPath = filepath.Join(s.config.Build.WorkspaceDir, "dist_backup")
+	result.LogPath = filepath.Join(s.config.Build.WorkspaceDir, "realtime.log")
 }
 
 // ExecuteH5Build 执行H5项目构建（保持向后兼容）
@@ -542,7 +627,7 @@ func (s *BuildService) ExecuteH5Build(branch, version string, environments, proj
 	}
 
 	// 执行批量构建
-	_, err := s.ExecuteBatchBuild(batchReq, func(progress BuildProgress) {
+	_, err := s.ExecuteBatchBuild(context.Background(), batchReq, func(progress BuildProgress) {
 		// 转换为旧的输出消息格式
 		outputType := "output"
 		if progress.Status == "failed" {
@@ -2,9 +2,11 @@ package services
 
 import (
 	"errors"
+	"strings"
 
 	"brand-config-api/database"
 	"brand-config-api/models"
+	"brand-config-api/utils"
 
 	"gorm.io/gorm"
 )
@@ -61,16 +63,8 @@ func (s *ClientService) CreateClient(brandID int, host string) (*models.Client,
 	}
 
 	// 验证host格式
-	validHosts := []string{"h5", "tth5", "ksh5"}
-	hostValid := false
-	for _, validHost := range validHosts {
-		if host == validHost {
-			hostValid = true
-			break
-		}
-	}
-	if !hostValid {
-		return nil, errors.New("invalid host. Must be one of: h5, tth5, ksh5")
+	if err := validateClientHost(host); err != nil {
+		return nil, err
 	}
 
 	// 检查是否已存在相同的brand_id + host组合
@@ -104,16 +98,8 @@ func (s *ClientService) CreateClientWithTx(tx *gorm.DB, brandID int, host string
 	}
 
 	// 验证host格式
-	validHosts := []string{"h5", "tth5", "ksh5"}
-	hostValid := false
-	for _, validHost := range validHosts {
-		if host == validHost {
-			hostValid = true
-			break
-		}
-	}
-	if !hostValid {
-		return nil, errors.New("invalid host. Must be one of: h5, tth5, ksh5")
+	if err := validateClientHost(host); err != nil {
+		return nil, err
 	}
 
 	// 检查是否已存在相同的brand_id + host组合
@@ -152,16 +138,8 @@ func (s *ClientService) UpdateClient(id int, brandID int, host string) (*models.
 	}
 
 	// 验证host格式
-	validHosts := []string{"h5", "tth5", "ksh5"}
-	hostValid := false
-	for _, validHost := range validHosts {
-		if host == validHost {
-			hostValid = true
-			break
-		}
-	}
-	if !hostValid {
-		return nil, errors.New("invalid host. Must be one of: h5, tth5, ksh5")
+	if err := validateClientHost(host); err != nil {
+		return nil, err
 	}
 
 	// 检查是否与其他客户端冲突
@@ -195,3 +173,12 @@ func (s *ClientService) DeleteClient(id int) error {
 
 	return s.db.Delete(&models.Client{}, id).Error
 }
+
+// validateClientHost 根据端类型注册表验证host是否可以创建客户端
+func validateClientHost(host string) error {
+	registry := utils.GetPlatformRegistry()
+	if !registry.IsCreatableHost(host) {
+		return errors.New("invalid host. Must be one of: " + strings.Join(registry.CreatableHosts(), ", "))
+	}
+	return nil
+}
@@ -101,6 +101,13 @@ func (s *CommonConfigService) generateConfigFile(ctx *rollback.TransactionContex
 
 // DeleteCommonConfigByClientID 根据client_id删除通用配置（独立事务）
 func (s *CommonConfigService) DeleteCommonConfigByClientID(clientID int) error {
+	// 锁定品牌配置文件和项目共享文件（vite.config.js），避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "DeleteCommonConfigByClientID", true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
@@ -161,82 +168,73 @@ func (s *CommonConfigService) FormatCommonConfig(commonConfig models.CommonConfi
 	}
 }
 
-// UpdateCommonConfigByClientID 根据client_id更新通用配置
-func (s *CommonConfigService) UpdateCommonConfigByClientID(clientID int, commonConfig models.CommonConfig) error {
+// UpdateCommonConfigByClientID 根据client_id更新通用配置，并记录修订历史
+func (s *CommonConfigService) UpdateCommonConfigByClientID(clientID int, commonConfig models.CommonConfig, operator string) error {
+	// 锁定品牌配置文件和项目共享文件（vite.config.js），避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "UpdateCommonConfigByClientID", true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
 	return rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
-		// 获取客户端信息
-		var client models.Client
-		if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
-			return fmt.Errorf("failed to find client: %v", err)
+		revisionService := NewConfigRevisionService()
+		if err := revisionService.EnsureBaseline(ctx, clientID, models.ConfigSectionCommon); err != nil {
+			return err
 		}
 
-		// 获取品牌信息
-		var brand models.Brand
-		if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
-			return fmt.Errorf("failed to find brand: %v", err)
+		if err := s.updateCommonConfigInternal(ctx, clientID, commonConfig, false); err != nil {
+			return err
 		}
 
-		// 添加调试信息
-		log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
-			clientID, client.BrandID, brand.Code, client.Host)
+		return revisionService.RecordRevision(ctx, clientID, models.ConfigSectionCommon, models.RevisionActionUpdate, operator, "")
+	}, nil)
+}
 
-		log.Printf("🔄 开始更新通用配置: brand=%s, host=%s", brand.Code, client.Host)
+// updateCommonConfigInternal 内部更新通用配置方法（不管理事务）
+// overwrite 为 true 时按传入的配置写入所有字段（包括空值，恢复历史修订时使用），否则只更新开关和非空字段
+func (s *CommonConfigService) updateCommonConfigInternal(ctx *rollback.TransactionContext, clientID int, commonConfig models.CommonConfig, overwrite bool) error {
+	// 获取客户端信息
+	var client models.Client
+	if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
+		return fmt.Errorf("failed to find client: %v", err)
+	}
 
-		// 验证必填字段
-		if commonConfig.DeliverBusinessIDEnable && commonConfig.DeliverBusinessID == "" {
-			return fmt.Errorf("deliver_business_id is required when deliver_business_id_enable is true")
-		}
-		if commonConfig.DeliverSwitchIDEnable && commonConfig.DeliverSwitchID == "" {
-			return fmt.Errorf("deliver_switch_id is required when deliver_switch_id_enable is true")
-		}
+	// 获取品牌信息
+	var brand models.Brand
+	if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
+		return fmt.Errorf("failed to find brand: %v", err)
+	}
 
-		// 检查是否已存在通用配置记录
-		var existingCommonConfig models.CommonConfig
-		err := ctx.DB.Where("client_id = ?", clientID).First(&existingCommonConfig).Error
+	// 添加调试信息
+	log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
+		clientID, client.BrandID, brand.Code, client.Host)
 
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 记录不存在，创建新记录
-				log.Printf("📝 通用配置记录不存在，创建新记录")
-				commonConfig.ClientID = clientID
-				// 使用 Select 明确指定要创建的字段，确保零值（如 false）被正确处理
-				if err := ctx.DB.Select(
-					"client_id",
-					"deliver_business_id_enable",
-					"deliver_business_id",
-					"deliver_switch_id_enable",
-					"deliver_switch_id",
-					"protocol_company",
-					"protocol_about",
-					"protocol_privacy",
-					"protocol_vod",
-					"protocol_user_cancel",
-					"contact_url",
-					"script_base",
-				).Create(&commonConfig).Error; err != nil {
-					return fmt.Errorf("failed to create common config in database: %v", err)
-				}
-				log.Printf("✅ 数据库记录创建成功")
-			} else {
-				return fmt.Errorf("failed to check existing common config: %v", err)
-			}
-		} else {
-			// 记录存在，更新记录
-			log.Printf("📝 通用配置记录已存在，更新记录")
-			log.Printf("🔍 更新前的配置: %+v", existingCommonConfig)
-			log.Printf("🔍 要更新的配置: %+v", commonConfig)
+	log.Printf("🔄 开始更新通用配置: brand=%s, host=%s", brand.Code, client.Host)
 
-			// 保留原有的 ID 和 created_at，设置其他字段
-			commonConfig.ID = existingCommonConfig.ID
-			commonConfig.ClientID = clientID
-			commonConfig.CreatedAt = existingCommonConfig.CreatedAt
-			commonConfig.UpdatedAt = time.Now()
+	// 验证必填字段
+	if commonConfig.DeliverBusinessIDEnable && commonConfig.DeliverBusinessID == "" {
+		return fmt.Errorf("deliver_business_id is required when deliver_business_id_enable is true")
+	}
+	if commonConfig.DeliverSwitchIDEnable && commonConfig.DeliverSwitchID == "" {
+		return fmt.Errorf("deliver_switch_id is required when deliver_switch_id_enable is true")
+	}
 
-			// 使用 Select 明确指定要更新的字段，这样可以更新零值（如 false）
-			if err := ctx.DB.Model(&existingCommonConfig).Select(
+	// 检查是否已存在通用配置记录
+	var existingCommonConfig models.CommonConfig
+	err := ctx.DB.Where("client_id = ?", clientID).First(&existingCommonConfig).Error
+	oldScriptBase := existingCommonConfig.ScriptBase
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// 记录不存在，创建新记录
+			log.Printf("📝 通用配置记录不存在，创建新记录")
+			commonConfig.ClientID = clientID
+			// 使用 Select 明确指定要创建的字段，确保零值（如 false）被正确处理
+			if err := ctx.DB.Select(
 				"client_id",
 				"deliver_business_id_enable",
 				"deliver_business_id",
@@ -249,23 +247,102 @@ func (s *CommonConfigService) UpdateCommonConfigByClientID(clientID int, commonC
 				"protocol_user_cancel",
 				"contact_url",
 				"script_base",
-				"updated_at",
-			).Updates(&commonConfig).Error; err != nil {
-				return fmt.Errorf("failed to update common config in database: %v", err)
+			).Create(&commonConfig).Error; err != nil {
+				return fmt.Errorf("failed to create common config in database: %v", err)
 			}
-			log.Printf("✅ 数据库记录更新成功")
+			log.Printf("✅ 数据库记录创建成功")
+		} else {
+			return fmt.Errorf("failed to check existing common config: %v", err)
 		}
+	} else {
+		// 记录存在，更新记录
+		log.Printf("📝 通用配置记录已存在，更新记录")
+		log.Printf("🔍 更新前的配置: %+v", existingCommonConfig)
+		log.Printf("🔍 要更新的配置: %+v", commonConfig)
+
+		// 保留原有的 ID 和 created_at，设置其他字段
+		commonConfig.ID = existingCommonConfig.ID
+		commonConfig.ClientID = clientID
+		commonConfig.CreatedAt = existingCommonConfig.CreatedAt
+		commonConfig.UpdatedAt = time.Now()
+
+		// 恢复历史修订时使用 Select 写入所有字段（包括空值）；
+		// 普通更新总是写入开关（可以关闭），其他字段只写入非空值，不覆盖未传入的字段
+		if overwrite {
+			err = ctx.DB.Model(&existingCommonConfig).Select(
+				"client_id",
+				"deliver_business_id_enable",
+				"deliver_business_id",
+				"deliver_switch_id_enable",
+				"deliver_switch_id",
+				"protocol_company",
+				"protocol_about",
+				"protocol_privacy",
+				"protocol_vod",
+				"protocol_user_cancel",
+				"contact_url",
+				"script_base",
+				"updated_at",
+			).Updates(&commonConfig).Error
+		} else {
+			err = ctx.DB.Model(&existingCommonConfig).Updates(commonConfigUpdates(commonConfig)).Error
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update common config in database: %v", err)
+		}
+		log.Printf("✅ 数据库记录更新成功")
 
-		// 更新本地配置文件
-		configFile := filepath.Join(s.config.File.CommonConfigsDir, brand.Code+".js")
-		hostConfig := s.FormatCommonConfig(commonConfig)
+		// 配置文件按更新后的完整记录生成
+		var updatedCommonConfig models.CommonConfig
+		if err := ctx.DB.First(&updatedCommonConfig, existingCommonConfig.ID).Error; err != nil {
+			return fmt.Errorf("failed to reload common config: %v", err)
+		}
+		commonConfig = updatedCommonConfig
+	}
 
-		configFileUtils := utils.NewConfigFileUtils()
-		if err := configFileUtils.UpdateConfigFileHost(ctx, configFile, hostConfig, client.Host); err != nil {
-			return fmt.Errorf("failed to update config file host: %v", err)
+	// 更新本地配置文件
+	configFile := filepath.Join(s.config.File.CommonConfigsDir, brand.Code+".js")
+	hostConfig := s.FormatCommonConfig(commonConfig)
+
+	configFileUtils := utils.NewConfigFileUtils()
+	if err := configFileUtils.UpdateConfigFileHost(ctx, configFile, hostConfig, client.Host); err != nil {
+		return fmt.Errorf("failed to update config file host: %v", err)
+	}
+
+	// script_base变化时同步更新vite.config.js中的basePathMap
+	if oldScriptBase != commonConfig.ScriptBase && commonConfig.ScriptBase != "" {
+		fileService := newFileServiceWithConfig(s.config)
+		if err := fileService.updateViteConfigFile(brand.Code, client.Host, commonConfig.ScriptBase, ctx.Files); err != nil {
+			return fmt.Errorf("failed to update vite.config.js: %v", err)
 		}
+	}
 
-		log.Printf("✅ 通用配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
-		return nil
-	}, nil)
+	log.Printf("✅ 通用配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
+	return nil
+}
+
+// commonConfigUpdates 普通更新时写入的字段：开关总是写入，其他字段只写入非空值
+func commonConfigUpdates(commonConfig models.CommonConfig) map[string]interface{} {
+	updates := map[string]interface{}{
+		"deliver_business_id_enable": commonConfig.DeliverBusinessIDEnable,
+		"deliver_switch_id_enable":   commonConfig.DeliverSwitchIDEnable,
+		"updated_at":                 commonConfig.UpdatedAt,
+	}
+	optional := map[string]string{
+		"deliver_business_id":  commonConfig.DeliverBusinessID,
+		"deliver_switch_id":    commonConfig.DeliverSwitchID,
+		"protocol_company":     commonConfig.ProtocolCompany,
+		"protocol_about":       commonConfig.ProtocolAbout,
+		"protocol_privacy":     commonConfig.ProtocolPrivacy,
+		"protocol_vod":         commonConfig.ProtocolVod,
+		"protocol_user_cancel": commonConfig.ProtocolUserCancel,
+		"contact_url":          commonConfig.ContactURL,
+		"script_base":          commonConfig.ScriptBase,
+	}
+	for column, value := range optional {
+		if value != "" {
+			updates[column] = value
+		}
+	}
+	return updates
 }
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"brand-config-api/config"
+	"brand-config-api/database"
+	"brand-config-api/models"
+	"brand-config-api/utils/rollback"
+
+	"gorm.io/gorm"
+)
+
+// ConfigRevisionService 配置修订历史服务
+type ConfigRevisionService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewConfigRevisionService 创建配置修订历史服务实例
+func NewConfigRevisionService() *ConfigRevisionService {
+	return &ConfigRevisionService{
+		db:     database.DB,
+		config: config.Load(),
+	}
+}
+
+// ConfigFieldChange 两个修订之间单个字段的变更
+type ConfigFieldChange struct {
+	Field    string      `json:"field"`
+	Type     string      `json:"type"` // added/removed/modified
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
+
+// ConfigRevisionDiff 两个修订之间的字段级差异
+type ConfigRevisionDiff struct {
+	ClientID int                 `json:"client_id"`
+	Section  string              `json:"section"`
+	From     int                 `json:"from"`
+	To       int                 `json:"to"`
+	Changes  []ConfigFieldChange `json:"changes"`
+}
+
+// snapshotExcludedFields 快照中不记录的字段（主键、关联和时间戳）
+var snapshotExcludedFields = []string{"id", "client_id", "client", "created_at", "updated_at"}
+
+// newSectionModel 根据配置块标识创建对应的模型实例
+func newSectionModel(section string) (interface{}, error) {
+	switch section {
+	case models.ConfigSectionBase:
+		return &models.BaseConfig{}, nil
+	case models.ConfigSectionCommon:
+		return &models.CommonConfig{}, nil
+	case models.ConfigSectionPay:
+		return &models.PayConfig{}, nil
+	case models.ConfigSectionUI:
+		return &models.UIConfig{}, nil
+	case models.ConfigSectionNovel:
+		return &models.NovelConfig{}, nil
+	}
+	return nil, fmt.Errorf("invalid config section: %s", section)
+}
+
+// loadSnapshot 读取客户端当前配置并转换为快照，记录不存在时返回 nil
+func (s *ConfigRevisionService) loadSnapshot(db *gorm.DB, clientID int, section string) (map[string]interface{}, error) {
+	record, err := newSectionModel(section)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Where("client_id = ?", clientID).First(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load %s config: %v", section, err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s config: %v", section, err)
+	}
+
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s config: %v", section, err)
+	}
+
+	for _, field := range snapshotExcludedFields {
+		delete(snapshot, field)
+	}
+	return snapshot, nil
+}
+
+// createRevision 追加一条修订记录，修订号在同一客户端同一配置块内递增
+func (s *ConfigRevisionService) createRevision(db *gorm.DB, clientID int, section, action, operator, remark string, snapshot map[string]interface{}) (*models.ConfigRevision, error) {
+	var maxRevision int
+	if err := db.Model(&models.ConfigRevision{}).
+		Where("client_id = ? AND section = ?", clientID, section).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&maxRevision).Error; err != nil {
+		return nil, fmt.Errorf("failed to query latest revision: %v", err)
+	}
+
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal revision content: %v", err)
+	}
+
+	revision := &models.ConfigRevision{
+		ClientID: clientID,
+		Section:  section,
+		Revision: maxRevision + 1,
+		Action:   action,
+		Operator: operator,
+		Remark:   remark,
+		Content:  string(content),
+	}
+	if err := db.Create(revision).Error; err != nil {
+		return nil, fmt.Errorf("failed to create config revision: %v", err)
+	}
+
+	log.Printf("📝 记录配置修订: client=%d, section=%s, revision=%d, action=%s", clientID, section, revision.Revision, action)
+	return revision, nil
+}
+
+// EnsureBaseline 首次修改前记录当前配置作为基线修订，保证修改前的状态可以恢复
+func (s *ConfigRevisionService) EnsureBaseline(ctx *rollback.TransactionContext, clientID int, section string) error {
+	var count int64
+	if err := ctx.DB.Model(&models.ConfigRevision{}).
+		Where("client_id = ? AND section = ?", clientID, section).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count config revisions: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	snapshot, err := s.loadSnapshot(ctx.DB, clientID, section)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		return nil
+	}
+
+	_, err = s.createRevision(ctx.DB, clientID, section, models.RevisionActionBaseline, "system", "", snapshot)
+	return err
+}
+
+// RecordRevision 记录修改后的配置快照（需在同一事务内、修改完成后调用）
+func (s *ConfigRevisionService) RecordRevision(ctx *rollback.TransactionContext, clientID int, section, action, operator, remark string) error {
+	snapshot, err := s.loadSnapshot(ctx.DB, clientID, section)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		return fmt.Errorf("%s config of client %d not found after update", section, clientID)
+	}
+
+	_, err = s.createRevision(ctx.DB, clientID, section, action, operator, remark, snapshot)
+	return err
+}
+
+// GetRevisions 获取客户端的修订列表，section 为空时返回所有配置块
+func (s *ConfigRevisionService) GetRevisions(clientID int, section string) ([]models.ConfigRevision, error) {
+	query := s.db.Where("client_id = ?", clientID)
+	if section != "" {
+		query = query.Where("section = ?", section)
+	}
+
+	var revisions []models.ConfigRevision
+	err := query.Order("section").Order("revision DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+// GetRevision 获取指定修订
+func (s *ConfigRevisionService) GetRevision(clientID int, section string, revision int) (*models.ConfigRevision, error) {
+	var record models.ConfigRevision
+	if err := s.db.Where("client_id = ? AND section = ? AND revision = ?", clientID, section, revision).
+		First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// DiffRevisions 比较两个修订之间的字段差异
+func (s *ConfigRevisionService) DiffRevisions(clientID int, section string, from, to int) (*ConfigRevisionDiff, error) {
+	fromRevision, err := s.GetRevision(clientID, section, from)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d not found: %v", from, err)
+	}
+	toRevision, err := s.GetRevision(clientID, section, to)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d not found: %v", to, err)
+	}
+
+	var oldContent, newContent map[string]interface{}
+	if err := json.Unmarshal([]byte(fromRevision.Content), &oldContent); err != nil {
+		return nil, fmt.Errorf("failed to parse revision %d: %v", from, err)
+	}
+	if err := json.Unmarshal([]byte(toRevision.Content), &newContent); err != nil {
+		return nil, fmt.Errorf("failed to parse revision %d: %v", to, err)
+	}
+
+	return &ConfigRevisionDiff{
+		ClientID: clientID,
+		Section:  section,
+		From:     from,
+		To:       to,
+		Changes:  diffSnapshots(oldContent, newContent),
+	}, nil
+}
+
+// diffSnapshots 按字段名排序比较两个快照
+func diffSnapshots(oldContent, newContent map[string]interface{}) []ConfigFieldChange {
+	fields := make(map[string]bool)
+	for field := range oldContent {
+		fields[field] = true
+	}
+	for field := range newContent {
+		fields[field] = true
+	}
+
+	var names []string
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	changes := make([]ConfigFieldChange, 0)
+	for _, field := range names {
+		oldValue, inOld := oldContent[field]
+		newValue, inNew := newContent[field]
+		switch {
+		case !inOld:
+			changes = append(changes, ConfigFieldChange{Field: field, Type: "added", NewValue: newValue})
+		case !inNew:
+			changes = append(changes, ConfigFieldChange{Field: field, Type: "removed", OldValue: oldValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, ConfigFieldChange{Field: field, Type: "modified", OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return changes
+}
+
+// RestoreRevision 将配置恢复到指定修订，数据库和配置文件在同一事务中重写
+func (s *ConfigRevisionService) RestoreRevision(clientID int, section string, revision int, operator string) (*models.ConfigRevision, error) {
+	target, err := s.GetRevision(clientID, section, revision)
+	if err != nil {
+		return nil, fmt.Errorf("revision %d not found: %v", revision, err)
+	}
+
+	log.Printf("🔄 开始恢复配置修订: client=%d, section=%s, revision=%d", clientID, section, revision)
+
+	// 锁定品牌配置文件和项目共享文件，避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "RestoreRevision", true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		if err := s.EnsureBaseline(ctx, clientID, section); err != nil {
+			return err
+		}
+
+		if err := s.applyRevision(ctx, clientID, section, target.Content); err != nil {
+			return err
+		}
+
+		remark := fmt.Sprintf("restore from revision %d", revision)
+		return s.RecordRevision(ctx, clientID, section, models.RevisionActionRestore, operator, remark)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest models.ConfigRevision
+	if err := s.db.Where("client_id = ? AND section = ?", clientID, section).
+		Order("revision DESC").First(&latest).Error; err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ 配置修订恢复成功: client=%d, section=%s, revision=%d -> %d", clientID, section, revision, latest.Revision)
+	return &latest, nil
+}
+
+// applyRevision 通过各配置服务的内部更新方法写回修订内容（数据库和配置文件）
+func (s *ConfigRevisionService) applyRevision(ctx *rollback.TransactionContext, clientID int, section, content string) error {
+	record, err := newSectionModel(section)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(content), record); err != nil {
+		return fmt.Errorf("failed to parse revision content: %v", err)
+	}
+
+	switch cfg := record.(type) {
+	case *models.BaseConfig:
+		return NewBaseConfigService().updateBaseConfigInternal(ctx, clientID, *cfg, true)
+	case *models.CommonConfig:
+		return NewCommonConfigService().updateCommonConfigInternal(ctx, clientID, *cfg, true)
+	case *models.PayConfig:
+		return NewPayConfigService().updatePayConfigInternal(ctx, clientID, *cfg)
+	case *models.UIConfig:
+		return NewUIConfigService().updateUIConfigInternal(ctx, clientID, *cfg, true)
+	case *models.NovelConfig:
+		return NewNovelConfigService().updateNovelConfigInternal(ctx, clientID, *cfg, true)
+	}
+	return fmt.Errorf("invalid config section: %s", section)
+}
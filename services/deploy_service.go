@@ -2,13 +2,16 @@ package services
 
 import (
 	"brand-config-api/config"
+	"brand-config-api/utils"
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -55,7 +58,7 @@ type DeployResult struct {
 
 // OutputMessage 输出消息
 type OutputMessage struct {
-	Type    string `json:"type"` // output, error, success, failed
+	Type    string `json:"type"` // output, error, success, failed, cancelled
 	Message string `json:"message"`
 }
 
@@ -231,8 +234,9 @@ func (s *DeployService) buildScriptCommand(config NginxDeployConfig, scriptDir s
 	return scriptCmd
 }
 
-// ExecuteDeployScriptWithStream 远程执行nginx部署脚本（带流式输出）
-func (s *DeployService) ExecuteDeployScriptWithStream(config NginxDeployConfig, outputChan chan<- OutputMessage) error {
+// ExecuteDeployScriptWithStream 远程执行nginx部署脚本（带流式输出），ctx 取消时关闭SSH会话
+// 远程脚本的输出按输出分类规则分类，命中错误规则的行以 error 类型发送并记录到 summary（可为nil）
+func (s *DeployService) ExecuteDeployScriptWithStream(ctx context.Context, config NginxDeployConfig, outputChan chan<- OutputMessage, summary *utils.LogSummary) error {
 	log.Printf("🚀 开始远程执行nginx部署脚本: %s -> %s (端口: %d)", config.Domain, config.LocationPath, config.Port)
 
 	// 发送开始消息
@@ -268,6 +272,18 @@ func (s *DeployService) ExecuteDeployScriptWithStream(config NginxDeployConfig,
 	}
 	defer client.Close()
 
+	// 取消时关闭SSH连接，正在执行的会话随之关闭，远程脚本因伪终端挂断而退出
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Printf("⛔ 部署任务已取消，关闭SSH会话: %s", config.Server.Host)
+			client.Close()
+		case <-done:
+		}
+	}()
+
 	outputChan <- OutputMessage{Type: "output", Message: "✅ SSH连接建立成功"}
 
 	// 检查并上传脚本文件
@@ -385,26 +401,34 @@ func (s *DeployService) ExecuteDeployScriptWithStream(config NginxDeployConfig,
 	outputChan <- OutputMessage{Type: "output", Message: "🚀 远程脚本开始执行..."}
 	outputChan <- OutputMessage{Type: "output", Message: strings.Repeat("=", 60)}
 
-	// 读取stdout
-	go func() {
-		scanner := bufio.NewScanner(stdout)
+	// 读取stdout和stderr，按规则分类
+	var readers sync.WaitGroup
+	readOutput := func(r io.Reader, isStderr bool) {
+		defer readers.Done()
+		scanner := bufio.NewScanner(r)
 		for scanner.Scan() {
 			line := scanner.Text()
-			outputChan <- OutputMessage{Type: "output", Message: line}
+			classification := utils.GetLogClassifier().Classify(line, isStderr)
+			summary.Add(line, classification)
+			msgType := "output"
+			if classification.Severity == utils.LogSeverityError {
+				msgType = "error"
+			}
+			outputChan <- OutputMessage{Type: msgType, Message: line}
 		}
-	}()
+	}
+	readers.Add(2)
+	go readOutput(stdout, false)
+	go readOutput(stderr, true)
 
-	// 读取stderr
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			line := scanner.Text()
-			outputChan <- OutputMessage{Type: "error", Message: line}
+	// 等待命令完成，并等待输出读取完毕
+	err = session.Wait()
+	readers.Wait()
+	if err != nil {
+		if ctx.Err() != nil {
+			outputChan <- OutputMessage{Type: "cancelled", Message: "⛔ 远程部署已取消，SSH会话已关闭"}
+			return fmt.Errorf("deploy cancelled: %v", ctx.Err())
 		}
-	}()
-
-	// 等待命令完成
-	if err := session.Wait(); err != nil {
 		errMsg := fmt.Sprintf("远程脚本执行失败: %v", err)
 		outputChan <- OutputMessage{Type: "error", Message: errMsg}
 		outputChan <- OutputMessage{Type: "failed", Message: "远程部署失败"}
@@ -0,0 +1,697 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"brand-config-api/config"
+	"brand-config-api/database"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+	"brand-config-api/utils/rollback"
+
+	"gorm.io/gorm"
+)
+
+// 配置块漂移状态
+const (
+	DriftStatusInSync        = "in_sync"         // 文件与数据库一致
+	DriftStatusDifferent     = "different"       // 文件与数据库存在差异
+	DriftStatusMissingInFile = "missing_in_file" // 数据库有记录，文件中没有该host配置
+	DriftStatusMissingInDB   = "missing_in_db"   // 文件中有该host配置，数据库没有记录
+	DriftStatusError         = "error"           // 配置文件读取或解析失败
+)
+
+// 键级差异类型
+const (
+	DriftKeyMissing   = "missing"   // 文件中缺少该键
+	DriftKeyExtra     = "extra"     // 文件中多出该键
+	DriftKeyDifferent = "different" // 值不同
+)
+
+// driftSections 参与漂移检测的配置块
+var driftSections = []string{
+	models.ConfigSectionBase,
+	models.ConfigSectionCommon,
+	models.ConfigSectionPay,
+	models.ConfigSectionUI,
+	models.ConfigSectionNovel,
+}
+
+// DriftKeyDiff 配置文件与数据库之间单个键的差异
+type DriftKeyDiff struct {
+	Key      string      `json:"key"`      // 以点分隔的键路径，如 protocol.about
+	Type     string      `json:"type"`     // missing/extra/different
+	Expected interface{} `json:"expected"` // 根据数据库生成的值
+	Actual   interface{} `json:"actual"`   // 配置文件中的值
+}
+
+// SectionDrift 单个配置块的漂移情况
+type SectionDrift struct {
+	Section string         `json:"section"`
+	File    string         `json:"file"`
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Diffs   []DriftKeyDiff `json:"diffs"`
+}
+
+// ClientDrift 单个客户端的漂移情况
+type ClientDrift struct {
+	ClientID  int            `json:"client_id"`
+	BrandCode string         `json:"brand_code"`
+	Host      string         `json:"host"`
+	Drifted   bool           `json:"drifted"`
+	Sections  []SectionDrift `json:"sections"`
+}
+
+// DriftReport 漂移检测报告
+type DriftReport struct {
+	CheckedAt      time.Time     `json:"checked_at"`
+	TotalClients   int           `json:"total_clients"`
+	DriftedClients int           `json:"drifted_clients"`
+	Clients        []ClientDrift `json:"clients"`
+}
+
+// DriftActionResult 重新生成或导入操作的结果
+type DriftActionResult struct {
+	ClientID int          `json:"client_id"`
+	Applied  []string     `json:"applied"` // 已处理的配置块
+	Skipped  []string     `json:"skipped"` // 无可用数据而跳过的配置块
+	Drift    *ClientDrift `json:"drift"`   // 操作后的漂移情况
+}
+
+// driftFileCache 单次检测内的配置文件缓存，避免同一品牌文件重复解析
+type driftFileCache struct {
+	data   map[string]map[string]interface{}
+	errors map[string]error
+}
+
+var (
+	lastDriftReport *DriftReport
+	driftReportMu   sync.RWMutex
+	driftJobOnce    sync.Once
+)
+
+// DriftService 数据库与配置文件漂移检测服务
+type DriftService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewDriftService 创建漂移检测服务实例
+func NewDriftService() *DriftService {
+	return &DriftService{
+		db:     database.DB,
+		config: config.Load(),
+	}
+}
+
+// StartReconciliationJob 启动定时漂移检查（间隔由 DRIFT_CHECK_INTERVAL 配置，单位分钟，0 表示关闭）
+func (s *DriftService) StartReconciliationJob() {
+	interval := s.config.DriftCheckInterval
+	if interval <= 0 {
+		log.Printf("⏸️ 配置漂移定时检查已关闭")
+		return
+	}
+
+	driftJobOnce.Do(func() {
+		log.Printf("🕒 配置漂移定时检查已启动，间隔: %d 分钟", interval)
+		go func() {
+			ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+			defer ticker.Stop()
+
+			for {
+				if _, err := s.CheckDrift(0); err != nil {
+					log.Printf("❌ 配置漂移检查失败: %v", err)
+				}
+				<-ticker.C
+			}
+		}()
+	})
+}
+
+// GetLastReport 获取最近一次完整检查的报告，尚未检查时返回 nil
+func (s *DriftService) GetLastReport() *DriftReport {
+	driftReportMu.RLock()
+	defer driftReportMu.RUnlock()
+	return lastDriftReport
+}
+
+// CheckDrift 检查客户端的数据库配置与配置文件是否一致，clientID 为 0 时检查所有客户端
+func (s *DriftService) CheckDrift(clientID int) (*DriftReport, error) {
+	var clients []models.Client
+	query := s.db.Preload("Brand").Order("id")
+	if clientID > 0 {
+		query = query.Where("id = ?", clientID)
+	}
+	if err := query.Find(&clients).Error; err != nil {
+		return nil, fmt.Errorf("failed to get clients: %v", err)
+	}
+	if clientID > 0 && len(clients) == 0 {
+		return nil, fmt.Errorf("client %d not found", clientID)
+	}
+
+	report := &DriftReport{
+		CheckedAt:    time.Now(),
+		TotalClients: len(clients),
+		Clients:      make([]ClientDrift, 0, len(clients)),
+	}
+	files := &driftFileCache{
+		data:   make(map[string]map[string]interface{}),
+		errors: make(map[string]error),
+	}
+
+	for _, client := range clients {
+		drift := s.checkClient(client, files)
+		if drift.Drifted {
+			report.DriftedClients++
+		}
+		report.Clients = append(report.Clients, drift)
+	}
+
+	if clientID == 0 {
+		driftReportMu.Lock()
+		lastDriftReport = report
+		driftReportMu.Unlock()
+
+		if report.DriftedClients > 0 {
+			log.Printf("⚠️ 配置漂移检查完成: %d/%d 个客户端存在漂移", report.DriftedClients, report.TotalClients)
+		} else {
+			log.Printf("✅ 配置漂移检查完成: %d 个客户端全部一致", report.TotalClients)
+		}
+	}
+	return report, nil
+}
+
+// checkClient 检查单个客户端所有配置块
+func (s *DriftService) checkClient(client models.Client, files *driftFileCache) ClientDrift {
+	drift := ClientDrift{
+		ClientID:  client.ID,
+		BrandCode: client.Brand.Code,
+		Host:      client.Host,
+		Sections:  make([]SectionDrift, 0, len(driftSections)),
+	}
+
+	for _, section := range driftSections {
+		sectionDrift, ok := s.checkSection(client, section, files)
+		if !ok {
+			continue
+		}
+		if sectionDrift.Status != DriftStatusInSync {
+			drift.Drifted = true
+		}
+		drift.Sections = append(drift.Sections, sectionDrift)
+	}
+	return drift
+}
+
+// checkSection 检查单个配置块；数据库和文件都没有该配置时返回 false
+func (s *DriftService) checkSection(client models.Client, section string, files *driftFileCache) (SectionDrift, bool) {
+	result := SectionDrift{
+		Section: section,
+		File:    s.sectionFile(section, client.Brand.Code),
+		Status:  DriftStatusInSync,
+		Diffs:   make([]DriftKeyDiff, 0),
+	}
+
+	expected, err := s.expectedBlock(s.db, section, client.ID)
+	if err != nil {
+		result.Status = DriftStatusError
+		result.Error = err.Error()
+		return result, true
+	}
+
+	actual, err := s.actualBlock(files, section, client.Brand.Code, client.Host)
+	if err != nil {
+		result.Status = DriftStatusError
+		result.Error = err.Error()
+		return result, true
+	}
+
+	switch {
+	case expected == nil && actual == nil:
+		return result, false
+	case actual == nil:
+		result.Status = DriftStatusMissingInFile
+	case expected == nil:
+		result.Status = DriftStatusMissingInDB
+	default:
+		result.Diffs = diffConfigBlocks("", expected, actual)
+		if len(result.Diffs) > 0 {
+			result.Status = DriftStatusDifferent
+		}
+	}
+	return result, true
+}
+
+// sectionFile 配置块对应的配置文件路径
+func (s *DriftService) sectionFile(section, brandCode string) string {
+	switch section {
+	case models.ConfigSectionBase:
+		return filepath.Join(s.config.File.BaseConfigsDir, brandCode+".js")
+	case models.ConfigSectionCommon:
+		return filepath.Join(s.config.File.CommonConfigsDir, brandCode+".js")
+	case models.ConfigSectionPay:
+		return filepath.Join(s.config.File.PayConfigsDir, brandCode+".js")
+	case models.ConfigSectionUI:
+		return filepath.Join(s.config.File.UIConfigsDir, brandCode+".js")
+	case models.ConfigSectionNovel:
+		return filepath.Join(s.config.File.LocalConfigsDir, "novelConfig.js")
+	}
+	return ""
+}
+
+// loadSectionRecord 读取客户端的配置块记录，不存在时返回 nil
+func (s *DriftService) loadSectionRecord(db *gorm.DB, section string, clientID int) (interface{}, error) {
+	record, err := newSectionModel(section)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Where("client_id = ?", clientID).First(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load %s config: %v", section, err)
+	}
+	return record, nil
+}
+
+// expectedBlock 使用各配置服务的 Format 方法生成数据库记录对应的host配置，记录不存在时返回 nil
+func (s *DriftService) expectedBlock(db *gorm.DB, section string, clientID int) (map[string]interface{}, error) {
+	record, err := s.loadSectionRecord(db, section, clientID)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	var block map[string]interface{}
+	switch cfg := record.(type) {
+	case *models.BaseConfig:
+		block = (&BaseConfigService{db: s.db, config: s.config}).FormatBaseConfig(*cfg)
+	case *models.CommonConfig:
+		block = (&CommonConfigService{db: s.db, config: s.config}).FormatCommonConfig(*cfg)
+	case *models.PayConfig:
+		block = (&PayConfigService{db: s.db, config: s.config}).FormatPayConfig(*cfg)
+	case *models.UIConfig:
+		block = (&UIConfigService{db: s.db, config: s.config}).FormatUIConfig(*cfg)
+	case *models.NovelConfig:
+		block = (&NovelConfigService{db: s.db, config: s.config}).FormatNovelConfig(*cfg)
+	}
+
+	// 转换为与解析配置文件相同的类型（数字为float64等），便于比较
+	data, err := json.Marshal(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s config: %v", section, err)
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s config: %v", section, err)
+	}
+	return normalized, nil
+}
+
+// actualBlock 读取配置文件中的host配置，文件或配置不存在时返回 nil
+func (s *DriftService) actualBlock(files *driftFileCache, section, brandCode, host string) (map[string]interface{}, error) {
+	configFile := s.sectionFile(section, brandCode)
+	configData, err := files.read(configFile)
+	if err != nil || configData == nil {
+		return nil, err
+	}
+
+	// novelConfig.js 使用 brandCode -> host 的结构
+	if section == models.ConfigSectionNovel {
+		brandConfig, exists := configData[brandCode]
+		if !exists {
+			return nil, nil
+		}
+		brandConfigMap, ok := brandConfig.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid brand config format in %s", configFile)
+		}
+		configData = brandConfigMap
+	}
+
+	hostConfig, exists := configData[host]
+	if !exists {
+		return nil, nil
+	}
+	hostConfigMap, ok := hostConfig.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid host config format in %s", configFile)
+	}
+	return hostConfigMap, nil
+}
+
+// read 读取并解析配置文件，文件不存在时返回 nil
+func (c *driftFileCache) read(configFile string) (map[string]interface{}, error) {
+	if err, exists := c.errors[configFile]; exists {
+		return nil, err
+	}
+	if data, exists := c.data[configFile]; exists {
+		return data, nil
+	}
+
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.data[configFile] = nil
+			return nil, nil
+		}
+		c.errors[configFile] = fmt.Errorf("failed to read %s: %v", configFile, err)
+		return nil, c.errors[configFile]
+	}
+
+	data, err := utils.ParseJSConfig(string(content))
+	if err != nil {
+		c.errors[configFile] = fmt.Errorf("failed to parse %s: %v", configFile, err)
+		return nil, c.errors[configFile]
+	}
+	c.data[configFile] = data
+	return data, nil
+}
+
+// diffConfigBlocks 递归比较期望配置和文件配置，返回按键路径排序的差异
+func diffConfigBlocks(prefix string, expected, actual map[string]interface{}) []DriftKeyDiff {
+	keys := make(map[string]bool)
+	for key := range expected {
+		keys[key] = true
+	}
+	for key := range actual {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	diffs := make([]DriftKeyDiff, 0)
+	for _, key := range sortedKeys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		expectedValue, inExpected := expected[key]
+		actualValue, inActual := actual[key]
+		switch {
+		case !inActual:
+			diffs = append(diffs, DriftKeyDiff{Key: path, Type: DriftKeyMissing, Expected: expectedValue})
+		case !inExpected:
+			diffs = append(diffs, DriftKeyDiff{Key: path, Type: DriftKeyExtra, Actual: actualValue})
+		default:
+			expectedMap, expectedIsMap := expectedValue.(map[string]interface{})
+			actualMap, actualIsMap := actualValue.(map[string]interface{})
+			if expectedIsMap && actualIsMap {
+				diffs = append(diffs, diffConfigBlocks(path, expectedMap, actualMap)...)
+			} else if !reflect.DeepEqual(expectedValue, actualValue) {
+				diffs = append(diffs, DriftKeyDiff{Key: path, Type: DriftKeyDifferent, Expected: expectedValue, Actual: actualValue})
+			}
+		}
+	}
+	return diffs
+}
+
+// normalizeDriftSections 校验配置块列表，为空时返回所有配置块
+func normalizeDriftSections(sections []string) ([]string, error) {
+	if len(sections) == 0 {
+		return driftSections, nil
+	}
+	for _, section := range sections {
+		if !models.IsValidConfigSection(section) {
+			return nil, fmt.Errorf("invalid config section: %s", section)
+		}
+	}
+	return sections, nil
+}
+
+// findDriftClient 获取客户端及其品牌
+func (s *DriftService) findDriftClient(clientID int) (*models.Client, error) {
+	var client models.Client
+	if err := s.db.Preload("Brand").First(&client, clientID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find client: %v", err)
+	}
+	return &client, nil
+}
+
+// checkClientAfterAction 操作完成后重新检查客户端
+func (s *DriftService) checkClientAfterAction(result *DriftActionResult) (*DriftActionResult, error) {
+	report, err := s.CheckDrift(result.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	result.Drift = &report.Clients[0]
+	return result, nil
+}
+
+// RegenerateClient 根据数据库记录重新生成客户端的配置文件（覆盖文件中的手动修改）
+func (s *DriftService) RegenerateClient(clientID int, sections []string) (*DriftActionResult, error) {
+	sections, err := normalizeDriftSections(sections)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.findDriftClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 锁定品牌配置文件和项目共享文件，避免与其他修改并发
+	release, err := lockBrandFiles(s.config, fmt.Sprintf("RegenerateClient(client=%d)", clientID), true, client.Brand.Code)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result := &DriftActionResult{ClientID: clientID, Applied: []string{}, Skipped: []string{}}
+	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		for _, section := range sections {
+			record, err := s.loadSectionRecord(ctx.DB, section, clientID)
+			if err != nil {
+				return err
+			}
+			if record == nil {
+				result.Skipped = append(result.Skipped, section)
+				continue
+			}
+
+			if err := s.generateSectionFile(ctx, record, client.Brand.Code, client.Host); err != nil {
+				return fmt.Errorf("failed to regenerate %s config: %v", section, err)
+			}
+			result.Applied = append(result.Applied, section)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ 已根据数据库重新生成配置文件: client=%d, sections=%s", clientID, strings.Join(result.Applied, ","))
+	return s.checkClientAfterAction(result)
+}
+
+// generateSectionFile 调用各配置服务生成配置文件
+func (s *DriftService) generateSectionFile(ctx *rollback.TransactionContext, record interface{}, brandCode, host string) error {
+	switch cfg := record.(type) {
+	case *models.BaseConfig:
+		return (&BaseConfigService{db: s.db, config: s.config}).generateConfigFile(ctx, cfg, brandCode, host)
+	case *models.CommonConfig:
+		return (&CommonConfigService{db: s.db, config: s.config}).generateConfigFile(ctx, cfg, brandCode, host)
+	case *models.PayConfig:
+		return (&PayConfigService{db: s.db, config: s.config}).generateConfigFile(ctx, cfg, brandCode, host)
+	case *models.UIConfig:
+		return (&UIConfigService{db: s.db, config: s.config}).generateConfigFile(ctx, cfg, brandCode, host)
+	case *models.NovelConfig:
+		return (&NovelConfigService{db: s.db, config: s.config}).generateConfigFile(ctx, cfg, brandCode, host)
+	}
+	return fmt.Errorf("unsupported config record: %T", record)
+}
+
+// ImportClient 将配置文件中的host配置导入数据库（不修改配置文件），并记录修订历史
+func (s *DriftService) ImportClient(clientID int, sections []string, operator string) (*DriftActionResult, error) {
+	sections, err := normalizeDriftSections(sections)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.findDriftClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := &driftFileCache{
+		data:   make(map[string]map[string]interface{}),
+		errors: make(map[string]error),
+	}
+
+	// 锁定品牌配置文件和项目共享文件，避免与其他修改并发
+	release, err := lockBrandFiles(s.config, fmt.Sprintf("ImportClient(client=%d)", clientID), true, client.Brand.Code)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result := &DriftActionResult{ClientID: clientID, Applied: []string{}, Skipped: []string{}}
+	revisionService := NewConfigRevisionService()
+	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		for _, section := range sections {
+			block, err := s.actualBlock(files, section, client.Brand.Code, client.Host)
+			if err != nil {
+				return err
+			}
+			if block == nil {
+				result.Skipped = append(result.Skipped, section)
+				continue
+			}
+
+			if err := revisionService.EnsureBaseline(ctx, clientID, section); err != nil {
+				return err
+			}
+
+			record, err := s.loadSectionRecord(ctx.DB, section, clientID)
+			if err != nil {
+				return err
+			}
+			if record == nil {
+				record, _ = newSectionModel(section)
+				applyConfigBlock(record, clientID, block)
+				err = createImportedRecord(ctx.DB, record)
+			} else {
+				applyConfigBlock(record, clientID, block)
+				err = ctx.DB.Omit("Client").Save(record).Error
+			}
+			if err != nil {
+				return fmt.Errorf("failed to import %s config: %v", section, err)
+			}
+
+			if err := revisionService.RecordRevision(ctx, clientID, section, models.RevisionActionImport, operator, "从配置文件导入"); err != nil {
+				return err
+			}
+			result.Applied = append(result.Applied, section)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ 已将配置文件导入数据库: client=%d, sections=%s", clientID, strings.Join(result.Applied, ","))
+	return s.checkClientAfterAction(result)
+}
+
+// createImportedRecord 创建从配置文件导入的记录
+// GORM创建时会用字段默认值替换零值（如 version 的 1.0.0），这里写回文件中的原值，避免与配置文件不一致
+func createImportedRecord(db *gorm.DB, record interface{}) error {
+	cfg, isBase := record.(*models.BaseConfig)
+	emptyVersion := isBase && cfg.Version == ""
+	if err := db.Omit("Client").Create(record).Error; err != nil {
+		return err
+	}
+	if emptyVersion {
+		return db.Model(cfg).Update("version", "").Error
+	}
+	return nil
+}
+
+// applyConfigBlock 将配置文件中的host配置写入模型字段（Format*Config 的逆操作）
+func applyConfigBlock(record interface{}, clientID int, block map[string]interface{}) {
+	switch cfg := record.(type) {
+	case *models.BaseConfig:
+		cfg.ClientID = clientID
+		cfg.AppName = blockString(block, "app_name")
+		cfg.Platform = blockString(block, "platform")
+		cfg.AppCode = blockString(block, "app_code")
+		cfg.Product = blockString(block, "product")
+		cfg.Customer = blockString(block, "customer")
+		cfg.AppID = blockString(block, "appid")
+		cfg.Version = blockString(block, "version")
+		cfg.CL = blockString(block, "cl")
+		cfg.UC = blockString(block, "uc")
+
+	case *models.CommonConfig:
+		cfg.ClientID = clientID
+		cfg.DeliverBusinessID = blockString(block, "deliver", "business_id", "value")
+		cfg.DeliverBusinessIDEnable = blockBool(block, "deliver", "business_id", "enable")
+		cfg.DeliverSwitchID = blockString(block, "deliver", "switch_id", "value")
+		cfg.DeliverSwitchIDEnable = blockBool(block, "deliver", "switch_id", "enable")
+		cfg.ProtocolCompany = blockString(block, "protocol", "company")
+		cfg.ProtocolAbout = blockString(block, "protocol", "about")
+		cfg.ProtocolPrivacy = blockString(block, "protocol", "privacy")
+		cfg.ProtocolVod = blockString(block, "protocol", "vod")
+		cfg.ProtocolUserCancel = blockString(block, "protocol", "userCancel")
+		cfg.ContactURL = blockString(block, "contact")
+		cfg.ScriptBase = blockString(block, "script", "base")
+
+	case *models.PayConfig:
+		cfg.ClientID = clientID
+		cfg.NormalPayEnable = blockBool(block, "normal_pay", "enable")
+		cfg.NormalPayGatewayAndroid = blockIntPtr(block, "normal_pay", "gateway_id", "android")
+		cfg.NormalPayGatewayIOS = blockIntPtr(block, "normal_pay", "gateway_id", "ios")
+		cfg.RenewPayEnable = blockBool(block, "renew_pay", "enable")
+		cfg.RenewPayGatewayAndroid = blockIntPtr(block, "renew_pay", "gateway_id", "android")
+		cfg.RenewPayGatewayIOS = blockIntPtr(block, "renew_pay", "gateway_id", "ios")
+
+	case *models.UIConfig:
+		cfg.ClientID = clientID
+		cfg.ThemeBgMain = blockString(block, "bgStyle", "main")
+		cfg.ThemeBgSecond = blockString(block, "bgStyle", "second")
+		cfg.ThemeTextMain = nil
+		if textMain := blockString(block, "textColor", "main"); textMain != "" {
+			cfg.ThemeTextMain = &textMain
+		}
+
+	case *models.NovelConfig:
+		cfg.ClientID = clientID
+		cfg.TTJumpHomeUrl = blockString(block, "tt_jump_home_url")
+		cfg.TTLoginCallbackDomain = blockString(block, "tt_login_callback_domain")
+	}
+}
+
+// blockValue 按路径获取host配置中的值
+func blockValue(block map[string]interface{}, path ...string) interface{} {
+	var value interface{} = block
+	for _, key := range path {
+		current, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = current[key]
+	}
+	return value
+}
+
+// blockString 按路径获取字符串值，非字符串值转换为字符串
+func blockString(block map[string]interface{}, path ...string) string {
+	switch value := blockValue(block, path...).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// blockBool 按路径获取布尔值
+func blockBool(block map[string]interface{}, path ...string) bool {
+	value, _ := blockValue(block, path...).(bool)
+	return value
+}
+
+// blockIntPtr 按路径获取整数值，不存在或为null时返回 nil
+func blockIntPtr(block map[string]interface{}, path ...string) *int {
+	value, ok := blockValue(block, path...).(float64)
+	if !ok {
+		return nil
+	}
+	result := int(value)
+	return &result
+}
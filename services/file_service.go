@@ -21,8 +21,13 @@ type FileService struct {
 
 // NewFileService 创建文件操作服务实例
 func NewFileService() *FileService {
+	return newFileServiceWithConfig(config.Load())
+}
+
+// newFileServiceWithConfig 使用指定配置创建文件操作服务实例
+func newFileServiceWithConfig(cfg *config.Config) *FileService {
 	return &FileService{
-		config:    config.Load(),
+		config:    cfg,
 		fileUtils: utils.NewFileUtils(), // 初始化fileUtils
 		jsonUtils: utils.NewJSONUtils(), // 初始化jsonUtils
 	}
@@ -49,7 +54,7 @@ func (s *FileService) CreatePrebuildFiles(brandCode string, appName string, host
 
 	// 检查品牌目录是否存在
 	if _, err := os.Stat(brandDir); err == nil {
-		// 目录已存在，备份它以便回滚时删除
+		// 目录已存在，快照备份以便回滚时恢复
 		if err := fileManager.Backup(brandDir, ""); err != nil {
 			return fmt.Errorf("failed to backup existing brand directory: %v", err)
 		}
@@ -174,11 +179,25 @@ func (s *FileService) CreatePrebuildFiles(brandCode string, appName string, host
 	return nil
 }
 
+// defaultStaticTemplate 新建网站时复制的默认静态图片目录（img-jinse）
+const defaultStaticTemplate = "jinse"
+
 // CreateStaticImageDirectory 创建static图片目录
 func (s *FileService) CreateStaticImageDirectory(brandCode string, fileManager *rollback.FileRollback) error {
-	sourceDir := filepath.Join(s.config.File.StaticDir, "img-jinse")
+	return s.CreateStaticImageDirectoryFrom(defaultStaticTemplate, brandCode, fileManager)
+}
+
+// CreateStaticImageDirectoryFrom 以指定品牌的static图片目录为模板创建新品牌的图片目录
+func (s *FileService) CreateStaticImageDirectoryFrom(sourceBrandCode, brandCode string, fileManager *rollback.FileRollback) error {
+	sourceDir := s.config.GetStaticPath(sourceBrandCode)
 	targetDir := s.config.GetStaticPath(brandCode)
 
+	// 同一品牌的不同端共用图片目录，无需复制
+	if sourceDir == targetDir {
+		log.Printf("⏭️ 源目录与目标目录相同，跳过复制: %s", targetDir)
+		return nil
+	}
+
 	// 检查源目录是否存在
 	if _, err := os.Stat(sourceDir); os.IsNotExist(err) {
 		return fmt.Errorf("source directory %s does not exist", sourceDir)
@@ -186,7 +205,7 @@ func (s *FileService) CreateStaticImageDirectory(brandCode string, fileManager *
 
 	// 检查目标目录是否已存在
 	if _, err := os.Stat(targetDir); err == nil {
-		// 目录已存在，备份它以便回滚时删除
+		// 目录已存在，快照备份以便回滚时恢复
 		if err := fileManager.Backup(targetDir, ""); err != nil {
 			return fmt.Errorf("failed to backup existing target directory: %v", err)
 		}
@@ -224,14 +243,13 @@ func (s *FileService) copyDirectoryWithRollback(src, dst string, fileManager *ro
 		dstPath := filepath.Join(dst, entry.Name())
 
 		if entry.IsDir() {
-			// 创建子目录
-			if err := os.MkdirAll(dstPath, 0755); err != nil {
-				return err
-			}
-			// 标记子目录为需要回滚删除
+			// 先标记子目录（不存在时回滚删除，已存在时快照），再创建
 			if err := fileManager.Backup(dstPath, ""); err != nil {
 				return fmt.Errorf("failed to backup subdirectory: %v", err)
 			}
+			if err := os.MkdirAll(dstPath, 0755); err != nil {
+				return err
+			}
 			// 递归拷贝子目录
 			if err := s.copyDirectoryWithRollback(srcPath, dstPath, fileManager); err != nil {
 				return err
@@ -251,53 +269,64 @@ func (s *FileService) copyDirectoryWithRollback(src, dst string, fileManager *ro
 	return nil
 }
 
-// updateViteConfigFile 更新vite.config.js文件
+// updateViteConfigFile 新增或更新vite.config.js中basePathMap的记录
 func (s *FileService) updateViteConfigFile(brandCode, host string, scriptBase string, fileManager *rollback.FileRollback) error {
-	// 备份文件
-	if err := fileManager.Backup(s.config.File.ViteConfigFile, ""); err != nil {
-		return fmt.Errorf("failed to backup vite.config.js: %v", err)
-	}
-
 	content, err := os.ReadFile(s.config.File.ViteConfigFile)
 	if err != nil {
 		return fmt.Errorf("failed to read vite.config.js: %v", err)
 	}
 
-	contentStr := string(content)
+	editor, err := utils.NewBasePathMapEditor(string(content))
+	if err != nil {
+		return err
+	}
 
-	// 检查是否已经存在该配置
-	scriptKey := fmt.Sprintf("'%s-%s'", host, brandCode)
-	if strings.Contains(contentStr, scriptKey) {
+	// 检查是否已经存在相同的配置
+	scriptKey := fmt.Sprintf("%s-%s", host, brandCode)
+	existing, err := editor.Get(scriptKey)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.IsString && existing.Value == scriptBase {
 		return nil
 	}
 
-	// 找到basePathMap对象的位置
-	mapStartIndex := strings.Index(contentStr, "const basePathMap = {")
-	if mapStartIndex == -1 {
-		return fmt.Errorf("cannot find basePathMap in vite.config.js")
+	// 备份文件
+	if err := fileManager.Backup(s.config.File.ViteConfigFile, ""); err != nil {
+		return fmt.Errorf("failed to backup vite.config.js: %v", err)
 	}
 
-	// 找到basePathMap对象的结束位置
-	mapEndIndex := strings.Index(contentStr[mapStartIndex:], "}")
-	if mapEndIndex == -1 {
-		return fmt.Errorf("cannot find basePathMap end in vite.config.js")
+	if _, err := editor.Set(scriptKey, scriptBase); err != nil {
+		return fmt.Errorf("failed to update basePathMap: %v", err)
 	}
-	mapEndIndex += mapStartIndex
-
-	// 插入新的配置（确保换行）
-	newConfigEntry := fmt.Sprintf(`  '%s-%s': '%s',`, host, brandCode, scriptBase)
-
-	// 在basePathMap对象内部插入新条目，确保在反括号前换行
-	newContent := contentStr[:mapEndIndex] + newConfigEntry + "\n" + contentStr[mapEndIndex:]
 
 	// 写回文件
-	if err := os.WriteFile(s.config.File.ViteConfigFile, []byte(newContent), 0644); err != nil {
+	if err := os.WriteFile(s.config.File.ViteConfigFile, []byte(editor.Content()), 0644); err != nil {
 		return fmt.Errorf("failed to write vite.config.js: %v", err)
 	}
 
+	if existing != nil {
+		log.Printf("✅ 更新vite.config.js配置: %s: %s -> %s", scriptKey, existing.Value, scriptBase)
+	} else {
+		log.Printf("✅ 新增vite.config.js配置: %s: %s", scriptKey, scriptBase)
+	}
 	return nil
 }
 
+// GetBasePathMap 获取vite.config.js中basePathMap的所有记录
+func (s *FileService) GetBasePathMap() ([]utils.BasePathEntry, error) {
+	content, err := os.ReadFile(s.config.File.ViteConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vite.config.js: %v", err)
+	}
+
+	editor, err := utils.NewBasePathMapEditor(string(content))
+	if err != nil {
+		return nil, err
+	}
+	return editor.List()
+}
+
 // updatePackageJSONFile 更新package.json文件
 func (s *FileService) updatePackageJSONFile(brandCode, host string, appName string, fileManager *rollback.FileRollback) error {
 	// 备份文件
@@ -310,89 +339,47 @@ func (s *FileService) updatePackageJSONFile(brandCode, host string, appName stri
 		return fmt.Errorf("failed to read package.json: %v", err)
 	}
 
-	contentStr := string(content)
+	editor, err := utils.NewJSONEditor(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse package.json: %v", err)
+	}
 
 	// 生成平台标识
 	platformKey := fmt.Sprintf("%s-%s", host, brandCode)
 
 	// 检查是否已经存在该配置
-	if strings.Contains(contentStr, fmt.Sprintf(`"dev:%s"`, platformKey)) {
+	if editor.Has("scripts", "dev:"+platformKey) {
 		fmt.Printf("⚠️  Platform %s already exists in package.json, skipping...\n", platformKey)
 		return nil
 	}
 
-	// 1. 添加scripts - 直接在 "scripts": { 后一行添加
-	scriptsStartIndex := strings.Index(contentStr, `"scripts": {`)
-	if scriptsStartIndex == -1 {
-		return fmt.Errorf("cannot find scripts block in package.json")
+	// 1. 添加scripts
+	if err := editor.Set([]string{"scripts", "dev:" + platformKey},
+		fmt.Sprintf("uni -p %s --minify", platformKey)); err != nil {
+		return fmt.Errorf("failed to add dev script to package.json: %v", err)
 	}
-
-	// 找到 "scripts": { 行的结束位置
-	scriptsLineEndIndex := strings.Index(contentStr[scriptsStartIndex:], "\n")
-	if scriptsLineEndIndex == -1 {
-		return fmt.Errorf("cannot find end of scripts line")
+	if err := editor.Set([]string{"scripts", "build:" + platformKey},
+		fmt.Sprintf("cross-env UNI_UTS_PLATFORM=%s npm run prebuild && uni build -p %s --minify", platformKey, platformKey)); err != nil {
+		return fmt.Errorf("failed to add build script to package.json: %v", err)
 	}
-	insertPosition := scriptsStartIndex + scriptsLineEndIndex + 1
 
-	// 添加新脚本（带逗号）
-	newScripts := fmt.Sprintf(`    "dev:%s": "uni -p %s --minify",
-    "build:%s": "cross-env UNI_UTS_PLATFORM=%s npm run prebuild && uni build -p %s --minify",
-`, platformKey, platformKey, platformKey, platformKey, platformKey)
-
-	// 插入新脚本
-	contentStr = contentStr[:insertPosition] + newScripts + contentStr[insertPosition:]
-
-	// 2. 添加uni-app.scripts - 直接在 "scripts": { 后一行添加
-	uniAppScriptsStartIndex := strings.Index(contentStr, `"uni-app": {`)
-	if uniAppScriptsStartIndex == -1 {
-		return fmt.Errorf("cannot find uni-app block in package.json")
+	// 2. 添加uni-app.scripts，根据端类型注册表设置对应的平台宏
+	define := utils.JSONObject{{Key: "MP-" + strings.ToUpper(brandCode), Value: true}}
+	for _, macro := range utils.GetPlatformRegistry().DefineMacros(host) {
+		define = append(define, utils.JSONField{Key: macro, Value: true})
 	}
 
-	// 在uni-app块中查找 "scripts": {
-	uniAppScriptsStart := strings.Index(contentStr[uniAppScriptsStartIndex:], `"scripts": {`)
-	if uniAppScriptsStart == -1 {
-		return fmt.Errorf("cannot find uni-app.scripts block in package.json")
+	uniAppScript := utils.JSONObject{
+		{Key: "env", Value: utils.JSONObject{{Key: "UNI_PLATFORM", Value: s.fileUtils.GetUniPlatform(host)}}},
+		{Key: "define", Value: define},
+		{Key: "title", Value: "h5" + appName},
 	}
-
-	uniAppScriptsStart += uniAppScriptsStartIndex
-	uniAppScriptsLineEndIndex := strings.Index(contentStr[uniAppScriptsStart:], "\n")
-	if uniAppScriptsLineEndIndex == -1 {
-		return fmt.Errorf("cannot find end of uni-app.scripts line")
+	if err := editor.Set([]string{"uni-app", "scripts", platformKey}, uniAppScript); err != nil {
+		return fmt.Errorf("failed to add uni-app script to package.json: %v", err)
 	}
-	uniAppInsertPosition := uniAppScriptsStart + uniAppScriptsLineEndIndex + 1
-
-	// 添加新uni-app脚本（带逗号，正确的缩进）
-	newUniAppScript := fmt.Sprintf(`      "%s": {
-        "env": {
-          "UNI_PLATFORM": "%s"
-        },
-        "define": {
-          "MP-%s": true`, platformKey, s.fileUtils.GetUniPlatform(host), strings.ToUpper(brandCode))
-
-	// 根据host类型设置对应的平台宏
-	switch host {
-	case "tth5":
-		newUniAppScript += `,
-          "MP-TTH5": true`
-	case "ksh5":
-		newUniAppScript += `,
-          "MP-KSH5": true`
-	case "h5":
-		newUniAppScript += `,
-          "MP-H5": true`
-	}
-
-	newUniAppScript += fmt.Sprintf(`
-        },
-        "title": "h5%s"
-      },
-`, appName)
-
-	// 插入新uni-app脚本
-	contentStr = contentStr[:uniAppInsertPosition] + newUniAppScript + contentStr[uniAppInsertPosition:]
 
 	// 写回文件
-	if err := os.WriteFile(s.config.File.PackageFile, []byte(contentStr), 0644); err != nil {
+	if err := os.WriteFile(s.config.File.PackageFile, []byte(editor.String()), 0644); err != nil {
 		return fmt.Errorf("failed to write package.json: %v", err)
 	}
 
@@ -430,13 +417,20 @@ func (s *FileService) removeViteConfigEntry(brandCode, host string, fileManager
 		return fmt.Errorf("failed to read vite.config.js: %v", err)
 	}
 
-	contentStr := string(content)
+	editor, err := utils.NewBasePathMapEditor(string(content))
+	if err != nil {
+		return err
+	}
 
 	// 生成要删除的配置键
-	scriptKey := fmt.Sprintf("'%s-%s'", host, brandCode)
+	scriptKey := fmt.Sprintf("%s-%s", host, brandCode)
 
 	// 检查是否存在该配置
-	if !strings.Contains(contentStr, scriptKey) {
+	existing, err := editor.Get(scriptKey)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
 		log.Printf("⚠️ vite.config.js中不存在配置: %s", scriptKey)
 		return nil
 	}
@@ -446,23 +440,12 @@ func (s *FileService) removeViteConfigEntry(brandCode, host string, fileManager
 		return fmt.Errorf("failed to backup vite.config.js: %v", err)
 	}
 
-	// 找到并删除配置行
-	lines := strings.Split(contentStr, "\n")
-	var newLines []string
-
-	for _, line := range lines {
-		if strings.Contains(line, scriptKey) {
-			// 删除包含配置键的行
-			log.Printf("🗑️ 删除vite.config.js配置行: %s", strings.TrimSpace(line))
-			continue
-		}
-
-		newLines = append(newLines, line)
+	if _, err := editor.Remove(scriptKey); err != nil {
+		return fmt.Errorf("failed to remove basePathMap entry: %v", err)
 	}
 
 	// 写回文件
-	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(s.config.File.ViteConfigFile, []byte(newContent), 0644); err != nil {
+	if err := os.WriteFile(s.config.File.ViteConfigFile, []byte(editor.Content()), 0644); err != nil {
 		return fmt.Errorf("failed to write vite.config.js: %v", err)
 	}
 
@@ -477,13 +460,28 @@ func (s *FileService) removePackageJSONEntries(brandCode, host string, fileManag
 		return fmt.Errorf("failed to read package.json: %v", err)
 	}
 
-	contentStr := string(content)
+	editor, err := utils.NewJSONEditor(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse package.json: %v", err)
+	}
 
 	// 生成平台标识
 	platformKey := fmt.Sprintf("%s-%s", host, brandCode)
+	entries := [][]string{
+		{"scripts", "dev:" + platformKey},
+		{"scripts", "build:" + platformKey},
+		{"uni-app", "scripts", platformKey},
+	}
 
-	// 检查是否存在该配置
-	if !strings.Contains(contentStr, platformKey) {
+	// 检查是否存在该配置（按完整键匹配，避免品牌代码互为子串时误删）
+	exists := false
+	for _, entry := range entries {
+		if editor.Has(entry...) {
+			exists = true
+			break
+		}
+	}
+	if !exists {
 		log.Printf("⚠️ package.json中不存在配置: %s", platformKey)
 		return nil
 	}
@@ -493,61 +491,18 @@ func (s *FileService) removePackageJSONEntries(brandCode, host string, fileManag
 		return fmt.Errorf("failed to backup package.json: %v", err)
 	}
 
-	// 按行删除包含该平台标识的所有行
-	lines := strings.Split(contentStr, "\n")
-	var newLines []string
-	skipMode := false
-	braceCount := 0
-	inConfigBlock := false
-
-	for _, line := range lines {
-
-		// 检查是否进入删除模式 - 找到配置块开始
-		if strings.Contains(line, fmt.Sprintf(`"%s": {`, platformKey)) {
-			skipMode = true
-			braceCount = 1
-			inConfigBlock = true
-			log.Printf("🗑️ 开始删除配置块: %s", platformKey)
-			continue
-		}
-
-		// 如果在删除模式中
-		if skipMode {
-			// 计算大括号数量
-			for _, char := range line {
-				if char == '{' {
-					braceCount++
-				} else if char == '}' {
-					braceCount--
-				}
-			}
-
-			// 如果大括号数量归零，说明配置块结束
-			if braceCount == 0 {
-				log.Printf("🗑️ 配置块结束，完成删除: %s", platformKey)
-				skipMode = false
-				inConfigBlock = false
-				continue
-			}
-
-			// 跳过当前行（继续删除）
-			continue
+	for _, entry := range entries {
+		deleted, err := editor.Delete(entry...)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s from package.json: %v", strings.Join(entry, "."), err)
 		}
-
-		// 检查是否包含该平台标识的其他行（如scripts中的引用）
-		// 专门处理scripts中的dev:和build:行
-		if !inConfigBlock && (strings.Contains(line, fmt.Sprintf(`"dev:%s"`, platformKey)) ||
-			strings.Contains(line, fmt.Sprintf(`"build:%s"`, platformKey))) {
-			log.Printf("🗑️ 删除package.json scripts行: %s", strings.TrimSpace(line))
-			continue
+		if deleted {
+			log.Printf("🗑️ 删除package.json配置: %s", strings.Join(entry, "."))
 		}
-
-		newLines = append(newLines, line)
 	}
 
 	// 写回文件
-	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(s.config.File.PackageFile, []byte(newContent), 0644); err != nil {
+	if err := os.WriteFile(s.config.File.PackageFile, []byte(editor.String()), 0644); err != nil {
 		return fmt.Errorf("failed to write package.json: %v", err)
 	}
 
@@ -55,6 +55,14 @@ func (s *GitService) ExecuteGitCommit(req *types.GitCommitRequest) *types.GitOpe
 		return result
 	}
 
+	// git操作会改写整个工作区，需要独占锁
+	release, lockErr := acquireWorkspaceLock(s.config, "ExecuteGitCommit", utils.LockKeyWorkspace)
+	if lockErr != nil {
+		result.Error = fmt.Sprintf("工作区正忙: %v", lockErr)
+		return result
+	}
+	defer release()
+
 	// 检查工作区状态，决定是否需要stash
 	cmd := exec.Command("git", "status", "--porcelain")
 	cmd.Dir = req.BasePath
@@ -186,6 +194,14 @@ func (s *GitService) ResetToRemote(basePath, remoteName, branchName string) *typ
 		return result
 	}
 
+	// git操作会改写整个工作区，需要独占锁
+	release, lockErr := acquireWorkspaceLock(s.config, "ResetToRemote", utils.LockKeyWorkspace)
+	if lockErr != nil {
+		result.Error = fmt.Sprintf("工作区正忙: %v", lockErr)
+		return result
+	}
+	defer release()
+
 	// 先执行git fetch更新远程分支引用
 	fetchDetail := utils.ExecuteGitCommand(basePath, "git_fetch", "git", []string{"fetch", remoteName}, "更新远程分支引用")
 
@@ -248,6 +264,14 @@ func (s *GitService) PullCode(basePath, remoteName, branchName string) *types.Gi
 		return result
 	}
 
+	// git操作会改写整个工作区，需要独占锁
+	release, lockErr := acquireWorkspaceLock(s.config, "PullCode", utils.LockKeyWorkspace)
+	if lockErr != nil {
+		result.Error = fmt.Sprintf("工作区正忙: %v", lockErr)
+		return result
+	}
+	defer release()
+
 	// 检查工作区状态，如果是dirty则不允许拉取
 	gitStatus, err := utils.GetGitStatus(basePath)
 	if err != nil {
@@ -325,6 +349,14 @@ func (s *GitService) PullBranch(repositoryURL, branchName, defaultBranch string)
 		return result, err
 	}
 
+	// 分支仓库目录同一时间只允许一个操作
+	release, lockErr := acquireWorkspaceLock(s.config, "PullBranch("+branchName+")", utils.LockKeyRepoBranches)
+	if lockErr != nil {
+		result.Message = "仓库目录正忙"
+		return result, lockErr
+	}
+	defer release()
+
 	// 记录配置验证成功
 	result.Details = append(result.Details, types.GitOperationDetail{
 		Operation: "config_validation",
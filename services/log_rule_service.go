@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"brand-config-api/config"
+	"brand-config-api/utils"
+)
+
+// logRuleFile 规则配置文件的格式
+type logRuleFile struct {
+	Rules []utils.LogRule `json:"rules"`
+}
+
+// LogRuleTestLine 规则测试中单行输出的分类结果
+type LogRuleTestLine struct {
+	Line string `json:"line"`
+	utils.LogClassification
+}
+
+// logRuleMutex 串行化规则文件的写入
+var logRuleMutex sync.Mutex
+
+// LogRuleService 构建和部署输出分类规则服务，规则保存在 BUILD_LOG_RULES_FILE
+type LogRuleService struct {
+	config *config.Config
+}
+
+// NewLogRuleService 创建输出分类规则服务实例
+func NewLogRuleService() *LogRuleService {
+	return &LogRuleService{
+		config: config.Load(),
+	}
+}
+
+// LoadRules 从配置文件加载规则，文件不存在时使用内置规则
+func (s *LogRuleService) LoadRules() error {
+	path := s.config.Build.LogRulesFile
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Printf("📋 输出分类规则文件不存在，使用内置规则: %s", path)
+		return utils.GetLogClassifier().Replace(utils.DefaultLogRules())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read log rules: %v", err)
+	}
+
+	var file logRuleFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to parse log rules %s: %v", path, err)
+	}
+	if err := utils.GetLogClassifier().Replace(file.Rules); err != nil {
+		return fmt.Errorf("invalid log rules %s: %v", path, err)
+	}
+	log.Printf("✅ 输出分类规则已加载: %d 条规则", len(file.Rules))
+	return nil
+}
+
+// GetRules 获取当前生效的规则
+func (s *LogRuleService) GetRules() []utils.LogRule {
+	return utils.GetLogClassifier().Rules()
+}
+
+// UpdateRules 校验并保存规则，保存成功后立即生效
+func (s *LogRuleService) UpdateRules(rules []utils.LogRule) error {
+	if _, err := utils.CompileLogRules(rules); err != nil {
+		return err
+	}
+
+	logRuleMutex.Lock()
+	defer logRuleMutex.Unlock()
+	if err := s.saveRules(rules); err != nil {
+		return err
+	}
+	if err := utils.GetLogClassifier().Replace(rules); err != nil {
+		return err
+	}
+	log.Printf("✅ 输出分类规则已更新: %d 条规则", len(rules))
+	return nil
+}
+
+// ResetRules 删除规则文件，恢复内置规则
+func (s *LogRuleService) ResetRules() error {
+	logRuleMutex.Lock()
+	defer logRuleMutex.Unlock()
+	if err := os.Remove(s.config.Build.LogRulesFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove log rules: %v", err)
+	}
+	log.Printf("🔄 输出分类规则已恢复为内置规则")
+	return utils.GetLogClassifier().Replace(utils.DefaultLogRules())
+}
+
+// TestRules 用指定规则（为空时用当前规则）对一段输出逐行分类，不保存规则
+func (s *LogRuleService) TestRules(rules []utils.LogRule, text string, isStderr bool) ([]LogRuleTestLine, *utils.LogSummary, error) {
+	if len(rules) == 0 {
+		rules = s.GetRules()
+	}
+	compiled, err := utils.CompileLogRules(rules)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := []LogRuleTestLine{}
+	summary := utils.NewLogSummary()
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		classification := utils.ClassifyWith(compiled, line, isStderr)
+		summary.Add(line, classification)
+		lines = append(lines, LogRuleTestLine{Line: line, LogClassification: classification})
+	}
+	return lines, summary, nil
+}
+
+// saveRules 写入规则文件（先写临时文件再替换）
+func (s *LogRuleService) saveRules(rules []utils.LogRule) error {
+	path := s.config.Build.LogRulesFile
+	content, err := json.MarshalIndent(logRuleFile{Rules: rules}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode log rules: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create log rules directory: %v", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write log rules: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save log rules: %v", err)
+	}
+	return nil
+}
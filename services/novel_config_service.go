@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"brand-config-api/config"
 	"brand-config-api/database"
@@ -117,107 +118,153 @@ func (s *NovelConfigService) generateConfigFile(ctx *rollback.TransactionContext
 	return nil
 }
 
-// UpdateNovelConfigByClientID 根据client_id更新小说配置
-func (s *NovelConfigService) UpdateNovelConfigByClientID(clientID int, novelConfig models.NovelConfig) error {
+// UpdateNovelConfigByClientID 根据client_id更新小说配置，并记录修订历史
+func (s *NovelConfigService) UpdateNovelConfigByClientID(clientID int, novelConfig models.NovelConfig, operator string) error {
+	// 锁定品牌配置文件和项目共享文件（novelConfig.js），避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "UpdateNovelConfigByClientID", true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
 	return rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
-		// 获取客户端信息
-		var client models.Client
-		if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
-			return fmt.Errorf("failed to find client: %v", err)
+		revisionService := NewConfigRevisionService()
+		if err := revisionService.EnsureBaseline(ctx, clientID, models.ConfigSectionNovel); err != nil {
+			return err
 		}
 
-		// 获取品牌信息
-		var brand models.Brand
-		if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
-			return fmt.Errorf("failed to find brand: %v", err)
+		if err := s.updateNovelConfigInternal(ctx, clientID, novelConfig, false); err != nil {
+			return err
 		}
 
-		// 添加调试信息
-		log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
-			clientID, client.BrandID, brand.Code, client.Host)
+		return revisionService.RecordRevision(ctx, clientID, models.ConfigSectionNovel, models.RevisionActionUpdate, operator, "")
+	}, nil)
+}
+
+// novelConfigColumns 小说配置的可更新字段
+var novelConfigColumns = []string{"tt_jump_home_url", "tt_login_callback_domain", "updated_at"}
+
+// updateNovelConfigInternal 内部更新小说配置方法（不管理事务）
+// overwrite 为 true 时按传入的配置写入所有字段（包括空值，恢复历史修订时使用），否则只更新非空字段
+func (s *NovelConfigService) updateNovelConfigInternal(ctx *rollback.TransactionContext, clientID int, novelConfig models.NovelConfig, overwrite bool) error {
+	// 获取客户端信息
+	var client models.Client
+	if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
+		return fmt.Errorf("failed to find client: %v", err)
+	}
 
-		log.Printf("🔄 开始更新小说配置: brand=%s, host=%s", brand.Code, client.Host)
+	// 获取品牌信息
+	var brand models.Brand
+	if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
+		return fmt.Errorf("failed to find brand: %v", err)
+	}
 
-		// 检查是否已存在小说配置记录
-		var existingNovelConfig models.NovelConfig
-		err := ctx.DB.Where("client_id = ?", clientID).First(&existingNovelConfig).Error
+	// 添加调试信息
+	log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
+		clientID, client.BrandID, brand.Code, client.Host)
 
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 记录不存在，创建新记录
-				log.Printf("📝 小说配置记录不存在，创建新记录")
-				novelConfig.ClientID = clientID
-				if err := ctx.DB.Create(&novelConfig).Error; err != nil {
-					return fmt.Errorf("failed to create novel config in database: %v", err)
-				}
-				log.Printf("✅ 数据库记录创建成功")
-			} else {
-				return fmt.Errorf("failed to check existing novel config: %v", err)
+	log.Printf("🔄 开始更新小说配置: brand=%s, host=%s", brand.Code, client.Host)
+
+	// 检查是否已存在小说配置记录
+	var existingNovelConfig models.NovelConfig
+	err := ctx.DB.Where("client_id = ?", clientID).First(&existingNovelConfig).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// 记录不存在，创建新记录
+			log.Printf("📝 小说配置记录不存在，创建新记录")
+			novelConfig.ClientID = clientID
+			if err := ctx.DB.Create(&novelConfig).Error; err != nil {
+				return fmt.Errorf("failed to create novel config in database: %v", err)
 			}
+			log.Printf("✅ 数据库记录创建成功")
 		} else {
-			// 记录存在，更新记录
-			log.Printf("📝 小说配置记录已存在，更新记录")
-			if err := ctx.DB.Model(&existingNovelConfig).Updates(novelConfig).Error; err != nil {
-				return fmt.Errorf("failed to update novel config in database: %v", err)
-			}
-			log.Printf("✅ 数据库记录更新成功")
+			return fmt.Errorf("failed to check existing novel config: %v", err)
 		}
-
-		// 更新本地配置文件
-		// 构建文件路径
-		configFile := filepath.Join(s.config.File.LocalConfigsDir, "novelConfig.js")
-		log.Printf("📁 准备更新文件: %s", configFile)
-
-		// 备份文件
-		log.Printf("📋 开始备份文件...")
-		if err := ctx.Files.Backup(configFile, ""); err != nil {
-			return fmt.Errorf("failed to backup file: %v", err)
+	} else {
+		// 记录存在，更新记录
+		log.Printf("📝 小说配置记录已存在，更新记录")
+		novelConfig.UpdatedAt = time.Now()
+
+		// 恢复历史修订时使用 Select 写入所有字段（包括空值），普通更新不覆盖未传入的字段
+		db := ctx.DB.Model(&existingNovelConfig)
+		if overwrite {
+			db = db.Select(novelConfigColumns)
 		}
-		log.Printf("✅ 文件备份成功")
+		if err := db.Updates(&novelConfig).Error; err != nil {
+			return fmt.Errorf("failed to update novel config in database: %v", err)
+		}
+		log.Printf("✅ 数据库记录更新成功")
 
-		// 读取现有配置文件
-		log.Printf("📖 开始读取现有配置文件...")
-		configfileManager := utils.NewConfigFileManager()
-		configData, err := configfileManager.ReadConfigFile(configFile)
-		if err != nil {
-			return fmt.Errorf("failed to read existing config file: %v", err)
+		// 配置文件按更新后的完整记录生成
+		var updatedNovelConfig models.NovelConfig
+		if err := ctx.DB.First(&updatedNovelConfig, existingNovelConfig.ID).Error; err != nil {
+			return fmt.Errorf("failed to reload novel config: %v", err)
 		}
-		log.Printf("✅ 配置文件读取成功，当前内容: %+v", configData)
+		novelConfig = updatedNovelConfig
+	}
 
-		// 更新指定host的配置
-		// 注意：文件结构是 {brandCode: {host: config}}
-		hostConfig := s.FormatNovelConfig(novelConfig)
-		log.Printf("📝 准备更新的配置: %+v", hostConfig)
+	// 更新本地配置文件
+	// 构建文件路径
+	configFile := filepath.Join(s.config.File.LocalConfigsDir, "novelConfig.js")
+	log.Printf("📁 准备更新文件: %s", configFile)
 
-		// 确保品牌配置存在
-		if configData[brand.Code] == nil {
-			log.Printf("🆕 品牌 %s 配置不存在，创建新的品牌配置", brand.Code)
-			configData[brand.Code] = make(map[string]interface{})
-		}
+	// 备份文件
+	log.Printf("📋 开始备份文件...")
+	if err := ctx.Files.Backup(configFile, ""); err != nil {
+		return fmt.Errorf("failed to backup file: %v", err)
+	}
+	log.Printf("✅ 文件备份成功")
 
-		// 更新指定host的配置
-		configData[brand.Code].(map[string]interface{})[client.Host] = hostConfig
-		log.Printf("✅ 配置数据更新完成，更新后的结构: %+v", configData)
+	// 读取现有配置文件
+	log.Printf("📖 开始读取现有配置文件...")
+	configfileManager := utils.NewConfigFileManager()
+	configData, err := configfileManager.ReadConfigFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to read existing config file: %v", err)
+	}
+	log.Printf("✅ 配置文件读取成功，当前内容: %+v", configData)
 
-		// 写入文件
-		log.Printf("💾 开始写入配置文件...")
-		if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
-			return fmt.Errorf("failed to write config file: %v", err)
-		}
-		log.Printf("✅ 配置文件写入成功")
+	// 更新指定host的配置
+	// 注意：文件结构是 {brandCode: {host: config}}
+	hostConfig := s.FormatNovelConfig(novelConfig)
+	log.Printf("📝 准备更新的配置: %+v", hostConfig)
 
-		log.Printf("✅ 小说配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
-		log.Printf("📁 文件路径: %s", configFile)
-		log.Printf("📝 更新的配置: %+v", hostConfig)
-		return nil
-	}, nil)
+	// 确保品牌配置存在
+	if configData[brand.Code] == nil {
+		log.Printf("🆕 品牌 %s 配置不存在，创建新的品牌配置", brand.Code)
+		configData[brand.Code] = make(map[string]interface{})
+	}
+
+	// 更新指定host的配置
+	configData[brand.Code].(map[string]interface{})[client.Host] = hostConfig
+	log.Printf("✅ 配置数据更新完成，更新后的结构: %+v", configData)
+
+	// 写入文件
+	log.Printf("💾 开始写入配置文件...")
+	if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+	log.Printf("✅ 配置文件写入成功")
+
+	log.Printf("✅ 小说配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
+	log.Printf("📁 文件路径: %s", configFile)
+	log.Printf("📝 更新的配置: %+v", hostConfig)
+	return nil
 }
 
 // DeleteNovelConfigByClientID 根据client_id删除小说配置（独立事务）
 func (s *NovelConfigService) DeleteNovelConfigByClientID(clientID int) error {
+	// 锁定品牌配置文件和项目共享文件（novelConfig.js），避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "DeleteNovelConfigByClientID", true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
@@ -90,69 +90,63 @@ func (s *PayConfigService) generateConfigFile(ctx *rollback.TransactionContext,
 	return nil
 }
 
-// UpdatePayConfigByClientID 根据client_id更新支付配置
-func (s *PayConfigService) UpdatePayConfigByClientID(clientID int, payConfig models.PayConfig) error {
+// UpdatePayConfigByClientID 根据client_id更新支付配置，并记录修订历史
+func (s *PayConfigService) UpdatePayConfigByClientID(clientID int, payConfig models.PayConfig, operator string) error {
+	// 锁定品牌配置文件，避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "UpdatePayConfigByClientID", false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
 	return rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
-		// 获取客户端信息
-		var client models.Client
-		if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
-			return fmt.Errorf("failed to find client: %v", err)
+		revisionService := NewConfigRevisionService()
+		if err := revisionService.EnsureBaseline(ctx, clientID, models.ConfigSectionPay); err != nil {
+			return err
 		}
 
-		// 获取品牌信息
-		var brand models.Brand
-		if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
-			return fmt.Errorf("failed to find brand: %v", err)
+		if err := s.updatePayConfigInternal(ctx, clientID, payConfig); err != nil {
+			return err
 		}
 
-		// 添加调试信息
-		log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
-			clientID, client.BrandID, brand.Code, client.Host)
-
-		log.Printf("🔄 开始更新支付配置: brand=%s, host=%s", brand.Code, client.Host)
-
-		// 检查是否已存在支付配置记录
-		var existingPayConfig models.PayConfig
-		err := ctx.DB.Where("client_id = ?", clientID).First(&existingPayConfig).Error
-
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 记录不存在，创建新记录
-				log.Printf("📝 支付配置记录不存在，创建新记录")
-				payConfig.ClientID = clientID
-				// 使用 Select 明确指定要创建的字段，确保零值（如 false）被正确处理
-				if err := ctx.DB.Select(
-					"client_id",
-					"normal_pay_enable",
-					"normal_pay_gateway_android",
-					"normal_pay_gateway_ios",
-					"renew_pay_enable",
-					"renew_pay_gateway_android",
-					"renew_pay_gateway_ios",
-				).Create(&payConfig).Error; err != nil {
-					return fmt.Errorf("failed to create pay config in database: %v", err)
-				}
-				log.Printf("✅ 数据库记录创建成功")
-			} else {
-				return fmt.Errorf("failed to check existing pay config: %v", err)
-			}
-		} else {
-			// 记录存在，更新记录
-			log.Printf("📝 支付配置记录已存在，更新记录")
-			log.Printf("🔍 更新前的配置: %+v", existingPayConfig)
-			log.Printf("🔍 要更新的配置: %+v", payConfig)
+		return revisionService.RecordRevision(ctx, clientID, models.ConfigSectionPay, models.RevisionActionUpdate, operator, "")
+	}, nil)
+}
 
-			// 保留原有的 ID 和 created_at，设置其他字段
-			payConfig.ID = existingPayConfig.ID
-			payConfig.ClientID = clientID
-			payConfig.CreatedAt = existingPayConfig.CreatedAt
-			payConfig.UpdatedAt = time.Now()
+// updatePayConfigInternal 内部更新支付配置方法（不管理事务）
+func (s *PayConfigService) updatePayConfigInternal(ctx *rollback.TransactionContext, clientID int, payConfig models.PayConfig) error {
+	// 获取客户端信息
+	var client models.Client
+	if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
+		return fmt.Errorf("failed to find client: %v", err)
+	}
 
-			// 使用 Select 明确指定要更新的字段，这样可以更新零值（如 false）
-			if err := ctx.DB.Model(&existingPayConfig).Select(
+	// 获取品牌信息
+	var brand models.Brand
+	if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
+		return fmt.Errorf("failed to find brand: %v", err)
+	}
+
+	// 添加调试信息
+	log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
+		clientID, client.BrandID, brand.Code, client.Host)
+
+	log.Printf("🔄 开始更新支付配置: brand=%s, host=%s", brand.Code, client.Host)
+
+	// 检查是否已存在支付配置记录
+	var existingPayConfig models.PayConfig
+	err := ctx.DB.Where("client_id = ?", clientID).First(&existingPayConfig).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// 记录不存在，创建新记录
+			log.Printf("📝 支付配置记录不存在，创建新记录")
+			payConfig.ClientID = clientID
+			// 使用 Select 明确指定要创建的字段，确保零值（如 false）被正确处理
+			if err := ctx.DB.Select(
 				"client_id",
 				"normal_pay_enable",
 				"normal_pay_gateway_android",
@@ -160,45 +154,79 @@ func (s *PayConfigService) UpdatePayConfigByClientID(clientID int, payConfig mod
 				"renew_pay_enable",
 				"renew_pay_gateway_android",
 				"renew_pay_gateway_ios",
-				"updated_at",
-			).Updates(&payConfig).Error; err != nil {
-				return fmt.Errorf("failed to update pay config in database: %v", err)
+			).Create(&payConfig).Error; err != nil {
+				return fmt.Errorf("failed to create pay config in database: %v", err)
 			}
-			log.Printf("✅ 数据库记录更新成功")
+			log.Printf("✅ 数据库记录创建成功")
+		} else {
+			return fmt.Errorf("failed to check existing pay config: %v", err)
+		}
+	} else {
+		// 记录存在，更新记录
+		log.Printf("📝 支付配置记录已存在，更新记录")
+		log.Printf("🔍 更新前的配置: %+v", existingPayConfig)
+		log.Printf("🔍 要更新的配置: %+v", payConfig)
+
+		// 保留原有的 ID 和 created_at，设置其他字段
+		payConfig.ID = existingPayConfig.ID
+		payConfig.ClientID = clientID
+		payConfig.CreatedAt = existingPayConfig.CreatedAt
+		payConfig.UpdatedAt = time.Now()
+
+		// 使用 Select 明确指定要更新的字段，这样可以更新零值（如 false）
+		if err := ctx.DB.Model(&existingPayConfig).Select(
+			"client_id",
+			"normal_pay_enable",
+			"normal_pay_gateway_android",
+			"normal_pay_gateway_ios",
+			"renew_pay_enable",
+			"renew_pay_gateway_android",
+			"renew_pay_gateway_ios",
+			"updated_at",
+		).Updates(&payConfig).Error; err != nil {
+			return fmt.Errorf("failed to update pay config in database: %v", err)
 		}
+		log.Printf("✅ 数据库记录更新成功")
+	}
 
-		// 更新本地配置文件
-		// 构建文件路径
-		configFile := filepath.Join(s.config.File.PayConfigsDir, brand.Code+".js")
+	// 更新本地配置文件
+	// 构建文件路径
+	configFile := filepath.Join(s.config.File.PayConfigsDir, brand.Code+".js")
 
-		// 备份文件
-		if err := ctx.Files.Backup(configFile, ""); err != nil {
-			return fmt.Errorf("failed to backup file: %v", err)
-		}
+	// 备份文件
+	if err := ctx.Files.Backup(configFile, ""); err != nil {
+		return fmt.Errorf("failed to backup file: %v", err)
+	}
 
-		// 读取现有配置文件
-		configfileManager := utils.NewConfigFileManager()
-		configData, err := configfileManager.ReadConfigFile(configFile)
-		if err != nil {
-			return fmt.Errorf("failed to read existing config file: %v", err)
-		}
+	// 读取现有配置文件
+	configfileManager := utils.NewConfigFileManager()
+	configData, err := configfileManager.ReadConfigFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to read existing config file: %v", err)
+	}
 
-		// 更新指定host的配置
-		hostConfig := s.FormatPayConfig(payConfig)
-		configData[client.Host] = hostConfig
+	// 更新指定host的配置
+	hostConfig := s.FormatPayConfig(payConfig)
+	configData[client.Host] = hostConfig
 
-		// 写入文件
-		if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
-			return fmt.Errorf("failed to write config file: %v", err)
-		}
+	// 写入文件
+	if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
 
-		log.Printf("✅ 支付配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
-		return nil
-	}, nil)
+	log.Printf("✅ 支付配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
+	return nil
 }
 
 // DeletePayConfigByClientID 根据client_id删除支付配置（独立事务）
 func (s *PayConfigService) DeletePayConfigByClientID(clientID int) error {
+	// 锁定品牌配置文件，避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "DeletePayConfigByClientID", false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"brand-config-api/database"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrPlatformNotFound 端类型不存在
+var ErrPlatformNotFound = errors.New("platform not found")
+
+// PlatformRequest 创建或更新端类型的请求
+type PlatformRequest struct {
+	Code             string   `json:"code"`
+	Name             string   `json:"name"`
+	UniPlatform      string   `json:"uni_platform"`
+	DefineMacros     []string `json:"define_macros"`
+	ExtraHost        string   `json:"extra_host"`
+	IsExtra          bool     `json:"is_extra"`
+	RequiredSections []string `json:"required_sections"`
+	RequiredFields   []string `json:"required_fields"`
+	Enabled          *bool    `json:"enabled"` // 为空时：创建时启用，更新时保持不变
+}
+
+// toPlatform 转换为端类型记录，请求未指定 enabled 时使用 defaultEnabled
+func (r *PlatformRequest) toPlatform(defaultEnabled bool) *models.Platform {
+	platform := &models.Platform{
+		Code:             r.Code,
+		Name:             r.Name,
+		UniPlatform:      r.UniPlatform,
+		DefineMacros:     r.DefineMacros,
+		ExtraHost:        r.ExtraHost,
+		IsExtra:          r.IsExtra,
+		RequiredSections: r.RequiredSections,
+		RequiredFields:   r.RequiredFields,
+		Enabled:          defaultEnabled,
+	}
+	if r.Enabled != nil {
+		platform.Enabled = *r.Enabled
+	}
+	return platform
+}
+
+// PlatformService 端类型注册服务
+type PlatformService struct {
+	db *gorm.DB
+}
+
+// NewPlatformService 创建端类型注册服务实例
+func NewPlatformService() *PlatformService {
+	return &PlatformService{
+		db: database.DB,
+	}
+}
+
+// platformColumns 创建/更新时需要明确写入的字段，确保零值（如 false）被正确处理
+var platformColumns = []string{
+	"code",
+	"name",
+	"uni_platform",
+	"define_macros",
+	"extra_host",
+	"is_extra",
+	"required_sections",
+	"required_fields",
+	"enabled",
+}
+
+// InitRegistry 初始化端类型注册表：数据库为空时写入内置定义，然后加载到内存
+func (s *PlatformService) InitRegistry() error {
+	var count int64
+	if err := s.db.Model(&models.Platform{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count platforms: %v", err)
+	}
+
+	if count == 0 {
+		log.Printf("📝 端类型表为空，写入内置端类型定义")
+		for _, platform := range utils.DefaultPlatforms() {
+			platform := platform
+			if err := s.db.Select(platformColumns).Create(&platform).Error; err != nil {
+				return fmt.Errorf("failed to seed platform %s: %v", platform.Code, err)
+			}
+		}
+	}
+
+	return s.ReloadRegistry()
+}
+
+// ReloadRegistry 从数据库重新加载端类型注册表
+func (s *PlatformService) ReloadRegistry() error {
+	platforms, err := s.GetAllPlatforms()
+	if err != nil {
+		return fmt.Errorf("failed to load platforms: %v", err)
+	}
+
+	utils.GetPlatformRegistry().Replace(platforms)
+	log.Printf("✅ 端类型注册表已加载: %d 个端类型", len(platforms))
+	return nil
+}
+
+// GetAllPlatforms 获取所有端类型
+func (s *PlatformService) GetAllPlatforms() ([]models.Platform, error) {
+	var platforms []models.Platform
+	err := s.db.Order("id").Find(&platforms).Error
+	return platforms, err
+}
+
+// GetPlatformByID 根据ID获取端类型
+func (s *PlatformService) GetPlatformByID(id int) (*models.Platform, error) {
+	var platform models.Platform
+	if err := s.db.First(&platform, id).Error; err != nil {
+		return nil, err
+	}
+	return &platform, nil
+}
+
+// CreatePlatform 创建端类型，未指定 enabled 时默认启用
+func (s *PlatformService) CreatePlatform(req *PlatformRequest) (*models.Platform, error) {
+	platform := req.toPlatform(true)
+	if err := s.validatePlatform(platform, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Select(platformColumns).Create(platform).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.ReloadRegistry(); err != nil {
+		return nil, err
+	}
+	return platform, nil
+}
+
+// UpdatePlatform 更新端类型，未指定 enabled 时保持原状态
+func (s *PlatformService) UpdatePlatform(id int, req *PlatformRequest) (*models.Platform, error) {
+	var existing models.Platform
+	if err := s.db.First(&existing, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlatformNotFound
+		}
+		return nil, err
+	}
+
+	platform := req.toPlatform(existing.Enabled)
+	if err := s.validatePlatform(platform, id); err != nil {
+		return nil, err
+	}
+
+	// 已被客户端使用的端类型不允许修改code，否则已有网站会找不到端类型定义
+	if platform.Code != existing.Code {
+		var count int64
+		s.db.Model(&models.Client{}).Where("host = ?", existing.Code).Count(&count)
+		if count > 0 {
+			return nil, errors.New("cannot change code of platform with existing clients")
+		}
+	}
+
+	// 仍在使用的端类型不允许停用，被引用为额外端时不允许取消 is_extra
+	if existing.Enabled && !platform.Enabled {
+		var count int64
+		s.db.Model(&models.Client{}).Where("host = ?", existing.Code).Count(&count)
+		if count > 0 {
+			return nil, errors.New("cannot disable platform with existing clients")
+		}
+		s.db.Model(&models.Platform{}).Where("extra_host = ? AND enabled = ?", existing.Code, true).Count(&count)
+		if count > 0 {
+			return nil, errors.New("cannot disable platform referenced as extra host")
+		}
+	}
+	if existing.IsExtra && !platform.IsExtra {
+		var count int64
+		s.db.Model(&models.Platform{}).Where("extra_host = ?", existing.Code).Count(&count)
+		if count > 0 {
+			return nil, errors.New("cannot unset is_extra of platform referenced as extra host")
+		}
+	}
+
+	if err := s.db.Model(&existing).Select(platformColumns).Updates(platform).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.ReloadRegistry(); err != nil {
+		return nil, err
+	}
+
+	return s.GetPlatformByID(id)
+}
+
+// DeletePlatform 删除端类型
+func (s *PlatformService) DeletePlatform(id int) error {
+	var platform models.Platform
+	if err := s.db.First(&platform, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPlatformNotFound
+		}
+		return err
+	}
+
+	// 检查是否有相关的客户端
+	var count int64
+	s.db.Model(&models.Client{}).Where("host = ?", platform.Code).Count(&count)
+	if count > 0 {
+		return errors.New("cannot delete platform with existing clients")
+	}
+
+	// 检查是否被其他端类型作为额外端引用
+	s.db.Model(&models.Platform{}).Where("extra_host = ?", platform.Code).Count(&count)
+	if count > 0 {
+		return errors.New("cannot delete platform referenced as extra host")
+	}
+
+	if err := s.db.Delete(&platform).Error; err != nil {
+		return fmt.Errorf("failed to delete platform from database: %v", err)
+	}
+
+	return s.ReloadRegistry()
+}
+
+// validatePlatform 验证端类型定义
+func (s *PlatformService) validatePlatform(platform *models.Platform, id int) error {
+	platform.Code = strings.TrimSpace(platform.Code)
+	if platform.Code == "" {
+		return errors.New("code is required")
+	}
+	if platform.Name == "" {
+		return errors.New("name is required")
+	}
+	if platform.UniPlatform == "" {
+		return errors.New("uni_platform is required")
+	}
+
+	// 检查code是否与其他端类型冲突
+	var existing models.Platform
+	if err := s.db.Where("code = ? AND id != ?", platform.Code, id).First(&existing).Error; err == nil {
+		return errors.New("platform code already exists")
+	}
+
+	// 额外端必须已注册，且自身必须是额外端
+	if platform.ExtraHost != "" {
+		if platform.ExtraHost == platform.Code {
+			return errors.New("extra_host cannot be the platform itself")
+		}
+		var extra models.Platform
+		if err := s.db.Where("code = ?", platform.ExtraHost).First(&extra).Error; err != nil {
+			return fmt.Errorf("extra_host %s is not a registered platform", platform.ExtraHost)
+		}
+		if !extra.IsExtra {
+			return fmt.Errorf("extra_host %s must be marked as is_extra", platform.ExtraHost)
+		}
+	}
+
+	// 必填字段必须写成 section.field 形式
+	for _, field := range platform.RequiredFields {
+		parts := strings.SplitN(field, ".", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid required field %q, expected section.field", field)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"brand-config-api/config"
+	"brand-config-api/utils"
+	"brand-config-api/utils/rollback"
+)
+
+// 待处理回滚日志的处理动作
+const (
+	RollbackResolveRollback = "rollback" // 按日志恢复文件
+	RollbackResolveDiscard  = "discard"  // 保留文件当前状态
+)
+
+// ErrJournalNotFound 回滚日志不存在
+var ErrJournalNotFound = errors.New("rollback journal not found")
+
+// RollbackService 文件回滚日志服务
+type RollbackService struct {
+	config *config.Config
+}
+
+// NewRollbackService 创建文件回滚日志服务实例
+func NewRollbackService() *RollbackService {
+	return &RollbackService{
+		config: config.Load(),
+	}
+}
+
+// RecoverOnStartup 启动时处理上次进程遗留的回滚日志
+// 事务进行中退出的日志（数据库事务已随连接断开回滚）在开启自动恢复时直接回滚文件，
+// 提交中或回滚失败的日志只记录下来，等待通过接口人工处理
+func (s *RollbackService) RecoverOnStartup() {
+	journals, err := s.ListPending()
+	if err != nil {
+		log.Printf("⚠️ 读取回滚日志失败: %v", err)
+		return
+	}
+	if len(journals) == 0 {
+		return
+	}
+
+	// 恢复文件期间锁定整个工作区，避免与其他文件操作交错
+	if s.config.RollbackAutoRecover {
+		release, err := acquireWorkspaceLock(s.config, "RecoverOnStartup", utils.LockKeyWorkspace)
+		if err != nil {
+			log.Printf("⚠️ 获取工作区锁失败，跳过自动回滚: %v", err)
+			return
+		}
+		defer release()
+	}
+
+	pending := 0
+	for _, journal := range journals {
+		if !s.config.RollbackAutoRecover || journal.Status != rollback.JournalStatusActive {
+			pending++
+			continue
+		}
+		if err := journal.Rollback(); err != nil {
+			log.Printf("❌ 自动回滚未完成的事务失败 %s: %v", journal.ID, err)
+			pending++
+			continue
+		}
+		log.Printf("✅ 已自动回滚未完成的事务: %s", journal.ID)
+	}
+
+	if pending > 0 {
+		log.Printf("⚠️ 有 %d 个未完成的文件事务需要处理，请查看 GET /api/rollback/pending", pending)
+	}
+}
+
+// ListPending 列出需要处理的回滚日志（不包含本进程正在进行的事务）
+func (s *RollbackService) ListPending() ([]*rollback.Journal, error) {
+	journals, err := rollback.ListJournals(s.config)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]*rollback.Journal, 0, len(journals))
+	for _, journal := range journals {
+		if journal.IsPending() {
+			pending = append(pending, journal)
+		}
+	}
+	return pending, nil
+}
+
+// ResolvePending 处理一个待处理的回滚日志：rollback 恢复文件，discard 保留当前文件
+func (s *RollbackService) ResolvePending(id, action string) error {
+	release, err := acquireWorkspaceLock(s.config, "ResolvePending("+id+")", utils.LockKeyWorkspace)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	journal, err := rollback.LoadJournal(s.config, id)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrJournalNotFound
+		}
+		return err
+	}
+	if !journal.IsPending() {
+		return fmt.Errorf("journal %s belongs to a running transaction", id)
+	}
+
+	switch action {
+	case RollbackResolveRollback:
+		return journal.Rollback()
+	case RollbackResolveDiscard:
+		return journal.Discard()
+	default:
+		return fmt.Errorf("invalid action: %s", action)
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"brand-config-api/config"
+	"brand-config-api/database"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 任务列表分页参数
+const (
+	TaskListDefaultPageSize = 20
+	TaskListMaxPageSize     = 100
+)
+
+// TaskQuery 任务列表查询条件
+type TaskQuery struct {
+	Type     string
+	Status   string
+	From     *time.Time // 创建时间起（包含）
+	To       *time.Time // 创建时间止（不包含）
+	Page     int
+	PageSize int
+}
+
+// TaskService 任务持久化服务，实现 utils.TaskStore
+type TaskService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewTaskService 创建任务持久化服务实例
+func NewTaskService() *TaskService {
+	return &TaskService{
+		db:     database.DB,
+		config: config.Load(),
+	}
+}
+
+// SaveTask 保存任务状态（不覆盖输出日志）
+func (s *TaskService) SaveTask(task utils.Task) error {
+	record := taskToRecord(task)
+	err := s.db.Omit("log").Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"status", "progress", "message", "error", "result", "updated_at", "started_at", "finished_at",
+		}),
+	}).Create(record).Error
+	if err != nil {
+		return fmt.Errorf("failed to save task: %v", err)
+	}
+	return nil
+}
+
+// AppendTaskLog 追加任务输出日志
+func (s *TaskService) AppendTaskLog(taskID string, text string) error {
+	err := s.db.Model(&models.TaskRecord{}).
+		Where("id = ?", taskID).
+		UpdateColumn("log", gorm.Expr("CONCAT(IFNULL(log, ''), ?)", text)).Error
+	if err != nil {
+		return fmt.Errorf("failed to append task log: %v", err)
+	}
+	return nil
+}
+
+// LoadTask 读取任务
+func (s *TaskService) LoadTask(taskID string) (*utils.Task, error) {
+	var record models.TaskRecord
+	if err := s.db.Omit("log").First(&record, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to load task: %v", err)
+	}
+	return recordToTask(record), nil
+}
+
+// LoadTaskLog 读取任务的完整输出日志
+func (s *TaskService) LoadTaskLog(taskID string) (string, error) {
+	var record models.TaskRecord
+	if err := s.db.Select("id", "log").First(&record, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", utils.ErrTaskNotFound
+		}
+		return "", fmt.Errorf("failed to load task log: %v", err)
+	}
+	return record.Log, nil
+}
+
+// ListTasks 按类型、状态、创建时间筛选任务，按创建时间倒序分页返回
+func (s *TaskService) ListTasks(query TaskQuery) ([]*utils.Task, int64, error) {
+	db := s.db.Model(&models.TaskRecord{})
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count tasks: %v", err)
+	}
+
+	page, pageSize := query.Page, query.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = TaskListDefaultPageSize
+	}
+	if pageSize > TaskListMaxPageSize {
+		pageSize = TaskListMaxPageSize
+	}
+
+	var records []models.TaskRecord
+	if err := db.Omit("log").
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&records).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list tasks: %v", err)
+	}
+
+	tasks := make([]*utils.Task, 0, len(records))
+	for _, record := range records {
+		tasks = append(tasks, recordToTask(record))
+	}
+	return tasks, total, nil
+}
+
+// MarkInterruptedTasks 将上次进程退出时仍未结束的任务标记为已中断
+func (s *TaskService) MarkInterruptedTasks() {
+	now := time.Now()
+	result := s.db.Model(&models.TaskRecord{}).
+		Where("status IN ?", []string{string(utils.TaskStatusPending), string(utils.TaskStatusRunning)}).
+		Updates(map[string]interface{}{
+			"status":      string(utils.TaskStatusInterrupted),
+			"message":     "服务重启，任务已中断",
+			"updated_at":  now,
+			"finished_at": now,
+		})
+	if result.Error != nil {
+		log.Printf("⚠️ 标记中断任务失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("⚠️ 发现 %d 个上次运行时未结束的任务，已标记为中断", result.RowsAffected)
+	}
+}
+
+// taskToRecord 任务转换为数据库记录
+func taskToRecord(task utils.Task) *models.TaskRecord {
+	return &models.TaskRecord{
+		ID:         task.ID,
+		Type:       task.Type,
+		Requester:  task.Requester,
+		Params:     string(task.Params),
+		Status:     string(task.Status),
+		Progress:   task.Progress,
+		Message:    task.Message,
+		Error:      task.Error,
+		Result:     string(task.Result),
+		CreatedAt:  task.CreatedAt,
+		UpdatedAt:  task.UpdatedAt,
+		StartedAt:  task.StartedAt,
+		FinishedAt: task.FinishedAt,
+	}
+}
+
+// recordToTask 数据库记录转换为任务
+func recordToTask(record models.TaskRecord) *utils.Task {
+	task := &utils.Task{
+		ID:         record.ID,
+		Type:       record.Type,
+		Requester:  record.Requester,
+		Status:     utils.TaskStatus(record.Status),
+		Progress:   record.Progress,
+		Message:    record.Message,
+		Error:      record.Error,
+		CreatedAt:  record.CreatedAt,
+		UpdatedAt:  record.UpdatedAt,
+		StartedAt:  record.StartedAt,
+		FinishedAt: record.FinishedAt,
+	}
+	if record.Params != "" && json.Valid([]byte(record.Params)) {
+		task.Params = json.RawMessage(record.Params)
+	}
+	if record.Result != "" && json.Valid([]byte(record.Result)) {
+		task.Result = json.RawMessage(record.Result)
+	}
+	return task
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"brand-config-api/config"
 	"brand-config-api/database"
@@ -77,87 +78,133 @@ func (s *UIConfigService) generateConfigFile(ctx *rollback.TransactionContext, u
 	return nil
 }
 
-// UpdateUIConfigByClientID 根据client_id更新UI配置
-func (s *UIConfigService) UpdateUIConfigByClientID(clientID int, uiConfig models.UIConfig) error {
+// UpdateUIConfigByClientID 根据client_id更新UI配置，并记录修订历史
+func (s *UIConfigService) UpdateUIConfigByClientID(clientID int, uiConfig models.UIConfig, operator string) error {
+	// 锁定品牌配置文件，避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "UpdateUIConfigByClientID", false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
 	return rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
-		// 获取客户端信息
-		var client models.Client
-		if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
-			return fmt.Errorf("failed to find client: %v", err)
+		revisionService := NewConfigRevisionService()
+		if err := revisionService.EnsureBaseline(ctx, clientID, models.ConfigSectionUI); err != nil {
+			return err
 		}
 
-		// 获取品牌信息
-		var brand models.Brand
-		if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
-			return fmt.Errorf("failed to find brand: %v", err)
+		if err := s.updateUIConfigInternal(ctx, clientID, uiConfig, false); err != nil {
+			return err
 		}
 
-		// 添加调试信息
-		log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
-			clientID, client.BrandID, brand.Code, client.Host)
-
-		log.Printf("🔄 开始更新UI配置: brand=%s, host=%s", brand.Code, client.Host)
-
-		// 检查是否已存在UI配置记录
-		var existingUIConfig models.UIConfig
-		err := ctx.DB.Where("client_id = ?", clientID).First(&existingUIConfig).Error
-
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				// 记录不存在，创建新记录
-				log.Printf("📝 UI配置记录不存在，创建新记录")
-				uiConfig.ClientID = clientID
-				if err := ctx.DB.Create(&uiConfig).Error; err != nil {
-					return fmt.Errorf("failed to create ui config in database: %v", err)
-				}
-				log.Printf("✅ 数据库记录创建成功")
-			} else {
-				return fmt.Errorf("failed to check existing ui config: %v", err)
+		return revisionService.RecordRevision(ctx, clientID, models.ConfigSectionUI, models.RevisionActionUpdate, operator, "")
+	}, nil)
+}
+
+// uiConfigColumns UI配置的可更新字段
+var uiConfigColumns = []string{"theme_bg_main", "theme_bg_second", "theme_text_main", "updated_at"}
+
+// updateUIConfigInternal 内部更新UI配置方法（不管理事务）
+// overwrite 为 true 时按传入的配置写入所有字段（包括空值，恢复历史修订时使用），否则只更新非空字段
+func (s *UIConfigService) updateUIConfigInternal(ctx *rollback.TransactionContext, clientID int, uiConfig models.UIConfig, overwrite bool) error {
+	// 获取客户端信息
+	var client models.Client
+	if err := ctx.DB.Where("id = ?", clientID).First(&client).Error; err != nil {
+		return fmt.Errorf("failed to find client: %v", err)
+	}
+
+	// 获取品牌信息
+	var brand models.Brand
+	if err := ctx.DB.Where("id = ?", client.BrandID).First(&brand).Error; err != nil {
+		return fmt.Errorf("failed to find brand: %v", err)
+	}
+
+	// 添加调试信息
+	log.Printf("🔍 调试信息: clientID=%d, client.BrandID=%d, brand.Code=%s, client.Host=%s",
+		clientID, client.BrandID, brand.Code, client.Host)
+
+	log.Printf("🔄 开始更新UI配置: brand=%s, host=%s", brand.Code, client.Host)
+
+	// 检查是否已存在UI配置记录
+	var existingUIConfig models.UIConfig
+	err := ctx.DB.Where("client_id = ?", clientID).First(&existingUIConfig).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// 记录不存在，创建新记录
+			log.Printf("📝 UI配置记录不存在，创建新记录")
+			uiConfig.ClientID = clientID
+			if err := ctx.DB.Create(&uiConfig).Error; err != nil {
+				return fmt.Errorf("failed to create ui config in database: %v", err)
 			}
+			log.Printf("✅ 数据库记录创建成功")
 		} else {
-			// 记录存在，更新记录
-			log.Printf("📝 UI配置记录已存在，更新记录")
-			if err := ctx.DB.Model(&existingUIConfig).Updates(uiConfig).Error; err != nil {
-				return fmt.Errorf("failed to update ui config in database: %v", err)
-			}
-			log.Printf("✅ 数据库记录更新成功")
+			return fmt.Errorf("failed to check existing ui config: %v", err)
 		}
-
-		// 更新本地配置文件
-		// 构建文件路径
-		configFile := filepath.Join(s.config.File.UIConfigsDir, brand.Code+".js")
-
-		// 备份文件
-		if err := ctx.Files.Backup(configFile, ""); err != nil {
-			return fmt.Errorf("failed to backup file: %v", err)
+	} else {
+		// 记录存在，更新记录
+		log.Printf("📝 UI配置记录已存在，更新记录")
+		uiConfig.UpdatedAt = time.Now()
+
+		// 恢复历史修订时使用 Select 写入所有字段（包括空值），普通更新不覆盖未传入的字段
+		db := ctx.DB.Model(&existingUIConfig)
+		if overwrite {
+			db = db.Select(uiConfigColumns)
+		}
+		if err := db.Updates(&uiConfig).Error; err != nil {
+			return fmt.Errorf("failed to update ui config in database: %v", err)
 		}
+		log.Printf("✅ 数据库记录更新成功")
 
-		// 读取现有配置文件
-		configfileManager := utils.NewConfigFileManager()
-		configData, err := configfileManager.ReadConfigFile(configFile)
-		if err != nil {
-			return fmt.Errorf("failed to read existing config file: %v", err)
+		// 配置文件按更新后的完整记录生成
+		var updatedUIConfig models.UIConfig
+		if err := ctx.DB.First(&updatedUIConfig, existingUIConfig.ID).Error; err != nil {
+			return fmt.Errorf("failed to reload ui config: %v", err)
 		}
+		uiConfig = updatedUIConfig
+	}
+
+	// 更新本地配置文件
+	// 构建文件路径
+	configFile := filepath.Join(s.config.File.UIConfigsDir, brand.Code+".js")
 
-		// 更新指定host的配置
-		hostConfig := s.FormatUIConfig(uiConfig)
-		configData[client.Host] = hostConfig
+	// 备份文件
+	if err := ctx.Files.Backup(configFile, ""); err != nil {
+		return fmt.Errorf("failed to backup file: %v", err)
+	}
 
-		// 写入文件
-		if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
-			return fmt.Errorf("failed to write config file: %v", err)
-		}
+	// 读取现有配置文件
+	configfileManager := utils.NewConfigFileManager()
+	configData, err := configfileManager.ReadConfigFile(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to read existing config file: %v", err)
+	}
 
-		log.Printf("✅ UI配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
-		return nil
-	}, nil)
+	// 更新指定host的配置
+	hostConfig := s.FormatUIConfig(uiConfig)
+	configData[client.Host] = hostConfig
+
+	// 写入文件
+	if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	log.Printf("✅ UI配置更新成功: brand=%s, host=%s", brand.Code, client.Host)
+	return nil
 }
 
 // DeleteUIConfigByClientID 根据client_id删除UI配置（独立事务）
 func (s *UIConfigService) DeleteUIConfigByClientID(clientID int) error {
+	// 锁定品牌配置文件，避免并发读改写
+	release, err := lockClientFiles(s.db, s.config, clientID, "DeleteUIConfigByClientID", false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建事务管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
@@ -0,0 +1,479 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"brand-config-api/models"
+	"brand-config-api/utils"
+	"brand-config-api/utils/rollback"
+)
+
+// 批量创建的执行模式
+const (
+	BulkModeAllOrNothing    = "all_or_nothing"    // 任意一行失败则全部回滚
+	BulkModeContinueOnError = "continue_on_error" // 逐行独立提交，失败的行跳过
+)
+
+// 批量创建中单行的状态
+const (
+	BulkRowCreated    = "created"
+	BulkRowFailed     = "failed"
+	BulkRowInvalid    = "invalid"
+	BulkRowRolledBack = "rolled_back"
+	BulkRowSkipped    = "skipped"
+)
+
+// BulkWebsiteRow 清单中的一行
+type BulkWebsiteRow struct {
+	Row       int                   `json:"row"` // 表格中的行号（从1开始，含表头）
+	BrandCode string                `json:"brand_code"`
+	Host      string                `json:"host"`
+	Errors    []string              `json:"errors,omitempty"`
+	Request   *CreateWebsiteRequest `json:"-"`
+}
+
+// BulkWebsiteManifest 解析并校验后的批量创建清单
+type BulkWebsiteManifest struct {
+	Columns []string         `json:"columns"`
+	Rows    []BulkWebsiteRow `json:"rows"`
+	Valid   int              `json:"valid"`
+	Invalid int              `json:"invalid"`
+}
+
+// BulkWebsiteRowResult 单行执行结果
+type BulkWebsiteRowResult struct {
+	Row       int    `json:"row"`
+	BrandCode string `json:"brand_code"`
+	Host      string `json:"host"`
+	Status    string `json:"status"`
+	ClientID  int    `json:"client_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkWebsiteResult 批量创建结果
+type BulkWebsiteResult struct {
+	Mode      string                 `json:"mode"`
+	Total     int                    `json:"total"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Rows      []BulkWebsiteRowResult `json:"rows"`
+}
+
+// bulkField 清单列对应的请求字段
+type bulkField struct {
+	Section string
+	Field   string
+	Kind    reflect.Kind
+}
+
+// bulkBrandCodeColumn 按品牌编码指定品牌的列名（与 basic_info.brand_id 二选一）
+const bulkBrandCodeColumn = "brand_code"
+
+// bulkWebsiteFields 通过反射列出创建网站请求的所有字段，键为 "配置块.字段"
+// 同时返回配置块顺序，用于解析不带配置块前缀的列名
+func bulkWebsiteFields() (map[string]bulkField, []string) {
+	fields := make(map[string]bulkField)
+	var sections []string
+
+	reqType := reflect.TypeOf(CreateWebsiteRequest{})
+	for i := 0; i < reqType.NumField(); i++ {
+		section := strings.Split(reqType.Field(i).Tag.Get("json"), ",")[0]
+		if section == "" || section == "-" {
+			continue
+		}
+		sections = append(sections, section)
+
+		sectionType := reqType.Field(i).Type
+		if sectionType.Kind() == reflect.Ptr {
+			sectionType = sectionType.Elem()
+		}
+		for j := 0; j < sectionType.NumField(); j++ {
+			name := strings.Split(sectionType.Field(j).Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			kind := sectionType.Field(j).Type.Kind()
+			if kind == reflect.Ptr {
+				kind = sectionType.Field(j).Type.Elem().Kind()
+			}
+			fields[section+"."+name] = bulkField{Section: section, Field: name, Kind: kind}
+		}
+	}
+	return fields, sections
+}
+
+// resolveBulkColumns 将表头解析为请求字段
+// 列名可以写成 "base_config.app_name"，也可以省略配置块直接写 "app_name"（额外基础配置必须带前缀）
+func resolveBulkColumns(header []string) ([]*bulkField, error) {
+	fields, sections := bulkWebsiteFields()
+
+	columns := make([]*bulkField, len(header))
+	var unknown []string
+	hasHost, hasBrand := false, false
+	for i, raw := range header {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		if name == bulkBrandCodeColumn {
+			columns[i] = &bulkField{Field: bulkBrandCodeColumn, Kind: reflect.String}
+			hasBrand = true
+			continue
+		}
+
+		field, ok := fields[name]
+		if !ok && !strings.Contains(name, ".") {
+			for _, section := range sections {
+				if section == "extra_base_config" {
+					continue
+				}
+				if field, ok = fields[section+"."+name]; ok {
+					break
+				}
+			}
+		}
+		if !ok {
+			unknown = append(unknown, raw)
+			continue
+		}
+
+		columns[i] = &field
+		switch field.Section + "." + field.Field {
+		case "basic_info.host":
+			hasHost = true
+		case "basic_info.brand_id":
+			hasBrand = true
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown columns: %s", strings.Join(unknown, ", "))
+	}
+	if !hasHost {
+		return nil, fmt.Errorf("missing required column: host")
+	}
+	if !hasBrand {
+		return nil, fmt.Errorf("missing required column: brand_code or brand_id")
+	}
+	return columns, nil
+}
+
+// parseBulkCell 按字段类型转换单元格的值
+func parseBulkCell(field *bulkField, raw string) (interface{}, error) {
+	switch field.Kind {
+	case reflect.Bool:
+		switch strings.ToLower(raw) {
+		case "true", "1", "yes", "y", "on", "是":
+			return true, nil
+		case "false", "0", "no", "n", "off", "否":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid boolean value %q", raw)
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Uint:
+		if value, err := strconv.Atoi(raw); err == nil {
+			return value, nil
+		}
+		// XLSX中的数字可能带小数部分，如 "12.0"
+		if value, err := strconv.ParseFloat(raw, 64); err == nil && value == math.Trunc(value) {
+			return int(value), nil
+		}
+		return nil, fmt.Errorf("invalid integer value %q", raw)
+	default:
+		return raw, nil
+	}
+}
+
+// ParseBulkManifest 解析CSV/XLSX清单并逐行校验，返回每一行的创建请求和校验错误
+func (s *WebsiteService) ParseBulkManifest(fileName string, data []byte) (*BulkWebsiteManifest, error) {
+	table, err := utils.ReadTableFile(fileName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第一行非空行为表头
+	headerIndex := -1
+	for i, row := range table {
+		if !isBlankRow(row) {
+			headerIndex = i
+			break
+		}
+	}
+	if headerIndex < 0 {
+		return nil, fmt.Errorf("manifest is empty")
+	}
+
+	header := table[headerIndex]
+	columns, err := resolveBulkColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &BulkWebsiteManifest{Rows: make([]BulkWebsiteRow, 0)}
+	for i, column := range columns {
+		if column != nil {
+			manifest.Columns = append(manifest.Columns, strings.TrimSpace(header[i]))
+		}
+	}
+
+	brands := make(map[string]*models.Brand)
+	seen := make(map[string]int)
+
+	for i := headerIndex + 1; i < len(table); i++ {
+		if isBlankRow(table[i]) {
+			continue
+		}
+
+		row := s.parseBulkRow(i+1, table[i], columns, brands)
+		if len(row.Errors) == 0 {
+			key := fmt.Sprintf("%d|%s", row.Request.BasicInfo.BrandID, row.Request.BasicInfo.Host)
+			if first, exists := seen[key]; exists {
+				row.Errors = append(row.Errors, fmt.Sprintf("duplicate of row %d", first))
+			} else {
+				seen[key] = row.Row
+			}
+		}
+
+		if len(row.Errors) == 0 {
+			manifest.Valid++
+		} else {
+			manifest.Invalid++
+		}
+		manifest.Rows = append(manifest.Rows, row)
+	}
+
+	if len(manifest.Rows) == 0 {
+		return nil, fmt.Errorf("manifest has no data rows")
+	}
+	return manifest, nil
+}
+
+// parseBulkRow 将一行转换为创建网站请求并校验
+func (s *WebsiteService) parseBulkRow(rowNumber int, cells []string, columns []*bulkField, brands map[string]*models.Brand) BulkWebsiteRow {
+	row := BulkWebsiteRow{Row: rowNumber}
+	sections := make(map[string]map[string]interface{})
+
+	for i, column := range columns {
+		if column == nil || i >= len(cells) {
+			continue
+		}
+		raw := strings.TrimSpace(cells[i])
+		if raw == "" {
+			continue
+		}
+
+		if column.Field == bulkBrandCodeColumn {
+			row.BrandCode = raw
+			continue
+		}
+		value, err := parseBulkCell(column, raw)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s.%s: %v", column.Section, column.Field, err))
+			continue
+		}
+		// 可选配置块（额外基础配置、小说配置）只有在该行填写了对应列时才会生成
+		if sections[column.Section] == nil {
+			sections[column.Section] = make(map[string]interface{})
+		}
+		sections[column.Section][column.Field] = value
+	}
+
+	if basicInfo := sections["basic_info"]; basicInfo != nil {
+		row.Host, _ = basicInfo["host"].(string)
+	}
+
+	// 解析品牌：优先使用品牌编码，否则校验品牌ID是否存在
+	brand, err := s.resolveBulkBrand(row.BrandCode, sections["basic_info"], brands)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	} else {
+		row.BrandCode = brand.Code
+		if sections["basic_info"] == nil {
+			sections["basic_info"] = make(map[string]interface{})
+		}
+		sections["basic_info"]["brand_id"] = brand.ID
+	}
+
+	if len(row.Errors) > 0 {
+		return row
+	}
+
+	data, err := json.Marshal(sections)
+	if err != nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("failed to build request: %v", err))
+		return row
+	}
+	var req CreateWebsiteRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("failed to build request: %v", err))
+		return row
+	}
+
+	if err := s.validateRequest(&req); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+		return row
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Client{}).Where("brand_id = ? AND host = ?", req.BasicInfo.BrandID, req.BasicInfo.Host).Count(&count).Error; err != nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("failed to check existing website: %v", err))
+		return row
+	}
+	if count > 0 {
+		row.Errors = append(row.Errors, fmt.Sprintf("website %s-%s already exists", req.BasicInfo.Host, row.BrandCode))
+		return row
+	}
+
+	row.Request = &req
+	return row
+}
+
+// resolveBulkBrand 根据品牌编码或品牌ID查找品牌，结果按编码缓存
+func (s *WebsiteService) resolveBulkBrand(brandCode string, basicInfo map[string]interface{}, cache map[string]*models.Brand) (*models.Brand, error) {
+	if brandCode != "" {
+		if brand, ok := cache[brandCode]; ok {
+			return brand, nil
+		}
+		var brand models.Brand
+		if err := s.db.Where("code = ?", brandCode).First(&brand).Error; err != nil {
+			return nil, fmt.Errorf("brand %s not found", brandCode)
+		}
+		cache[brandCode] = &brand
+		return &brand, nil
+	}
+
+	brandID, _ := basicInfo["brand_id"].(int)
+	if brandID <= 0 {
+		return nil, fmt.Errorf("brand_code or brand_id is required")
+	}
+	var brand models.Brand
+	if err := s.db.First(&brand, brandID).Error; err != nil {
+		return nil, fmt.Errorf("brand %d not found", brandID)
+	}
+	cache[brand.Code] = &brand
+	return &brand, nil
+}
+
+// CreateWebsitesBulk 按清单批量创建网站
+// all_or_nothing 模式在同一事务中创建所有行，任意一行失败则全部回滚；
+// continue_on_error 模式逐行独立创建，校验失败或创建失败的行被跳过
+func (s *WebsiteService) CreateWebsitesBulk(manifest *BulkWebsiteManifest, mode string, progressCallback func(int, string, string)) (*BulkWebsiteResult, error) {
+	if mode != BulkModeAllOrNothing && mode != BulkModeContinueOnError {
+		return nil, fmt.Errorf("invalid bulk mode: %s", mode)
+	}
+	if mode == BulkModeAllOrNothing && manifest.Invalid > 0 {
+		return nil, fmt.Errorf("manifest has %d invalid rows", manifest.Invalid)
+	}
+
+	result := &BulkWebsiteResult{
+		Mode:  mode,
+		Total: len(manifest.Rows),
+		Rows:  make([]BulkWebsiteRowResult, len(manifest.Rows)),
+	}
+	for i, row := range manifest.Rows {
+		result.Rows[i] = BulkWebsiteRowResult{Row: row.Row, BrandCode: row.BrandCode, Host: row.Host, Status: BulkRowSkipped}
+		if len(row.Errors) > 0 {
+			result.Rows[i].Status = BulkRowInvalid
+			result.Rows[i].Error = strings.Join(row.Errors, "; ")
+			result.Failed++
+		}
+	}
+
+	if mode == BulkModeAllOrNothing {
+		return s.createWebsitesAllOrNothing(manifest, result, progressCallback)
+	}
+
+	for i, row := range manifest.Rows {
+		if row.Request == nil {
+			continue
+		}
+
+		created, err := s.CreateWebsite(row.Request, bulkRowProgress(progressCallback, i, len(manifest.Rows), row))
+		if err != nil {
+			log.Printf("❌ 批量创建第%d行失败: %v", row.Row, err)
+			result.Rows[i].Status = BulkRowFailed
+			result.Rows[i].Error = err.Error()
+			result.Failed++
+			continue
+		}
+		result.Rows[i].Status = BulkRowCreated
+		result.Rows[i].ClientID, _ = created["client_id"].(int)
+		result.Succeeded++
+	}
+
+	log.Printf("✅ 批量创建网站完成: 成功 %d, 失败 %d", result.Succeeded, result.Failed)
+	return result, nil
+}
+
+// createWebsitesAllOrNothing 在同一事务中创建所有行
+func (s *WebsiteService) createWebsitesAllOrNothing(manifest *BulkWebsiteManifest, result *BulkWebsiteResult, progressCallback func(int, string, string)) (*BulkWebsiteResult, error) {
+	brandCodes := make([]string, 0, len(manifest.Rows))
+	for _, row := range manifest.Rows {
+		brandCodes = append(brandCodes, row.BrandCode)
+	}
+	release, err := lockBrandFiles(s.config, "CreateWebsitesBulk", true, brandCodes...)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
+
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		for i, row := range manifest.Rows {
+			created, err := s.createWebsiteInTransaction(ctx, row.Request, bulkRowProgress(progressCallback, i, len(manifest.Rows), row))
+			if err != nil {
+				result.Rows[i].Status = BulkRowFailed
+				result.Rows[i].Error = err.Error()
+				return fmt.Errorf("row %d (%s-%s): %v", row.Row, row.Host, row.BrandCode, err)
+			}
+			result.Rows[i].Status = BulkRowCreated
+			result.Rows[i].ClientID = created.Client.ID
+		}
+		return nil
+	}, progressCallback)
+
+	if err != nil {
+		for i := range result.Rows {
+			if result.Rows[i].Status == BulkRowCreated {
+				result.Rows[i].Status = BulkRowRolledBack
+				result.Rows[i].ClientID = 0
+			}
+		}
+		result.Failed = 1
+		if progressCallback != nil {
+			progressCallback(0, "操作失败", "批量创建失败，已回滚全部网站")
+		}
+		return result, err
+	}
+
+	result.Succeeded = len(result.Rows)
+	log.Printf("✅ 批量创建网站完成: 共 %d 个", result.Succeeded)
+	return result, nil
+}
+
+// bulkRowProgress 将单行的进度换算为整体进度，并在文案前加上行信息
+func bulkRowProgress(progressCallback func(int, string, string), index, total int, row BulkWebsiteRow) func(int, string, string) {
+	if progressCallback == nil {
+		return nil
+	}
+	return func(percentage int, text string, detail string) {
+		overall := (index*100 + percentage) / total
+		progressCallback(overall, fmt.Sprintf("[%d/%d] %s-%s %s", index+1, total, row.Host, row.BrandCode, text), detail)
+	}
+}
+
+// isBlankRow 判断表格行是否全部为空
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
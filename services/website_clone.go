@@ -0,0 +1,176 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"brand-config-api/models"
+	"brand-config-api/utils"
+	"brand-config-api/utils/rollback"
+
+	"gorm.io/gorm"
+)
+
+// CloneWebsiteRequest 克隆网站请求
+type CloneWebsiteRequest struct {
+	BrandCode string                 `json:"brand_code" binding:"required"` // 目标品牌，不存在时使用源品牌的类型创建
+	Host      string                 `json:"host"`                          // 目标端类型，为空时与源网站相同
+	Overrides map[string]interface{} `json:"overrides"`                     // 稀疏覆盖，结构与创建网站请求相同，如 {"base_config": {"app_name": "xx"}}
+}
+
+// CloneWebsitePlan 克隆计划：合并覆盖后的创建请求
+type CloneWebsitePlan struct {
+	SourceClientID  int                   `json:"source_client_id"`
+	SourceBrandCode string                `json:"source_brand_code"`
+	SourceTypeID    uint                  `json:"-"`
+	BrandCode       string                `json:"brand_code"`
+	Request         *CreateWebsiteRequest `json:"request"`
+}
+
+// BuildCloneRequest 读取源网站配置并合并覆盖，生成创建网站请求
+func (s *WebsiteService) BuildCloneRequest(sourceClientID int, req *CloneWebsiteRequest) (*CloneWebsitePlan, error) {
+	source, err := s.GetWebsiteConfig(sourceClientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source website config: %v", err)
+	}
+
+	var sourceClient models.Client
+	if err := s.db.Preload("Brand").First(&sourceClient, sourceClientID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find source client: %v", err)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = sourceClient.Host
+	}
+	if req.BrandCode == sourceClient.Brand.Code && host == sourceClient.Host {
+		return nil, fmt.Errorf("target brand and host are the same as the source website")
+	}
+
+	// 源网站配置转换为创建请求的结构
+	sections := map[string]interface{}{
+		"basic_info":    map[string]interface{}{"host": host},
+		"base_config":   source["base_config"],
+		"common_config": source["common_config"],
+		"pay_config":    source["pay_config"],
+		"ui_config":     source["ui_config"],
+	}
+	if novelConfig, ok := source["novel_config"].(models.NovelConfig); ok && novelConfig.ID != 0 {
+		sections["novel_config"] = novelConfig
+	}
+
+	// 源品牌存在额外客户端（如tth5对应的tt端）时一并克隆其基础配置
+	if extraHost := utils.GetPlatformRegistry().ExtraHost(sourceClient.Host); extraHost != "" {
+		var extraClient models.Client
+		var extraBaseConfig models.BaseConfig
+		if err := s.db.Where("brand_id = ? AND host = ?", sourceClient.BrandID, extraHost).First(&extraClient).Error; err == nil {
+			if err := s.db.Where("client_id = ?", extraClient.ID).First(&extraBaseConfig).Error; err == nil {
+				sections["extra_base_config"] = extraBaseConfig
+			}
+		}
+	}
+
+	merged, err := toJSONMap(sections)
+	if err != nil {
+		return nil, err
+	}
+	mergeOverrides(merged, req.Overrides)
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal clone request: %v", err)
+	}
+	var createReq CreateWebsiteRequest
+	if err := json.Unmarshal(data, &createReq); err != nil {
+		return nil, fmt.Errorf("invalid overrides: %v", err)
+	}
+	createReq.StaticTemplate = sourceClient.Brand.Code
+
+	return &CloneWebsitePlan{
+		SourceClientID:  sourceClientID,
+		SourceBrandCode: sourceClient.Brand.Code,
+		SourceTypeID:    sourceClient.Brand.TypeID,
+		BrandCode:       req.BrandCode,
+		Request:         &createReq,
+	}, nil
+}
+
+// CloneWebsite 按克隆计划创建网站（目标品牌不存在时在同一事务中创建），复制源品牌的static图片目录
+func (s *WebsiteService) CloneWebsite(plan *CloneWebsitePlan, progressCallback func(int, string, string)) (map[string]interface{}, error) {
+	release, err := lockBrandFiles(s.config, "CloneWebsite(brand="+plan.BrandCode+")", true, plan.BrandCode)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
+
+	var result map[string]interface{}
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		var brand models.Brand
+		err := ctx.DB.Where("code = ?", plan.BrandCode).First(&brand).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			brand = models.Brand{Code: plan.BrandCode, TypeID: plan.SourceTypeID}
+			if err := ctx.DB.Create(&brand).Error; err != nil {
+				return fmt.Errorf("failed to create brand: %v", err)
+			}
+			log.Printf("📝 克隆网站时创建品牌: %s", plan.BrandCode)
+		} else if err != nil {
+			return fmt.Errorf("failed to find brand: %v", err)
+		}
+		plan.Request.BasicInfo.BrandID = brand.ID
+
+		created, err := s.createWebsiteInTransaction(ctx, plan.Request, progressCallback)
+		if err != nil {
+			return err
+		}
+
+		result = map[string]interface{}{
+			"client_id":        created.Client.ID,
+			"brand_id":         brand.ID,
+			"source_client_id": plan.SourceClientID,
+		}
+		if created.ExtraClient != nil {
+			result["extra_client_id"] = created.ExtraClient.ID
+		}
+		return nil
+	}, progressCallback)
+
+	if err != nil {
+		if progressCallback != nil {
+			progressCallback(0, "操作失败", "网站克隆失败，已进行回滚操作")
+		}
+		return nil, err
+	}
+
+	log.Printf("✅ 网站克隆成功: %s -> %s-%s", plan.SourceBrandCode, plan.Request.BasicInfo.Host, plan.BrandCode)
+	return result, nil
+}
+
+// toJSONMap 通过JSON转换为map，便于按字段名合并
+func toJSONMap(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %v", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal value: %v", err)
+	}
+	return result, nil
+}
+
+// mergeOverrides 将稀疏覆盖递归合并到目标map中（对象逐字段合并，其他值直接替换）
+func mergeOverrides(target, overrides map[string]interface{}) {
+	for key, value := range overrides {
+		overrideMap, isMap := value.(map[string]interface{})
+		targetMap, targetIsMap := target[key].(map[string]interface{})
+		if isMap && targetIsMap {
+			mergeOverrides(targetMap, overrideMap)
+			continue
+		}
+		target[key] = value
+	}
+}
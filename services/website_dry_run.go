@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"brand-config-api/config"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+	"brand-config-api/utils/rollback"
+)
+
+// WebsiteFileChange 预演模式下单个文件的变更
+type WebsiteFileChange struct {
+	Path   string `json:"path"`   // 相对于BasePath的路径
+	Status string `json:"status"` // added/modified/deleted
+	Binary bool   `json:"binary"`
+	Diff   string `json:"diff"`
+}
+
+// WebsiteDBRow 预演模式下将要插入的数据库记录
+type WebsiteDBRow struct {
+	Table string      `json:"table"`
+	Row   interface{} `json:"row"`
+}
+
+// WebsiteDryRunResult 网站创建预演结果
+type WebsiteDryRunResult struct {
+	Files  []WebsiteFileChange `json:"files"`
+	DBRows []WebsiteDBRow      `json:"db_rows"`
+	Diff   string              `json:"diff"` // 所有文件的统一差异
+}
+
+// errDryRunRollback 预演完成后用于触发回滚的错误
+var errDryRunRollback = errors.New("dry run completed, rolling back")
+
+// PreviewCreateWebsite 预演网站创建：在暂存目录中执行完整流程后回滚，返回文件差异和将插入的数据库记录
+func (s *WebsiteService) PreviewCreateWebsite(req *CreateWebsiteRequest) (*WebsiteDryRunResult, error) {
+	var brand models.Brand
+	if err := s.db.First(&brand, req.BasicInfo.BrandID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find brand: %v", err)
+	}
+
+	// 1. 准备暂存目录，复制本次创建会读写的文件
+	overlayDir, err := os.MkdirTemp("", "website-dry-run-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create overlay directory: %v", err)
+	}
+	defer os.RemoveAll(overlayDir)
+
+	// 复制期间持有与真实创建相同的工作区锁，避免读到其他任务写了一半的文件
+	overlayConfig := s.config.WithBasePath(overlayDir)
+	release, err := lockBrandFiles(s.config, "PreviewCreateWebsite(brand="+brand.Code+")", true, brand.Code)
+	if err != nil {
+		return nil, err
+	}
+	err = s.stageOverlay(overlayConfig, brand.Code, req.StaticTemplate)
+	release()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage overlay: %v", err)
+	}
+	log.Printf("🧪 预演暂存目录已准备: %s", overlayDir)
+
+	before, err := readOverlayFiles(overlayDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 在暂存目录中执行完整流程，完成后强制回滚数据库事务
+	overlayService := &WebsiteService{
+		db:          s.db,
+		config:      overlayConfig,
+		fileService: newFileServiceWithConfig(overlayConfig),
+	}
+
+	var result *WebsiteDryRunResult
+	rollbackManager := rollback.NewRollbackManager(s.db, overlayConfig)
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		created, err := overlayService.createWebsiteInTransaction(ctx, req, nil)
+		if err != nil {
+			return err
+		}
+
+		after, err := readOverlayFiles(overlayDir)
+		if err != nil {
+			return err
+		}
+
+		result = &WebsiteDryRunResult{
+			Files:  diffOverlayFiles(before, after),
+			DBRows: created.dbRows(),
+		}
+		return errDryRunRollback
+	}, nil)
+
+	if result == nil {
+		return nil, err
+	}
+
+	var diff strings.Builder
+	for _, file := range result.Files {
+		diff.WriteString(file.Diff)
+	}
+	result.Diff = diff.String()
+
+	log.Printf("✅ 网站创建预演完成: %d 个文件变更, %d 条数据库记录", len(result.Files), len(result.DBRows))
+	return result, nil
+}
+
+// stageOverlay 将网站创建涉及的文件和目录从真实目录复制到暂存目录
+func (s *WebsiteService) stageOverlay(overlayConfig *config.Config, brandCode, staticTemplate string) error {
+	if staticTemplate == "" {
+		staticTemplate = defaultStaticTemplate
+	}
+
+	// 暂存目录中保持与真实目录相同的目录结构
+	for _, dir := range []string{
+		overlayConfig.File.BaseConfigsDir,
+		overlayConfig.File.CommonConfigsDir,
+		overlayConfig.File.PayConfigsDir,
+		overlayConfig.File.UIConfigsDir,
+		overlayConfig.File.LocalConfigsDir,
+		overlayConfig.File.PrebuildDir,
+		overlayConfig.File.StaticDir,
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	paths := []string{
+		s.config.File.ViteConfigFile,
+		s.config.File.PackageFile,
+		filepath.Join(s.config.File.BaseConfigsDir, brandCode+".js"),
+		filepath.Join(s.config.File.CommonConfigsDir, brandCode+".js"),
+		filepath.Join(s.config.File.PayConfigsDir, brandCode+".js"),
+		filepath.Join(s.config.File.UIConfigsDir, brandCode+".js"),
+		filepath.Join(s.config.File.LocalConfigsDir, "novelConfig.js"),
+		s.config.GetPrebuildPath(brandCode),
+		s.config.GetStaticPath(staticTemplate),
+		s.config.GetStaticPath(brandCode),
+	}
+
+	fileUtils := utils.NewFileUtils()
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.config.File.BasePath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(overlayConfig.File.BasePath, rel)
+
+		if stat.IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			if err := fileUtils.CopyDirectory(path, target); err != nil {
+				return fmt.Errorf("failed to copy directory %s: %v", path, err)
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := fileUtils.CopyFile(path, target); err != nil {
+				return fmt.Errorf("failed to copy file %s: %v", path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readOverlayFiles 读取暂存目录下所有文件内容（key为相对路径）
+func readOverlayFiles(root string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overlay files: %v", err)
+	}
+	return files, nil
+}
+
+// diffOverlayFiles 比较执行前后的暂存目录，生成每个文件的统一差异
+func diffOverlayFiles(before, after map[string][]byte) []WebsiteFileChange {
+	paths := make(map[string]bool)
+	for path := range before {
+		paths[path] = true
+	}
+	for path := range after {
+		paths[path] = true
+	}
+
+	var sortedPaths []string
+	for path := range paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	changes := make([]WebsiteFileChange, 0)
+	for _, path := range sortedPaths {
+		oldContent, inBefore := before[path]
+		newContent, inAfter := after[path]
+		if inBefore && inAfter && bytes.Equal(oldContent, newContent) {
+			continue
+		}
+
+		change := WebsiteFileChange{Path: path, Status: "modified"}
+		oldName, newName := "a/"+path, "b/"+path
+		if !inBefore {
+			change.Status = "added"
+			oldName = "/dev/null"
+		}
+		if !inAfter {
+			change.Status = "deleted"
+			newName = "/dev/null"
+		}
+
+		if bytes.IndexByte(oldContent, 0) >= 0 || bytes.IndexByte(newContent, 0) >= 0 {
+			change.Binary = true
+			change.Diff = fmt.Sprintf("Binary files %s and %s differ\n", oldName, newName)
+		} else {
+			change.Diff = utils.UnifiedDiff(oldName, newName, string(oldContent), string(newContent), 3)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// dbRows 列出网站创建过程中插入的数据库记录
+func (c *createdWebsite) dbRows() []WebsiteDBRow {
+	rows := []WebsiteDBRow{
+		{Table: "clients", Row: c.Client},
+		{Table: "base_configs", Row: c.BaseConfig},
+		{Table: "common_configs", Row: c.CommonConfig},
+		{Table: "pay_configs", Row: c.PayConfig},
+		{Table: "ui_configs", Row: c.UIConfig},
+	}
+
+	if c.ExtraClient != nil {
+		rows = append(rows, WebsiteDBRow{Table: "clients", Row: c.ExtraClient})
+	}
+	if c.ExtraBaseConfig != nil {
+		rows = append(rows, WebsiteDBRow{Table: "base_configs", Row: c.ExtraBaseConfig})
+	}
+	if c.NovelConfig != nil {
+		rows = append(rows, WebsiteDBRow{Table: "novel_configs", Row: c.NovelConfig})
+	}
+	return rows
+}
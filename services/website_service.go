@@ -1,14 +1,17 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"brand-config-api/config"
 	"brand-config-api/database"
 	"brand-config-api/models"
+	"brand-config-api/utils"
 	"brand-config-api/utils/rollback"
 
 	"gorm.io/gorm"
@@ -23,6 +26,9 @@ type CreateWebsiteRequest struct {
 	PayConfig       PayConfigRequest    `json:"pay_config"`
 	UIConfig        UIConfigRequest     `json:"ui_config"`
 	NovelConfig     *NovelConfigRequest `json:"novel_config"`
+
+	// 复制静态图片目录时使用的源品牌（克隆网站时为源品牌），为空时使用img-jinse模板
+	StaticTemplate string `json:"-"`
 }
 
 type BasicInfoRequest struct {
@@ -76,6 +82,18 @@ type NovelConfigRequest struct {
 	TTLoginCallbackDomain string `json:"tt_login_callback_domain"`
 }
 
+// createdWebsite 网站创建过程中写入的数据库记录
+type createdWebsite struct {
+	Client          *models.Client
+	BaseConfig      *models.BaseConfig
+	CommonConfig    *models.CommonConfig
+	PayConfig       *models.PayConfig
+	UIConfig        *models.UIConfig
+	NovelConfig     *models.NovelConfig
+	ExtraClient     *models.Client
+	ExtraBaseConfig *models.BaseConfig
+}
+
 // WebsiteService 网站服务
 type WebsiteService struct {
 	db          *gorm.DB
@@ -94,6 +112,13 @@ func NewWebsiteService() *WebsiteService {
 
 // CreateWebsite 创建网站（带进度回调）
 func (s *WebsiteService) CreateWebsite(req *CreateWebsiteRequest, progressCallback func(int, string, string)) (map[string]interface{}, error) {
+	// 创建网站会修改项目共享文件和品牌目录，需要独占锁
+	release, err := lockBrandIDFiles(s.db, s.config, req.BasicInfo.BrandID, "CreateWebsite")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// 创建回滚管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
@@ -108,255 +133,274 @@ func (s *WebsiteService) CreateWebsite(req *CreateWebsiteRequest, progressCallba
 		}
 	}()
 
-	err := rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
-		// 步骤1: 验证数据
-		if progressCallback != nil {
-			progressCallback(5, "验证数据...", "开始验证请求参数")
+	err = rollbackManager.ExecuteWithTransaction(func(ctx *rollback.TransactionContext) error {
+		created, err := s.createWebsiteInTransaction(ctx, req, progressCallback)
+		if err != nil {
+			return err
 		}
 
-		if err := s.validateRequest(req); err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "验证失败", "配置验证失败: "+err.Error())
-			}
-			return fmt.Errorf("validation failed: %v", err)
+		// 构建返回结果
+		result = map[string]interface{}{
+			"client_id":        created.Client.ID,
+			"base_config_id":   created.BaseConfig.ID,
+			"common_config_id": created.CommonConfig.ID,
+			"pay_config_id":    created.PayConfig.ID,
+			"ui_config_id":     created.UIConfig.ID,
 		}
 
-		if progressCallback != nil {
-			progressCallback(10, "验证数据...", "数据验证通过")
+		if created.NovelConfig != nil {
+			result["novel_config_id"] = created.NovelConfig.ID
 		}
 
-		// 步骤2: 创建客户端
-		if progressCallback != nil {
-			progressCallback(15, "创建网站Client...", "正在创建网站客户端")
+		if created.ExtraClient != nil {
+			result["extra_client_id"] = created.ExtraClient.ID
 		}
 
-		client, err := s.createClient(ctx.DB, req.BasicInfo)
-		if err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "创建失败", "客户端创建失败: "+err.Error())
-			}
-			return fmt.Errorf("failed to create client: %v", err)
+		if created.ExtraBaseConfig != nil {
+			result["extra_base_config_id"] = created.ExtraBaseConfig.ID
 		}
 
-		if progressCallback != nil {
-			progressCallback(20, "创建网站Client...", "客户端创建成功")
-		}
+		return nil
+	}, progressCallback)
 
-		// 步骤3: 创建基础配置
+	if err != nil {
+		// 如果发生错误，通知进度回调
 		if progressCallback != nil {
-			progressCallback(25, "创建BaseConfig...", "开始创建基础配置")
+			progressCallback(0, "操作失败", "网站创建失败，已进行回滚操作")
 		}
+		return nil, err
+	}
 
-		baseConfigService := NewBaseConfigService()
-		baseConfig, err := baseConfigService.CreateBaseConfigWithFile(ctx, req.BaseConfig, int(client.ID), client.Brand.Code, client.Host)
-		if err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "创建失败", "基础配置创建失败: "+err.Error())
-			}
-			return fmt.Errorf("failed to create base config: %v", err)
-		}
+	return result, nil
+}
 
-		if progressCallback != nil {
-			progressCallback(30, "创建BaseConfig...", "基础配置创建成功")
-		}
+// createWebsiteInTransaction 在事务中执行网站创建的各个步骤（不管理事务）
+func (s *WebsiteService) createWebsiteInTransaction(ctx *rollback.TransactionContext, req *CreateWebsiteRequest, progressCallback func(int, string, string)) (*createdWebsite, error) {
+	// 步骤1: 验证数据
+	if progressCallback != nil {
+		progressCallback(5, "验证数据...", "开始验证请求参数")
+	}
 
-		// 步骤4: 创建通用配置
+	if err := s.validateRequest(req); err != nil {
 		if progressCallback != nil {
-			progressCallback(35, "创建CommonConfig...", "开始创建通用配置")
+			progressCallback(0, "验证失败", "配置验证失败: "+err.Error())
 		}
+		return nil, fmt.Errorf("validation failed: %v", err)
+	}
 
-		commonConfigService := NewCommonConfigService()
-		commonConfig, err := commonConfigService.CreateCommonConfigWithFile(ctx, req.CommonConfig, int(client.ID), client.Brand.Code, client.Host)
-		if err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "创建失败", "通用配置创建失败: "+err.Error())
-			}
-			return fmt.Errorf("failed to create common config: %v", err)
-		}
+	if progressCallback != nil {
+		progressCallback(10, "验证数据...", "数据验证通过")
+	}
 
-		if progressCallback != nil {
-			progressCallback(40, "创建CommonConfig...", "通用配置创建成功")
-		}
+	// 步骤2: 创建客户端
+	if progressCallback != nil {
+		progressCallback(15, "创建网站Client...", "正在创建网站客户端")
+	}
 
-		// 步骤5: 创建支付配置
+	client, err := s.createClient(ctx.DB, req.BasicInfo)
+	if err != nil {
 		if progressCallback != nil {
-			progressCallback(45, "创建PayConfig...", "开始创建支付配置")
+			progressCallback(0, "创建失败", "客户端创建失败: "+err.Error())
 		}
+		return nil, fmt.Errorf("failed to create client: %v", err)
+	}
 
-		payConfigService := NewPayConfigService()
-		payConfig, err := payConfigService.CreatePayConfigWithFile(ctx, req.PayConfig, int(client.ID), client.Brand.Code, client.Host)
-		if err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "创建失败", "支付配置创建失败: "+err.Error())
-			}
-			return fmt.Errorf("failed to create pay config: %v", err)
-		}
+	if progressCallback != nil {
+		progressCallback(20, "创建网站Client...", "客户端创建成功")
+	}
 
+	// 步骤3: 创建基础配置
+	if progressCallback != nil {
+		progressCallback(25, "创建BaseConfig...", "开始创建基础配置")
+	}
+
+	// 配置服务与网站服务共用同一份配置，预演模式下所有文件都写入暂存目录
+	baseConfigService := &BaseConfigService{db: s.db, config: s.config}
+	baseConfig, err := baseConfigService.CreateBaseConfigWithFile(ctx, req.BaseConfig, int(client.ID), client.Brand.Code, client.Host)
+	if err != nil {
 		if progressCallback != nil {
-			progressCallback(50, "创建PayConfig...", "支付配置创建成功")
+			progressCallback(0, "创建失败", "基础配置创建失败: "+err.Error())
 		}
+		return nil, fmt.Errorf("failed to create base config: %v", err)
+	}
+
+	if progressCallback != nil {
+		progressCallback(30, "创建BaseConfig...", "基础配置创建成功")
+	}
+
+	// 步骤4: 创建通用配置
+	if progressCallback != nil {
+		progressCallback(35, "创建CommonConfig...", "开始创建通用配置")
+	}
 
-		// 步骤6: 创建UI配置
+	commonConfigService := &CommonConfigService{db: s.db, config: s.config}
+	commonConfig, err := commonConfigService.CreateCommonConfigWithFile(ctx, req.CommonConfig, int(client.ID), client.Brand.Code, client.Host)
+	if err != nil {
 		if progressCallback != nil {
-			progressCallback(55, "创建UIConfig...", "开始创建UI配置")
+			progressCallback(0, "创建失败", "通用配置创建失败: "+err.Error())
 		}
+		return nil, fmt.Errorf("failed to create common config: %v", err)
+	}
 
-		uiConfigService := NewUIConfigService()
-		uiConfig, err := uiConfigService.CreateUIConfigWithFile(ctx, req.UIConfig, int(client.ID), client.Brand.Code, client.Host)
-		if err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "创建失败", "UI配置创建失败: "+err.Error())
-			}
-			return fmt.Errorf("failed to create UI config: %v", err)
-		}
+	if progressCallback != nil {
+		progressCallback(40, "创建CommonConfig...", "通用配置创建成功")
+	}
+
+	// 步骤5: 创建支付配置
+	if progressCallback != nil {
+		progressCallback(45, "创建PayConfig...", "开始创建支付配置")
+	}
 
+	payConfigService := &PayConfigService{db: s.db, config: s.config}
+	payConfig, err := payConfigService.CreatePayConfigWithFile(ctx, req.PayConfig, int(client.ID), client.Brand.Code, client.Host)
+	if err != nil {
 		if progressCallback != nil {
-			progressCallback(60, "创建UIConfig...", "UI配置创建成功")
+			progressCallback(0, "创建失败", "支付配置创建失败: "+err.Error())
 		}
+		return nil, fmt.Errorf("failed to create pay config: %v", err)
+	}
 
-		// 步骤7: 创建额外客户端和基础配置（如果需要）
-		var extraClient *models.Client
-		var extraBaseConfig *models.BaseConfig
-		if req.ExtraBaseConfig != nil {
-			if progressCallback != nil {
-				progressCallback(65, "创建额外客户端和基础配置...", "正在配置额外客户端")
-			}
+	if progressCallback != nil {
+		progressCallback(50, "创建PayConfig...", "支付配置创建成功")
+	}
 
-			// 确定额外的host类型
-			var extraHost string
-			if client.Host == "tth5" {
-				extraHost = "tt"
-			} else if client.Host == "ksh5" {
-				extraHost = "ks"
-			}
+	// 步骤6: 创建UI配置
+	if progressCallback != nil {
+		progressCallback(55, "创建UIConfig...", "开始创建UI配置")
+	}
 
-			if extraHost != "" {
-				// 创建额外的客户端
-				extraClient, err = s.createExtraClient(ctx.DB, client.Brand.ID, extraHost)
-				if err != nil {
-					if progressCallback != nil {
-						progressCallback(0, "创建失败", "额外客户端创建失败: "+err.Error())
-					}
-					return fmt.Errorf("failed to create extra client: %v", err)
-				}
+	uiConfigService := &UIConfigService{db: s.db, config: s.config}
+	uiConfig, err := uiConfigService.CreateUIConfigWithFile(ctx, req.UIConfig, int(client.ID), client.Brand.Code, client.Host)
+	if err != nil {
+		if progressCallback != nil {
+			progressCallback(0, "创建失败", "UI配置创建失败: "+err.Error())
+		}
+		return nil, fmt.Errorf("failed to create UI config: %v", err)
+	}
 
-				// 创建额外的基础配置（包含文件生成）
-				extraBaseConfig, err = baseConfigService.CreateBaseConfigWithFile(ctx, *req.ExtraBaseConfig, int(extraClient.ID), client.Brand.Code, extraHost)
-				if err != nil {
-					if progressCallback != nil {
-						progressCallback(0, "创建失败", "额外基础配置创建失败: "+err.Error())
-					}
-					return fmt.Errorf("failed to create extra base config: %v", err)
-				}
-			}
+	if progressCallback != nil {
+		progressCallback(60, "创建UIConfig...", "UI配置创建成功")
+	}
 
-			if progressCallback != nil {
-				progressCallback(70, "创建额外客户端BaseConfig...", "额外客户端"+extraHost+"基础配置创建成功")
-			}
+	// 步骤7: 创建额外客户端和基础配置（如果需要）
+	var extraClient *models.Client
+	var extraBaseConfig *models.BaseConfig
+	if req.ExtraBaseConfig != nil {
+		if progressCallback != nil {
+			progressCallback(65, "创建额外客户端和基础配置...", "正在配置额外客户端")
 		}
 
-		// 步骤8: 创建小说配置（如果存在）
-		var novelConfig *models.NovelConfig
-		if req.NovelConfig != nil {
-			if progressCallback != nil {
-				progressCallback(75, "创建小说特有配置NovelConfig...", "开始创建小说特有配置")
-			}
+		// 确定额外的host类型（由端类型注册表声明）
+		extraHost := utils.GetPlatformRegistry().ExtraHost(client.Host)
 
-			novelConfigService := NewNovelConfigService()
-			novelConfig, err = novelConfigService.CreateNovelConfigWithFile(ctx, *req.NovelConfig, int(client.ID), client.Brand.Code, client.Host)
+		if extraHost != "" {
+			// 创建额外的客户端
+			extraClient, err = s.createExtraClient(ctx.DB, client.Brand.ID, extraHost)
 			if err != nil {
 				if progressCallback != nil {
-					progressCallback(0, "创建失败", "小说配置创建失败: "+err.Error())
+					progressCallback(0, "创建失败", "额外客户端创建失败: "+err.Error())
 				}
-				return fmt.Errorf("failed to create novel config: %v", err)
-			}
-
-			if progressCallback != nil {
-				progressCallback(80, "创建小说特有配置NovelConfig...", "小说特有配置创建成功")
+				return nil, fmt.Errorf("failed to create extra client: %v", err)
 			}
-		}
-
-		// 步骤9: 更新项目配置文件
-		if progressCallback != nil {
-			progressCallback(85, "更新项目配置package.json、vite.config.js...", "开始更新项目配置文件")
-		}
 
-		if err := s.fileService.UpdateProjectConfigs(client.Brand.Code, client.Host, commonConfig.ScriptBase, baseConfig.AppName, ctx.Files); err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "更新失败", "项目配置文件更新失败: "+err.Error())
+			// 创建额外的基础配置（包含文件生成）
+			extraBaseConfig, err = baseConfigService.CreateBaseConfigWithFile(ctx, *req.ExtraBaseConfig, int(extraClient.ID), client.Brand.Code, extraHost)
+			if err != nil {
+				if progressCallback != nil {
+					progressCallback(0, "创建失败", "额外基础配置创建失败: "+err.Error())
+				}
+				return nil, fmt.Errorf("failed to create extra base config: %v", err)
 			}
-			return fmt.Errorf("failed to update project configs: %v", err)
 		}
 
 		if progressCallback != nil {
-			progressCallback(90, "更新项目配置...", "项目配置文件更新成功")
+			progressCallback(70, "创建额外客户端BaseConfig...", "额外客户端"+extraHost+"基础配置创建成功")
 		}
+	}
 
-		// 步骤10: 创建prebuild文件
+	// 步骤8: 创建小说配置（如果存在）
+	var novelConfig *models.NovelConfig
+	if req.NovelConfig != nil {
 		if progressCallback != nil {
-			progressCallback(92, "创建预构建文件Prebuild...", "开始创建预构建文件")
+			progressCallback(75, "创建小说特有配置NovelConfig...", "开始创建小说特有配置")
 		}
 
-		if err := s.fileService.CreatePrebuildFiles(client.Brand.Code, baseConfig.AppName, client.Host, ctx.Files); err != nil {
+		novelConfigService := &NovelConfigService{db: s.db, config: s.config}
+		novelConfig, err = novelConfigService.CreateNovelConfigWithFile(ctx, *req.NovelConfig, int(client.ID), client.Brand.Code, client.Host)
+		if err != nil {
 			if progressCallback != nil {
-				progressCallback(0, "创建失败", "预构建文件创建失败: "+err.Error())
+				progressCallback(0, "创建失败", "小说配置创建失败: "+err.Error())
 			}
-			return fmt.Errorf("failed to create prebuild files: %v", err)
+			return nil, fmt.Errorf("failed to create novel config: %v", err)
 		}
 
 		if progressCallback != nil {
-			progressCallback(95, "创建预构建文件...", "预构建文件创建成功")
-		}
-
-		// 步骤11: 创建static图片目录
-		if progressCallback != nil {
-			progressCallback(98, "创建静态资源Static...", "开始创建静态资源目录")
+			progressCallback(80, "创建小说特有配置NovelConfig...", "小说特有配置创建成功")
 		}
+	}
 
-		if err := s.fileService.CreateStaticImageDirectory(client.Brand.Code, ctx.Files); err != nil {
-			if progressCallback != nil {
-				progressCallback(0, "创建失败", "静态资源目录创建失败: "+err.Error())
-			}
-			return fmt.Errorf("failed to create static image directory: %v", err)
-		}
+	// 步骤9: 更新项目配置文件
+	if progressCallback != nil {
+		progressCallback(85, "更新项目配置package.json、vite.config.js...", "开始更新项目配置文件")
+	}
 
+	if err := s.fileService.UpdateProjectConfigs(client.Brand.Code, client.Host, commonConfig.ScriptBase, baseConfig.AppName, ctx.Files); err != nil {
 		if progressCallback != nil {
-			progressCallback(100, "创建静态资源...", "静态资源目录创建成功")
+			progressCallback(0, "更新失败", "项目配置文件更新失败: "+err.Error())
 		}
+		return nil, fmt.Errorf("failed to update project configs: %v", err)
+	}
 
-		// 构建返回结果
-		result = map[string]interface{}{
-			"client_id":        client.ID,
-			"base_config_id":   baseConfig.ID,
-			"common_config_id": commonConfig.ID,
-			"pay_config_id":    payConfig.ID,
-			"ui_config_id":     uiConfig.ID,
-		}
+	if progressCallback != nil {
+		progressCallback(90, "更新项目配置...", "项目配置文件更新成功")
+	}
 
-		if novelConfig != nil {
-			result["novel_config_id"] = novelConfig.ID
-		}
+	// 步骤10: 创建prebuild文件
+	if progressCallback != nil {
+		progressCallback(92, "创建预构建文件Prebuild...", "开始创建预构建文件")
+	}
 
-		if extraClient != nil {
-			result["extra_client_id"] = extraClient.ID
+	if err := s.fileService.CreatePrebuildFiles(client.Brand.Code, baseConfig.AppName, client.Host, ctx.Files); err != nil {
+		if progressCallback != nil {
+			progressCallback(0, "创建失败", "预构建文件创建失败: "+err.Error())
 		}
+		return nil, fmt.Errorf("failed to create prebuild files: %v", err)
+	}
 
-		if extraBaseConfig != nil {
-			result["extra_base_config_id"] = extraBaseConfig.ID
-		}
+	if progressCallback != nil {
+		progressCallback(95, "创建预构建文件...", "预构建文件创建成功")
+	}
 
-		return nil
-	}, progressCallback)
+	// 步骤11: 创建static图片目录
+	if progressCallback != nil {
+		progressCallback(98, "创建静态资源Static...", "开始创建静态资源目录")
+	}
 
-	if err != nil {
-		// 如果发生错误，通知进度回调
+	staticTemplate := req.StaticTemplate
+	if staticTemplate == "" {
+		staticTemplate = defaultStaticTemplate
+	}
+	if err := s.fileService.CreateStaticImageDirectoryFrom(staticTemplate, client.Brand.Code, ctx.Files); err != nil {
 		if progressCallback != nil {
-			progressCallback(0, "操作失败", "网站创建失败，已进行回滚操作")
+			progressCallback(0, "创建失败", "静态资源目录创建失败: "+err.Error())
 		}
-		return nil, err
+		return nil, fmt.Errorf("failed to create static image directory: %v", err)
 	}
 
-	return result, nil
+	if progressCallback != nil {
+		progressCallback(100, "创建静态资源...", "静态资源目录创建成功")
+	}
+
+	return &createdWebsite{
+		Client:          client,
+		BaseConfig:      baseConfig,
+		CommonConfig:    commonConfig,
+		PayConfig:       payConfig,
+		UIConfig:        uiConfig,
+		NovelConfig:     novelConfig,
+		ExtraClient:     extraClient,
+		ExtraBaseConfig: extraBaseConfig,
+	}, nil
 }
 
 // validateRequest 验证请求参数
@@ -369,8 +413,8 @@ func (s *WebsiteService) validateRequest(req *CreateWebsiteRequest) error {
 	}
 
 	// 2. 验证host类型（防止注入攻击）
-	validHosts := map[string]bool{"h5": true, "tth5": true, "ksh5": true}
-	if !validHosts[req.BasicInfo.Host] {
+	registry := utils.GetPlatformRegistry()
+	if !registry.IsCreatableHost(req.BasicInfo.Host) {
 		return fmt.Errorf("invalid host type")
 	}
 
@@ -392,17 +436,10 @@ func (s *WebsiteService) validateRequest(req *CreateWebsiteRequest) error {
 		}
 	}
 
-	// 4. 验证小说配置（tth5端是必填的）
-	if req.BasicInfo.Host == "tth5" {
-		if req.NovelConfig == nil {
-			return fmt.Errorf("novel_config is required for tth5 host")
-		}
-		if req.NovelConfig.TTJumpHomeUrl == "" {
-			return fmt.Errorf("tt_jump_home_url is required for tth5 host")
-		}
-		if req.NovelConfig.TTLoginCallbackDomain == "" {
-			return fmt.Errorf("tt_login_callback_domain is required for tth5 host")
-		}
+	// 4. 验证端类型声明的必填配置块和字段（如tth5端的小说配置）
+	platform, _ := registry.Get(req.BasicInfo.Host)
+	if err := s.validatePlatformRules(req, platform); err != nil {
+		return err
 	}
 
 	// 5. 颜色格式验证已移除，支持任何格式的颜色值
@@ -411,6 +448,46 @@ func (s *WebsiteService) validateRequest(req *CreateWebsiteRequest) error {
 	return nil
 }
 
+// validatePlatformRules 按端类型注册表中的规则验证必填配置块和字段
+func (s *WebsiteService) validatePlatformRules(req *CreateWebsiteRequest, platform models.Platform) error {
+	if len(platform.RequiredSections) == 0 && len(platform.RequiredFields) == 0 {
+		return nil
+	}
+
+	// 转换为map，按json字段名检查
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+	var sections map[string]interface{}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return fmt.Errorf("failed to unmarshal request: %v", err)
+	}
+
+	for _, section := range platform.RequiredSections {
+		if sections[section] == nil {
+			return fmt.Errorf("%s is required for %s host", section, platform.Code)
+		}
+	}
+
+	for _, field := range platform.RequiredFields {
+		parts := strings.SplitN(field, ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		sectionData, ok := sections[parts[0]].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is required for %s host", parts[0], platform.Code)
+		}
+		value, exists := sectionData[parts[1]]
+		if !exists || value == nil || value == "" || value == float64(0) {
+			return fmt.Errorf("%s is required for %s host", parts[1], platform.Code)
+		}
+	}
+
+	return nil
+}
+
 // GetWebsiteConfig 获取网站完整配置
 func (s *WebsiteService) GetWebsiteConfig(clientID int) (map[string]interface{}, error) {
 	// 查询Client信息
@@ -495,6 +572,13 @@ func (s *WebsiteService) createExtraClient(tx *gorm.DB, brandID int, extraHost s
 
 // DeleteWebsite 删除网站（原子操作）
 func (s *WebsiteService) DeleteWebsite(clientID int) error {
+	// 删除网站会修改项目共享文件和品牌目录，需要独占锁
+	release, err := lockClientFiles(s.db, s.config, clientID, "DeleteWebsite", true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// 创建回滚管理器
 	rollbackManager := rollback.NewRollbackManager(s.db, s.config)
 
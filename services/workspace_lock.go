@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"brand-config-api/config"
+	"brand-config-api/models"
+	"brand-config-api/utils"
+
+	"gorm.io/gorm"
+)
+
+// acquireWorkspaceLock 获取工作区锁，等待超时由 WORKSPACE_LOCK_TIMEOUT 配置（秒）
+func acquireWorkspaceLock(cfg *config.Config, owner string, keys ...string) (func(), error) {
+	timeout := time.Duration(cfg.WorkspaceLockTimeout) * time.Second
+	release, err := utils.GetWorkspaceLockManager().Acquire(owner, timeout, keys...)
+	if err != nil {
+		log.Printf("⏳ 获取工作区锁失败: %s - %v", owner, err)
+		return nil, err
+	}
+	return release, nil
+}
+
+// lockBrandFiles 锁定品牌相关文件，withProject 为 true 时同时锁定项目共享文件
+func lockBrandFiles(cfg *config.Config, owner string, withProject bool, brandCodes ...string) (func(), error) {
+	keys := make([]string, 0, len(brandCodes)+1)
+	for _, brandCode := range brandCodes {
+		if brandCode != "" {
+			keys = append(keys, utils.BrandLockKey(brandCode))
+		}
+	}
+	if withProject {
+		keys = append(keys, utils.LockKeyProject)
+	}
+	return acquireWorkspaceLock(cfg, owner, keys...)
+}
+
+// lockClientFiles 锁定客户端所属品牌的文件，withProject 为 true 时同时锁定项目共享文件
+func lockClientFiles(db *gorm.DB, cfg *config.Config, clientID int, owner string, withProject bool) (func(), error) {
+	var client models.Client
+	if err := db.Preload("Brand").First(&client, clientID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find client: %v", err)
+	}
+	return lockBrandFiles(cfg, fmt.Sprintf("%s(client=%d)", owner, clientID), withProject, client.Brand.Code)
+}
+
+// lockBrandIDFiles 按品牌ID锁定品牌文件和项目共享文件（品牌不存在时只锁定项目共享文件）
+func lockBrandIDFiles(db *gorm.DB, cfg *config.Config, brandID int, owner string) (func(), error) {
+	var brand models.Brand
+	if err := db.First(&brand, brandID).Error; err != nil {
+		return lockBrandFiles(cfg, owner, true)
+	}
+	return lockBrandFiles(cfg, fmt.Sprintf("%s(brand=%s)", owner, brand.Code), true, brand.Code)
+}
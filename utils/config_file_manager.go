@@ -4,10 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strings"
 
 	"brand-config-api/config"
 	"brand-config-api/utils/rollback"
@@ -125,81 +124,12 @@ func (w *ConfigFileManager) ReadConfigFile(configFile string) (map[string]interf
 		}
 	}()]))
 
-	// 解析配置 - 采用base_config_service.go中的方法
-	contentStr := string(content)
-
-	// 移除export default前缀
-	if strings.HasPrefix(contentStr, "export default ") {
-		contentStr = strings.TrimPrefix(contentStr, "export default ")
-	}
-
-	// 将单引号替换为双引号，使其符合JSON格式
-	contentStr = strings.ReplaceAll(contentStr, "'", `"`)
-
-	// 清理多余的逗号，修复JSON格式
-	fmt.Printf("🧹 开始清理JSON格式...\n")
-
-	// 使用正则表达式移除对象末尾的多余逗号
-	// 匹配模式：, 后面跟着 } 或 ]
-	re := regexp.MustCompile(`,(\s*[}\]])`)
-	cleanedContent := re.ReplaceAllString(contentStr, "$1")
-
-	// 移除行末的多余逗号
-	lines := strings.Split(cleanedContent, "\n")
-	var finalLines []string
-
-	for i, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// 如果这行以逗号结尾，检查下一行
-		if strings.HasSuffix(trimmedLine, ",") {
-			if i+1 < len(lines) {
-				nextLine := strings.TrimSpace(lines[i+1])
-				// 如果下一行是 } 或 } 开头，移除逗号
-				if strings.HasPrefix(nextLine, "}") || strings.HasPrefix(nextLine, "]") {
-					cleanedLine := strings.TrimSuffix(line, ",")
-					finalLines = append(finalLines, cleanedLine)
-					fmt.Printf("🧹 移除行 %d 的多余逗号\n", i+1)
-					continue
-				}
-			}
-		}
-
-		finalLines = append(finalLines, line)
-	}
-
-	// 重新组合内容
-	finalContent := strings.Join(finalLines, "\n")
-
-	// 最后检查：移除对象末尾的逗号
-	finalContent = strings.ReplaceAll(finalContent, ",}", "}")
-	finalContent = strings.ReplaceAll(finalContent, ",]", "]")
-
-	fmt.Printf("🧹 清理完成，内容长度: %d -> %d\n", len(contentStr), len(finalContent))
-	fmt.Printf("🧹 清理后的内容预览: %s\n", finalContent[:func() int {
-		if 200 < len(finalContent) {
-			return 200
-		} else {
-			return len(finalContent)
-		}
-	}()])
-
-	contentStr = finalContent
-
-	var configData map[string]interface{}
-	if err := json.Unmarshal([]byte(contentStr), &configData); err != nil {
-		fmt.Printf("❌ JSON解析失败: %v\n", err)
-		fmt.Printf("❌ 处理后的内容: %s\n", contentStr)
+	// 解析配置：支持单/双引号、不加引号的键、注释和结尾逗号
+	configData, err := ParseJSConfig(string(content))
+	if err != nil {
+		log.Printf("❌ 配置文件解析失败: %s: %v", configFile, err)
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
-
-	fmt.Printf("📖 JSON解析成功，找到host: %v\n", func() []string {
-		var keys []string
-		for key := range configData {
-			keys = append(keys, key)
-		}
-		return keys
-	}())
 	return configData, nil
 }
 
@@ -231,3 +161,56 @@ func (w *ConfigFileManager) WriteConfigDataToFile(configData map[string]interfac
 	fmt.Printf("✅ 文件写入成功\n")
 	return nil
 }
+
+// SetConfigFileKey 设置配置文件中顶层key的配置，只改写变化的部分并保留原有格式；文件不存在时新建
+func (w *ConfigFileManager) SetConfigFileKey(configFile string, key string, value interface{}) error {
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return w.WriteConfigDataToFile(map[string]interface{}{key: value}, configFile)
+		}
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+
+	configFileEditor, err := ParseJSConfigFile(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse config file: %v", err)
+	}
+	if err := configFileEditor.Set(key, value); err != nil {
+		return fmt.Errorf("failed to update config key %s: %v", key, err)
+	}
+
+	if configFileEditor.Content() == string(content) {
+		return nil
+	}
+	if err := os.WriteFile(configFile, []byte(configFileEditor.Content()), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	log.Printf("✅ 配置写入成功: %s, key: %s", configFile, key)
+	return nil
+}
+
+// DeleteConfigFileKey 删除配置文件中顶层key的配置，返回key是否存在
+func (w *ConfigFileManager) DeleteConfigFileKey(configFile string, key string) (bool, error) {
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	configFileEditor, err := ParseJSConfigFile(string(content))
+	if err != nil {
+		return false, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	exists, err := configFileEditor.Delete(key)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	if err := os.WriteFile(configFile, []byte(configFileEditor.Content()), 0644); err != nil {
+		return false, fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	log.Printf("✅ 配置删除成功: %s, key: %s", configFile, key)
+	return true, nil
+}
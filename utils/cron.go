@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears 计算下次执行时间时最多向后查找的年数（如 0 0 30 2 * 永远不会执行）
+const cronSearchYears = 5
+
+// cronMacros 预定义的表达式
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField 表达式中一个字段的取值范围和名称
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// CronSchedule 解析后的cron表达式（分 时 日 月 周），按服务器本地时间计算
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64 // 各字段允许的取值（按位）
+	domAny, dowAny                bool   // 日、周字段以 * 开头，此时只按另一个字段匹配
+}
+
+// ParseCron 解析标准的5字段cron表达式，支持 *、列表、范围、步长、月份和星期的英文缩写，
+// 以及 @hourly、@daily、@weekly、@monthly、@yearly；日和周同时指定时满足其一即可（与crontab一致）
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(parts))
+	}
+
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		bits, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+		}
+		values[i] = bits
+	}
+
+	// 星期中的 7 与 0 都表示周日
+	dow := values[4]
+	if dow&(1<<7) != 0 {
+		dow = (dow | 1) &^ (1 << 7)
+	}
+
+	return &CronSchedule{
+		minute: values[0],
+		hour:   values[1],
+		dom:    values[2],
+		month:  values[3],
+		dow:    dow,
+		domAny: strings.HasPrefix(parts[2], "*") || parts[2] == "?",
+		dowAny: strings.HasPrefix(parts[4], "*") || parts[4] == "?",
+	}, nil
+}
+
+// parseCronField 解析一个字段，返回允许取值的位集合
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", spec.name, stepPart)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = spec.min, spec.max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseCronValue(from, spec); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(to, spec); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("%s: invalid range %q", spec.name, rangePart)
+			}
+		default:
+			value, err := parseCronValue(rangePart, spec)
+			if err != nil {
+				return 0, err
+			}
+			// 5/15 表示从5开始每15个单位
+			start, end = value, value
+			if hasStep {
+				end = spec.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue 解析单个取值（数字或英文缩写）
+func parseCronValue(value string, spec cronField) (int, error) {
+	if n, ok := spec.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", spec.name, value)
+	}
+	if n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("%s: value %d out of range %d-%d", spec.name, n, spec.min, spec.max)
+	}
+	return n, nil
+}
+
+// Next 返回 after 之后（不含）的下一次执行时间，找不到时返回零值
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !s.matchDay(t) {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = cronAdvance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// cronAdvance 跳到 next；夏令时切换时 time.Date 可能返回不晚于 t 的时间，此时改为前进一分钟
+func cronAdvance(t, next time.Time) time.Time {
+	if !next.After(t) {
+		return t.Add(time.Minute)
+	}
+	return next
+}
+
+// NextN 返回 after 之后的 n 次执行时间
+func (s *CronSchedule) NextN(after time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, n)
+	for len(times) < n {
+		next := s.Next(after)
+		if next.IsZero() {
+			break
+		}
+		times = append(times, next)
+		after = next
+	}
+	return times
+}
+
+// matchDay 日和周都指定时满足其一即可，否则按指定的字段匹配
+func (s *CronSchedule) matchDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
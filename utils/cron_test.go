@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	base := time.Date(2026, 10, 16, 14, 37, 12, 0, loc)
+
+	cases := []struct {
+		expr string
+		want []string
+	}{
+		{"0 2 * * *", []string{"2026-10-17 02:00", "2026-10-18 02:00"}},
+		{"*/15 * * * *", []string{"2026-10-16 14:45", "2026-10-16 15:00"}},
+		{"30 9 * * mon-fri", []string{"2026-10-19 09:30", "2026-10-20 09:30"}},
+		{"0 0 13 * 5", []string{"2026-10-23 00:00", "2026-10-30 00:00"}}, // 日和周满足其一即可
+		{"0 3 */2 * *", []string{"2026-10-17 03:00", "2026-10-19 03:00"}},
+		{"0 0 * * 7", []string{"2026-10-18 00:00", "2026-10-25 00:00"}},
+		{"@monthly", []string{"2026-11-01 00:00", "2026-12-01 00:00"}},
+		{"0 0 31 2 *", nil}, // 永远不会执行
+	}
+	for _, c := range cases {
+		schedule, err := ParseCron(c.expr)
+		if err != nil {
+			t.Errorf("%s: %v", c.expr, err)
+			continue
+		}
+		got := schedule.NextN(base, len(c.want))
+		if len(c.want) == 0 {
+			if !schedule.Next(base).IsZero() {
+				t.Errorf("%s: expected no next run", c.expr)
+			}
+			continue
+		}
+		for i, want := range c.want {
+			if i >= len(got) || got[i].Format("2006-01-02 15:04") != want {
+				t.Errorf("%s: got %v, want %v", c.expr, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestCronNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// 2026-03-08 02:30 不存在（夏令时开始）
+	schedule, err := ParseCron("30 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := schedule.Next(time.Date(2026, 3, 7, 12, 0, 0, 0, loc))
+	if next.Format("2006-01-02 15:04") != "2026-03-09 02:30" {
+		t.Errorf("got %s, want 2026-03-09 02:30", next)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@every 5m"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp 行级差异操作
+type diffOp struct {
+	kind byte // ' ' 相同, '-' 删除, '+' 新增
+	line string
+}
+
+// UnifiedDiff 生成两段文本的统一格式差异（unified diff），内容相同时返回空字符串
+func UnifiedDiff(oldName, newName, oldText, newText string, context int) string {
+	if oldText == newText {
+		return ""
+	}
+
+	oldLines := splitDiffLines(oldText)
+	newLines := splitDiffLines(newText)
+	ops := diffLines(oldLines, newLines)
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+
+	// 按上下文行数将变更分组为多个hunk
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// 向后扩展，直到连续相同行超过 2*context
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end += min(context, run-end)
+				break
+			}
+			end = run
+		}
+
+		writeHunk(&builder, ops, start, end)
+		i = end
+	}
+
+	return builder.String()
+}
+
+// writeHunk 输出一个hunk（包含行号头）
+func writeHunk(builder *strings.Builder, ops []diffOp, start, end int) {
+	oldStart, newStart := 1, 1
+	for _, op := range ops[:start] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[start:end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	// 与diff工具保持一致：空范围的起始行号为前一行
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	builder.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+	for _, op := range ops[start:end] {
+		builder.WriteByte(op.kind)
+		builder.WriteString(op.line)
+		builder.WriteByte('\n')
+	}
+}
+
+// splitDiffLines 按行拆分文本，忽略末尾换行
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines 基于最长公共子序列计算行级差异（先去掉公共前后缀以减少计算量）
+func diffLines(oldLines, newLines []string) []diffOp {
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	a := oldLines[prefix : len(oldLines)-suffix]
+	b := newLines[prefix : len(newLines)-suffix]
+
+	// lcs[i][j] 表示 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(oldLines)+len(newLines))
+	for _, line := range oldLines[:prefix] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', line: a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{kind: '-', line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{kind: '+', line: b[j]})
+	}
+
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+	return ops
+}
@@ -9,4 +9,8 @@ var (
 	ErrTaskNotFound = errors.New("task not found")
 	// ErrTaskAlreadyRunning 任务已在运行错误
 	ErrTaskAlreadyRunning = errors.New("task is already running")
+	// ErrTaskFinished 任务已结束错误
+	ErrTaskFinished = errors.New("task has already finished")
+	// ErrTaskNotCancellable 任务不支持取消错误
+	ErrTaskNotCancellable = errors.New("task does not support cancellation")
 )
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// EventStreamID 全局事件流在 WebSocketManager 中的ID（任务ID为UUID，不会冲突）
+const EventStreamID = "events"
+
+// 事件类型
+const (
+	EventWebsiteCreated = "website.created" // 网站创建（含克隆、批量创建）完成
+	EventWebsiteDeleted = "website.deleted"
+	EventConfigUpdated  = "config.updated" // 配置创建、修改、删除、恢复修订、漂移处理，data.action 为具体操作
+	EventBuildStarted   = "build.started"
+	EventBuildFinished  = "build.finished"
+	EventDeployFinished = "deploy.finished"
+	EventGitPushed      = "git.pushed"
+)
+
+// Event 全局领域事件，通过 /ws/events 和 /api/events 推送给看板
+type Event struct {
+	Type     string      `json:"type"`                // 事件类型，见 Event* 常量
+	Time     time.Time   `json:"time"`                // 发生时间
+	Actor    string      `json:"actor,omitempty"`     // 操作人（X-Operator 或客户端IP）
+	ClientID int         `json:"client_id,omitempty"` // 相关客户端
+	TaskID   string      `json:"task_id,omitempty"`   // 相关异步任务
+	Status   string      `json:"status,omitempty"`    // 结束类事件的结果：completed、failed、cancelled
+	Data     interface{} `json:"data,omitempty"`      // 事件详情，结构随类型不同
+}
+
+var (
+	eventManager *WebSocketManager
+	eventMutex   sync.RWMutex
+)
+
+// SetEventManager 设置发布事件使用的消息管理器（启动时调用）
+func SetEventManager(wm *WebSocketManager) {
+	eventMutex.Lock()
+	defer eventMutex.Unlock()
+	eventManager = wm
+}
+
+// PublishEvent 发布全局事件，未设置消息管理器时忽略
+func PublishEvent(event Event) {
+	eventMutex.RLock()
+	wm := eventManager
+	eventMutex.RUnlock()
+
+	if wm == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	log.Printf("📢 发布事件: %s (actor=%s, client=%d, task=%s)", event.Type, event.Actor, event.ClientID, event.TaskID)
+	wm.SendMessage(EventStreamID, event)
+}
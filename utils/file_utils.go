@@ -69,18 +69,7 @@ func (fu *FileUtils) CopyFile(src, dst string) error {
 
 // GetUniPlatform 根据host获取对应的uni平台标识
 func (fu *FileUtils) GetUniPlatform(host string) string {
-	switch host {
-	case "tt":
-		return "mp-toutiao"
-	case "ks":
-		return "mp-kuaishou"
-	case "wx":
-		return "mp-weixin"
-	case "bd":
-		return "mp-baidu"
-	default:
-		return "h5"
-	}
+	return GetPlatformRegistry().UniPlatform(host)
 }
 
 // JSONUtils JSON配置文件解析工具类
@@ -268,24 +257,9 @@ func (cfu *ConfigFileUtils) GenerateConfigFile(ctx *rollback.TransactionContext,
 		return fmt.Errorf("failed to check file existence: %v", err)
 	}
 
-	// 读取现有配置文件或创建新的配置对象
+	// 更新指定host的配置（文件不存在时新建）
 	configfileManager := NewConfigFileManager()
-	configData, err := configfileManager.ReadConfigFile(configFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// 文件不存在，创建新的配置对象
-			configData = make(map[string]interface{})
-			log.Printf("📄 配置文件不存在，创建新的配置: %s", configFile)
-		} else {
-			return fmt.Errorf("failed to read existing config file: %v", err)
-		}
-	}
-
-	// 更新指定host的配置
-	configData[host] = hostConfig
-
-	// 写入文件
-	if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
+	if err := configfileManager.SetConfigFileKey(configFile, host, hostConfig); err != nil {
 		return fmt.Errorf("failed to write config file: %v", err)
 	}
 
@@ -304,21 +278,10 @@ func (cfu *ConfigFileUtils) DeleteConfigFileHost(ctx *rollback.TransactionContex
 		return fmt.Errorf("failed to backup file: %v", err)
 	}
 
-	// 读取现有配置文件
-	configfileManager := NewConfigFileManager()
-	configData, err := configfileManager.ReadConfigFile(configFile)
-	if err != nil {
-		return fmt.Errorf("failed to read existing config file: %v", err)
-	}
-
 	// 删除指定host的配置
-	if _, exists := configData[host]; exists {
-		delete(configData, host)
-	}
-
-	// 写入更新后的配置文件
-	if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+	configfileManager := NewConfigFileManager()
+	if _, err := configfileManager.DeleteConfigFileKey(configFile, host); err != nil {
+		return fmt.Errorf("failed to delete config file host: %v", err)
 	}
 
 	return nil
@@ -331,18 +294,14 @@ func (cfu *ConfigFileUtils) UpdateConfigFileHost(ctx *rollback.TransactionContex
 		return fmt.Errorf("failed to backup file: %v", err)
 	}
 
-	// 读取现有配置文件
-	configfileManager := NewConfigFileManager()
-	configData, err := configfileManager.ReadConfigFile(configFile)
-	if err != nil {
+	// 检查文件是否存在
+	if _, err := os.Stat(configFile); err != nil {
 		return fmt.Errorf("failed to read existing config file: %v", err)
 	}
 
 	// 更新指定host的配置
-	configData[host] = hostConfig
-
-	// 写入文件
-	if err := configfileManager.WriteConfigDataToFile(configData, configFile); err != nil {
+	configfileManager := NewConfigFileManager()
+	if err := configfileManager.SetConfigFileKey(configFile, host, hostConfig); err != nil {
 		return fmt.Errorf("failed to write config file: %v", err)
 	}
 
@@ -0,0 +1,643 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsConfigStyle 配置文件的书写风格，新增内容时沿用
+type jsConfigStyle struct {
+	keyQuote      byte   // 键使用的引号，0 表示合法标识符的键不加引号
+	valueQuote    byte   // 字符串值使用的引号
+	indent        string // 每级缩进
+	newline       string
+	trailingComma bool // 多行对象最后一个属性后是否带逗号
+}
+
+// jsEdit 对源码的一次替换 [start, end) -> text
+type jsEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// JSConfigFile export default {...} 形式的JS配置文件
+// 容忍单/双引号、不加引号的键、注释和结尾逗号；修改时只改写变化的属性，保留原有引号风格和注释
+type JSConfigFile struct {
+	content string
+	style   jsConfigStyle
+}
+
+// ParseJSConfigFile 解析JS配置文件内容
+func ParseJSConfigFile(content string) (*JSConfigFile, error) {
+	file := &JSConfigFile{content: content}
+	tokens, root, err := file.locate()
+	if err != nil {
+		return nil, err
+	}
+	file.style = detectJSConfigStyle(content, tokens, root)
+	return file, nil
+}
+
+// ParseJSConfig 将JS配置文件内容解析为map（字符串、数字、布尔、null、数组和对象）
+func ParseJSConfig(content string) (map[string]interface{}, error) {
+	file, err := ParseJSConfigFile(content)
+	if err != nil {
+		return nil, err
+	}
+	return file.Data()
+}
+
+// Content 获取编辑后的内容
+func (f *JSConfigFile) Content() string {
+	return f.content
+}
+
+// Data 获取配置对象
+func (f *JSConfigFile) Data() (map[string]interface{}, error) {
+	tokens, root, err := f.locate()
+	if err != nil {
+		return nil, err
+	}
+
+	value, _, err := f.parseValue(tokens, root.Open)
+	if err != nil {
+		return nil, err
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// Set 设置顶层属性的值；属性已存在时只改写有变化的子属性
+func (f *JSConfigFile) Set(key string, value interface{}) error {
+	normalized, err := normalizeJSConfigValue(value)
+	if err != nil {
+		return err
+	}
+
+	tokens, root, err := f.locate()
+	if err != nil {
+		return err
+	}
+
+	edits, err := f.diffObject(tokens, root, map[string]interface{}{key: normalized}, false)
+	if err != nil {
+		return err
+	}
+	f.apply(edits)
+	return nil
+}
+
+// Delete 删除顶层属性，返回属性是否存在
+func (f *JSConfigFile) Delete(key string) (bool, error) {
+	tokens, root, err := f.locate()
+	if err != nil {
+		return false, err
+	}
+
+	var edits []jsEdit
+	for _, entry := range root.Entries {
+		if entry.Key == key {
+			edits = append(edits, f.removeEntry(tokens, entry))
+		}
+	}
+	f.apply(edits)
+	return len(edits) > 0, nil
+}
+
+// locate 定位 export default 导出的对象字面量
+func (f *JSConfigFile) locate() ([]JSToken, *JSObjectLiteral, error) {
+	tokens, err := TokenizeJS(f.content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to tokenize config file: %v", err)
+	}
+
+	open, err := findJSConfigRoot(tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root, err := ParseJSObjectLiteral(tokens, open)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config object: %v", err)
+	}
+	return tokens, root, nil
+}
+
+// findJSConfigRoot 查找导出对象的 { 位置，支持 export default {...}、export default name 和 module.exports = {...}
+func findJSConfigRoot(tokens []JSToken) (int, error) {
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Kind != JSTokenIdent {
+			continue
+		}
+
+		switch tokens[i].Text {
+		case "export":
+			next := nextJSToken(tokens, i+1)
+			if next >= len(tokens) || tokens[next].Kind != JSTokenIdent || tokens[next].Text != "default" {
+				continue
+			}
+			value := nextJSToken(tokens, next+1)
+			if value >= len(tokens) {
+				continue
+			}
+			if tokens[value].IsPunct("{") {
+				return value, nil
+			}
+			if tokens[value].Kind == JSTokenIdent {
+				if open := findJSAssignedObject(tokens, tokens[value].Text); open >= 0 {
+					return open, nil
+				}
+			}
+
+		case "module":
+			dot := nextJSToken(tokens, i+1)
+			exports := nextJSToken(tokens, dot+1)
+			assign := nextJSToken(tokens, exports+1)
+			open := nextJSToken(tokens, assign+1)
+			if open < len(tokens) && tokens[dot].IsPunct(".") && tokens[exports].Text == "exports" &&
+				tokens[assign].IsPunct("=") && tokens[open].IsPunct("{") {
+				return open, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot find exported config object")
+}
+
+// findJSAssignedObject 查找 name = { 中 { 的位置，找不到返回 -1
+func findJSAssignedObject(tokens []JSToken, name string) int {
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Kind != JSTokenIdent || tokens[i].Text != name {
+			continue
+		}
+		assign := nextJSToken(tokens, i+1)
+		open := nextJSToken(tokens, assign+1)
+		if open < len(tokens) && tokens[assign].IsPunct("=") && tokens[open].IsPunct("{") {
+			return open
+		}
+	}
+	return -1
+}
+
+// parseValue 解析从 tokens[i] 开始的字面量，返回值和其后的词法单元下标
+func (f *JSConfigFile) parseValue(tokens []JSToken, i int) (interface{}, int, error) {
+	i = nextJSToken(tokens, i)
+	if i >= len(tokens) {
+		return nil, i, fmt.Errorf("unexpected end of config file")
+	}
+	token := tokens[i]
+
+	switch token.Kind {
+	case JSTokenString:
+		return token.StringValue(), i + 1, nil
+
+	case JSTokenTemplate:
+		if strings.Contains(token.Text, "${") {
+			return nil, i, f.unsupported(token)
+		}
+		return token.StringValue(), i + 1, nil
+
+	case JSTokenNumber:
+		number, err := parseJSNumber(token.Text)
+		if err != nil {
+			return nil, i, f.unsupported(token)
+		}
+		return number, i + 1, nil
+
+	case JSTokenIdent:
+		switch token.Text {
+		case "true":
+			return true, i + 1, nil
+		case "false":
+			return false, i + 1, nil
+		case "null", "undefined":
+			return nil, i + 1, nil
+		}
+		return nil, i, f.unsupported(token)
+
+	case JSTokenPunct:
+		switch token.Text {
+		case "{":
+			return f.parseObject(tokens, i)
+		case "[":
+			return f.parseArray(tokens, i)
+		case "-", "+":
+			next := nextJSToken(tokens, i+1)
+			if next < len(tokens) && tokens[next].Kind == JSTokenNumber {
+				number, err := parseJSNumber(tokens[next].Text)
+				if err != nil {
+					return nil, i, f.unsupported(tokens[next])
+				}
+				if token.Text == "-" {
+					number = -number
+				}
+				return number, next + 1, nil
+			}
+		}
+	}
+
+	return nil, i, f.unsupported(token)
+}
+
+// parseObject 解析对象字面量
+func (f *JSConfigFile) parseObject(tokens []JSToken, open int) (interface{}, int, error) {
+	object, err := ParseJSObjectLiteral(tokens, open)
+	if err != nil {
+		return nil, open, err
+	}
+
+	result := make(map[string]interface{}, len(object.Entries))
+	for _, entry := range object.Entries {
+		switch entry.KeyToken.Kind {
+		case JSTokenString, JSTokenIdent, JSTokenNumber:
+		default:
+			return nil, open, f.unsupported(entry.KeyToken)
+		}
+		if entry.ValueEnd == entry.ValueStart {
+			return nil, open, fmt.Errorf("missing value for key %q at line %d", entry.Key, f.lineAt(entry.KeyToken.Start))
+		}
+
+		value, next, err := f.parseValue(tokens, entry.ValueStart)
+		if err != nil {
+			return nil, open, err
+		}
+		if next != entry.ValueEnd {
+			return nil, open, f.unsupported(tokens[next])
+		}
+		result[entry.Key] = value
+	}
+	return result, object.Close + 1, nil
+}
+
+// parseArray 解析数组字面量
+func (f *JSConfigFile) parseArray(tokens []JSToken, open int) (interface{}, int, error) {
+	result := make([]interface{}, 0)
+	i := nextJSToken(tokens, open+1)
+	for i < len(tokens) {
+		if tokens[i].IsPunct("]") {
+			return result, i + 1, nil
+		}
+
+		value, next, err := f.parseValue(tokens, i)
+		if err != nil {
+			return nil, open, err
+		}
+		result = append(result, value)
+
+		i = nextJSToken(tokens, next)
+		if i < len(tokens) && tokens[i].IsPunct(",") {
+			i = nextJSToken(tokens, i+1)
+		} else if i < len(tokens) && !tokens[i].IsPunct("]") {
+			return nil, open, f.unsupported(tokens[i])
+		}
+	}
+	return nil, open, fmt.Errorf("unterminated array at line %d", f.lineAt(tokens[open].Start))
+}
+
+// unsupported 生成不支持的语法错误（带行号）
+func (f *JSConfigFile) unsupported(token JSToken) error {
+	return fmt.Errorf("unsupported token %q at line %d", token.Text, f.lineAt(token.Start))
+}
+
+// lineAt 计算偏移量所在行号（从1开始）
+func (f *JSConfigFile) lineAt(pos int) int {
+	return strings.Count(f.content[:pos], "\n") + 1
+}
+
+// diffObject 比较对象字面量与新值，生成最小改写；deleteMissing 为 true 时删除新值中不存在的属性
+func (f *JSConfigFile) diffObject(tokens []JSToken, object *JSObjectLiteral, values map[string]interface{}, deleteMissing bool) ([]jsEdit, error) {
+	var edits []jsEdit
+	var kept []JSObjectEntry
+	seen := make(map[string]bool)
+
+	for _, entry := range object.Entries {
+		value, ok := values[entry.Key]
+		if !ok {
+			if deleteMissing {
+				edits = append(edits, f.removeEntry(tokens, entry))
+			} else {
+				kept = append(kept, entry)
+			}
+			continue
+		}
+
+		seen[entry.Key] = true
+		kept = append(kept, entry)
+		valueEdits, err := f.diffValue(tokens, entry, value)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, valueEdits...)
+	}
+
+	var added []string
+	for key := range values {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	if len(added) == 0 {
+		return edits, nil
+	}
+	sort.Strings(added)
+
+	return append(edits, f.insertEntries(tokens, object, kept, added, values)...), nil
+}
+
+// diffValue 比较属性的旧值与新值，值未变化时不做改写
+func (f *JSConfigFile) diffValue(tokens []JSToken, entry JSObjectEntry, value interface{}) ([]jsEdit, error) {
+	if entry.ValueEnd == entry.ValueStart {
+		return nil, fmt.Errorf("missing value for key %q at line %d", entry.Key, f.lineAt(entry.KeyToken.Start))
+	}
+	first := tokens[entry.ValueStart]
+
+	// 对象与对象比较时逐个属性改写
+	if values, ok := value.(map[string]interface{}); ok && first.IsPunct("{") {
+		object, err := ParseJSObjectLiteral(tokens, entry.ValueStart)
+		if err != nil {
+			return nil, err
+		}
+		if object.Close == entry.ValueEnd-1 {
+			return f.diffObject(tokens, object, values, true)
+		}
+	}
+
+	current, next, err := f.parseValue(tokens, entry.ValueStart)
+	if err == nil && next == entry.ValueEnd && reflect.DeepEqual(current, value) {
+		return nil, nil
+	}
+
+	// 字符串值保留原有的引号
+	quote := f.style.valueQuote
+	if entry.ValueEnd-entry.ValueStart == 1 && first.Kind == JSTokenString {
+		quote = first.Quote()
+	}
+
+	return []jsEdit{{
+		start: first.Start,
+		end:   tokens[entry.ValueEnd-1].End,
+		text:  f.formatValue(value, lineIndentAt(f.content, first.Start), quote),
+	}}, nil
+}
+
+// insertEntries 在对象末尾追加属性，沿用已有属性的缩进
+func (f *JSConfigFile) insertEntries(tokens []JSToken, object *JSObjectLiteral, kept []JSObjectEntry, keys []string, values map[string]interface{}) []jsEdit {
+	openEnd := tokens[object.Open].End
+	closeStart := tokens[object.Close].Start
+	multiline := strings.Contains(f.content[openEnd:closeStart], "\n")
+
+	// 对象中没有保留的属性：插入到左花括号之后
+	if len(kept) == 0 {
+		closeIndent := lineIndentAt(f.content, closeStart)
+		indent := closeIndent + f.style.indent
+
+		var builder strings.Builder
+		for i, key := range keys {
+			builder.WriteString(f.style.newline + indent + f.formatEntry(key, values[key], indent))
+			if i < len(keys)-1 || f.style.trailingComma {
+				builder.WriteString(",")
+			}
+		}
+		if !multiline {
+			builder.WriteString(f.style.newline + closeIndent)
+		}
+		return []jsEdit{{start: openEnd, end: openEnd, text: builder.String()}}
+	}
+
+	last := kept[len(kept)-1]
+	indent := lineIndentAt(f.content, last.KeyToken.Start)
+	var edits []jsEdit
+
+	insertAt := jsEntryEnd(tokens, last)
+	trailingComma := last.Comma >= 0
+
+	// 最后一个属性没有逗号时先补上
+	addComma := func(at int, text string) []jsEdit {
+		if trailingComma {
+			return append(edits, jsEdit{start: at, end: at, text: text})
+		}
+		if at == insertAt {
+			return append(edits, jsEdit{start: at, end: at, text: "," + text})
+		}
+		return append(edits, jsEdit{start: insertAt, end: insertAt, text: ","}, jsEdit{start: at, end: at, text: text})
+	}
+
+	// 单行对象：直接跟在最后一个属性后面
+	rest := ""
+	lineEnd := strings.IndexByte(f.content[insertAt:], '\n')
+	if lineEnd >= 0 {
+		rest = strings.TrimSpace(f.content[insertAt : insertAt+lineEnd])
+	}
+	if !multiline || lineEnd < 0 || (rest != "" && !strings.HasPrefix(rest, "//")) {
+		var parts []string
+		for _, key := range keys {
+			parts = append(parts, f.formatEntry(key, values[key], indent))
+		}
+		text := " " + strings.Join(parts, ", ")
+		if trailingComma {
+			text += ","
+		}
+		return addComma(insertAt, text)
+	}
+
+	// 多行对象：插入到最后一个属性所在行的行尾
+	at := insertAt + lineEnd
+	if f.content[at-1] == '\r' {
+		at--
+	}
+	var builder strings.Builder
+	for i, key := range keys {
+		builder.WriteString(f.style.newline + indent + f.formatEntry(key, values[key], indent))
+		if i < len(keys)-1 || trailingComma {
+			builder.WriteString(",")
+		}
+	}
+	return addComma(at, builder.String())
+}
+
+// removeEntry 删除一个属性；属性独占一行时连同整行删除
+func (f *JSConfigFile) removeEntry(tokens []JSToken, entry JSObjectEntry) jsEdit {
+	start, end := jsEntryRemovalRange(f.content, entry.KeyToken.Start, jsEntryEnd(tokens, entry))
+	return jsEdit{start: start, end: end}
+}
+
+// apply 从后向前应用改写
+func (f *JSConfigFile) apply(edits []jsEdit) {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, edit := range edits {
+		f.content = f.content[:edit.start] + edit.text + f.content[edit.end:]
+	}
+}
+
+// formatEntry 格式化一个属性
+func (f *JSConfigFile) formatEntry(key string, value interface{}, indent string) string {
+	return f.formatKey(key) + ": " + f.formatValue(value, indent, f.style.valueQuote)
+}
+
+// formatKey 按文件风格格式化键
+func (f *JSConfigFile) formatKey(key string) string {
+	if f.style.keyQuote == 0 {
+		if IsJSIdentifier(key) {
+			return key
+		}
+		return QuoteJSString(key, f.style.valueQuote)
+	}
+	return QuoteJSString(key, f.style.keyQuote)
+}
+
+// formatValue 按文件风格格式化值，indent 为值所在行的缩进
+func (f *JSConfigFile) formatValue(value interface{}, indent string, quote byte) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return QuoteJSString(v, quote)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "{}"
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		childIndent := indent + f.style.indent
+		var builder strings.Builder
+		builder.WriteString("{")
+		for i, key := range keys {
+			builder.WriteString(f.style.newline + childIndent + f.formatEntry(key, v[key], childIndent))
+			if i < len(keys)-1 || f.style.trailingComma {
+				builder.WriteString(",")
+			}
+		}
+		builder.WriteString(f.style.newline + indent + "}")
+		return builder.String()
+
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]"
+		}
+		childIndent := indent + f.style.indent
+		var builder strings.Builder
+		builder.WriteString("[")
+		for i, item := range v {
+			builder.WriteString(f.style.newline + childIndent + f.formatValue(item, childIndent, f.style.valueQuote))
+			if i < len(v)-1 || f.style.trailingComma {
+				builder.WriteString(",")
+			}
+		}
+		builder.WriteString(f.style.newline + indent + "]")
+		return builder.String()
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// normalizeJSConfigValue 将任意值转换为解析结果使用的类型（map/[]interface{}/float64等），便于比较和格式化
+func normalizeJSConfigValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config value: %v", err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("failed to normalize config value: %v", err)
+	}
+	return normalized, nil
+}
+
+// parseJSNumber 解析JS数字字面量（支持十六进制、八进制、二进制和数字分隔符）
+func parseJSNumber(text string) (float64, error) {
+	text = strings.ReplaceAll(text, "_", "")
+	if len(text) > 2 && text[0] == '0' && strings.ContainsRune("xXoObB", rune(text[1])) {
+		number, err := strconv.ParseInt(text, 0, 64)
+		return float64(number), err
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+// detectJSConfigStyle 根据已有内容推断引号、缩进、换行和结尾逗号风格；空文件沿用JSON风格
+func detectJSConfigStyle(content string, tokens []JSToken, root *JSObjectLiteral) jsConfigStyle {
+	style := jsConfigStyle{keyQuote: '"', valueQuote: '"', indent: "  ", newline: "\n"}
+	if strings.Contains(content, "\r\n") {
+		style.newline = "\r\n"
+	}
+
+	keyQuotes := make(map[byte]int)
+	valueQuotes := make(map[byte]int)
+	for i, token := range tokens {
+		next := nextJSToken(tokens, i+1)
+		isKey := next < len(tokens) && tokens[next].IsPunct(":")
+		switch {
+		case token.Kind == JSTokenString && isKey:
+			keyQuotes[token.Quote()]++
+		case token.Kind == JSTokenString:
+			valueQuotes[token.Quote()]++
+		case token.Kind == JSTokenIdent && isKey:
+			keyQuotes[0]++
+		}
+	}
+
+	if valueQuotes['\''] > valueQuotes['"'] {
+		style.valueQuote = '\''
+	}
+	if keyQuotes[0] > keyQuotes['\'']+keyQuotes['"'] {
+		style.keyQuote = 0
+	} else if keyQuotes['\''] > keyQuotes['"'] {
+		style.keyQuote = '\''
+	} else if keyQuotes['"'] == 0 {
+		style.keyQuote = style.valueQuote
+	}
+
+	if len(root.Entries) > 0 {
+		first := root.Entries[0]
+		openIndent := lineIndentAt(content, tokens[root.Open].Start)
+		entryIndent := lineIndentAt(content, first.KeyToken.Start)
+		if len(entryIndent) > len(openIndent) && strings.HasPrefix(entryIndent, openIndent) {
+			style.indent = entryIndent[len(openIndent):]
+		}
+		style.trailingComma = root.Entries[len(root.Entries)-1].Comma >= 0
+	}
+	return style
+}
+
+// jsEntryEnd 属性结束位置（包括逗号）
+func jsEntryEnd(tokens []JSToken, entry JSObjectEntry) int {
+	if entry.Comma >= 0 {
+		return tokens[entry.Comma].End
+	}
+	if entry.ValueEnd > entry.ValueStart {
+		return tokens[entry.ValueEnd-1].End
+	}
+	return entry.KeyToken.End
+}
+
+// jsEntryRemovalRange 计算删除属性时的范围：属性独占一行时连同行尾注释和换行一起删除，否则删除属性及其后的空格
+func jsEntryRemovalRange(content string, start, end int) (int, int) {
+	lineStart := strings.LastIndex(content[:start], "\n") + 1
+	lineEnd := strings.IndexByte(content[end:], '\n')
+	rest := ""
+	if lineEnd >= 0 {
+		rest = strings.TrimSpace(content[end : end+lineEnd])
+	}
+	if lineEnd >= 0 && strings.TrimSpace(content[lineStart:start]) == "" &&
+		(rest == "" || strings.HasPrefix(rest, "//")) {
+		return lineStart, end + lineEnd + 1
+	}
+
+	for end < len(content) && content[end] == ' ' {
+		end++
+	}
+	return start, end
+}
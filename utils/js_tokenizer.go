@@ -0,0 +1,432 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSTokenKind JS词法单元类型
+type JSTokenKind int
+
+const (
+	JSTokenPunct    JSTokenKind = iota // 标点和运算符
+	JSTokenString                      // '...' 或 "..."
+	JSTokenTemplate                    // `...`
+	JSTokenNumber
+	JSTokenIdent
+	JSTokenRegex
+	JSTokenComment // 行注释和块注释
+)
+
+// JSToken JS词法单元，Start/End 为在源码中的字节偏移 [Start, End)
+type JSToken struct {
+	Kind  JSTokenKind
+	Text  string
+	Start int
+	End   int
+}
+
+// IsPunct 判断是否为指定标点
+func (t JSToken) IsPunct(text string) bool {
+	return t.Kind == JSTokenPunct && t.Text == text
+}
+
+// Quote 字符串词法单元使用的引号，非字符串返回0
+func (t JSToken) Quote() byte {
+	if t.Kind == JSTokenString || t.Kind == JSTokenTemplate {
+		return t.Text[0]
+	}
+	return 0
+}
+
+// StringValue 获取字符串词法单元解码后的值
+func (t JSToken) StringValue() string {
+	if t.Kind != JSTokenString && t.Kind != JSTokenTemplate {
+		return t.Text
+	}
+	return unquoteJSString(t.Text)
+}
+
+// TokenizeJS 将JS源码切分为词法单元（只覆盖配置文件中常见的语法，不做完整语法分析）
+func TokenizeJS(src string) ([]JSToken, error) {
+	var tokens []JSToken
+	pos := 0
+
+	for pos < len(src) {
+		c := src[pos]
+
+		// 空白
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			pos++
+			continue
+		}
+
+		start := pos
+		switch {
+		case c == '/' && pos+1 < len(src) && src[pos+1] == '/':
+			end := strings.IndexByte(src[pos:], '\n')
+			if end == -1 {
+				pos = len(src)
+			} else {
+				pos += end
+			}
+			tokens = append(tokens, JSToken{Kind: JSTokenComment, Text: src[start:pos], Start: start, End: pos})
+
+		case c == '/' && pos+1 < len(src) && src[pos+1] == '*':
+			end := strings.Index(src[pos+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated block comment at offset %d", start)
+			}
+			pos += 2 + end + 2
+			tokens = append(tokens, JSToken{Kind: JSTokenComment, Text: src[start:pos], Start: start, End: pos})
+
+		case c == '\'' || c == '"':
+			end, err := scanJSQuoted(src, pos, c)
+			if err != nil {
+				return nil, err
+			}
+			pos = end
+			tokens = append(tokens, JSToken{Kind: JSTokenString, Text: src[start:pos], Start: start, End: pos})
+
+		case c == '`':
+			end, err := scanJSTemplate(src, pos)
+			if err != nil {
+				return nil, err
+			}
+			pos = end
+			tokens = append(tokens, JSToken{Kind: JSTokenTemplate, Text: src[start:pos], Start: start, End: pos})
+
+		case c == '/' && jsRegexAllowed(tokens):
+			end, err := scanJSRegex(src, pos)
+			if err != nil {
+				return nil, err
+			}
+			pos = end
+			tokens = append(tokens, JSToken{Kind: JSTokenRegex, Text: src[start:pos], Start: start, End: pos})
+
+		case c >= '0' && c <= '9' || c == '.' && pos+1 < len(src) && src[pos+1] >= '0' && src[pos+1] <= '9':
+			for pos < len(src) && (isJSIdentChar(src[pos]) || src[pos] == '.') {
+				pos++
+			}
+			tokens = append(tokens, JSToken{Kind: JSTokenNumber, Text: src[start:pos], Start: start, End: pos})
+
+		case isJSIdentStart(c):
+			for pos < len(src) && isJSIdentChar(src[pos]) {
+				pos++
+			}
+			tokens = append(tokens, JSToken{Kind: JSTokenIdent, Text: src[start:pos], Start: start, End: pos})
+
+		case strings.HasPrefix(src[pos:], "..."):
+			pos += 3
+			tokens = append(tokens, JSToken{Kind: JSTokenPunct, Text: "...", Start: start, End: pos})
+
+		default:
+			_, size := utf8.DecodeRuneInString(src[pos:])
+			pos += size
+			tokens = append(tokens, JSToken{Kind: JSTokenPunct, Text: src[start:pos], Start: start, End: pos})
+		}
+	}
+
+	return tokens, nil
+}
+
+// scanJSQuoted 扫描单/双引号字符串，返回结束位置
+func scanJSQuoted(src string, pos int, quote byte) (int, error) {
+	for i := pos + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1, nil
+		case '\n':
+			return 0, fmt.Errorf("unterminated string at offset %d", pos)
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at offset %d", pos)
+}
+
+// scanJSTemplate 扫描模板字符串（支持 ${} 嵌套），返回结束位置
+func scanJSTemplate(src string, pos int) (int, error) {
+	depth := 0
+	for i := pos + 1; i < len(src); i++ {
+		switch {
+		case src[i] == '\\':
+			i++
+		case depth == 0 && src[i] == '`':
+			return i + 1, nil
+		case src[i] == '$' && i+1 < len(src) && src[i+1] == '{':
+			depth++
+			i++
+		case depth > 0 && src[i] == '{':
+			depth++
+		case depth > 0 && src[i] == '}':
+			depth--
+		}
+	}
+	return 0, fmt.Errorf("unterminated template literal at offset %d", pos)
+}
+
+// scanJSRegex 扫描正则字面量（包括标志位），返回结束位置
+func scanJSRegex(src string, pos int) (int, error) {
+	inClass := false
+	for i := pos + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '\n':
+			return 0, fmt.Errorf("unterminated regex at offset %d", pos)
+		case '/':
+			if inClass {
+				continue
+			}
+			end := i + 1
+			for end < len(src) && isJSIdentChar(src[end]) {
+				end++
+			}
+			return end, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated regex at offset %d", pos)
+}
+
+// jsRegexAllowed 根据前一个有效词法单元判断 / 是正则开始还是除号
+func jsRegexAllowed(tokens []JSToken) bool {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		token := tokens[i]
+		switch token.Kind {
+		case JSTokenComment:
+			continue
+		case JSTokenPunct:
+			return token.Text != ")" && token.Text != "]" && token.Text != "}"
+		case JSTokenIdent:
+			return token.Text == "return" || token.Text == "typeof" || token.Text == "case"
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isJSIdentStart 判断是否可以作为标识符开头
+func isJSIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// isJSIdentChar 判断是否可以作为标识符字符
+func isJSIdentChar(c byte) bool {
+	return isJSIdentStart(c) || c >= '0' && c <= '9'
+}
+
+// IsJSIdentifier 判断字符串是否可以不加引号作为对象键
+func IsJSIdentifier(s string) bool {
+	if s == "" || !isJSIdentStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isJSIdentChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// unquoteJSString 解码JS字符串字面量
+func unquoteJSString(raw string) string {
+	if len(raw) < 2 {
+		return raw
+	}
+	body := raw[1 : len(raw)-1]
+	if !strings.Contains(body, "\\") {
+		return body
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' || i+1 >= len(body) {
+			builder.WriteByte(body[i])
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 't':
+			builder.WriteByte('\t')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'u':
+			if i+4 < len(body) {
+				if code, err := strconv.ParseUint(body[i+1:i+5], 16, 32); err == nil {
+					builder.WriteRune(rune(code))
+					i += 4
+					continue
+				}
+			}
+			builder.WriteByte('u')
+		case '\n':
+			// 行继续符
+		default:
+			builder.WriteByte(body[i])
+		}
+	}
+	return builder.String()
+}
+
+// QuoteJSString 使用指定引号生成JS字符串字面量
+func QuoteJSString(value string, quote byte) string {
+	var builder strings.Builder
+	builder.WriteByte(quote)
+	for _, r := range value {
+		switch r {
+		case rune(quote), '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	builder.WriteByte(quote)
+	return builder.String()
+}
+
+// JSObjectEntry 对象字面量中的一个属性
+type JSObjectEntry struct {
+	Key        string
+	KeyToken   JSToken
+	ValueStart int // 值在词法单元列表中的范围 [ValueStart, ValueEnd)，简写属性时为空
+	ValueEnd   int
+	Comma      int // 属性后逗号的词法单元下标，没有时为 -1
+}
+
+// JSObjectLiteral 对象字面量
+type JSObjectLiteral struct {
+	Open    int // { 的词法单元下标
+	Close   int // } 的词法单元下标
+	Entries []JSObjectEntry
+}
+
+// ParseJSObjectLiteral 从 tokens[open]（必须为 {）开始解析对象字面量的属性
+// 属性值不做深入解析，只记录其词法单元范围；展开语法（...x）和计算属性会被跳过
+func ParseJSObjectLiteral(tokens []JSToken, open int) (*JSObjectLiteral, error) {
+	if open >= len(tokens) || !tokens[open].IsPunct("{") {
+		return nil, fmt.Errorf("expected '{'")
+	}
+
+	object := &JSObjectLiteral{Open: open}
+	i := nextJSToken(tokens, open+1)
+
+	for i < len(tokens) {
+		token := tokens[i]
+		if token.IsPunct("}") {
+			object.Close = i
+			return object, nil
+		}
+		if token.IsPunct(",") {
+			i = nextJSToken(tokens, i+1)
+			continue
+		}
+
+		entry := JSObjectEntry{KeyToken: token, Comma: -1}
+		switch token.Kind {
+		case JSTokenString:
+			entry.Key = token.StringValue()
+		case JSTokenIdent, JSTokenNumber:
+			entry.Key = token.Text
+		}
+
+		// 展开和计算属性：解析但不记录
+		skip := token.IsPunct("...") || token.IsPunct("[")
+		if token.IsPunct("[") {
+			for depth := 0; i < len(tokens); i++ {
+				if tokens[i].IsPunct("[") {
+					depth++
+				} else if tokens[i].IsPunct("]") {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+		}
+
+		j := nextJSToken(tokens, i+1)
+		if j < len(tokens) && tokens[j].IsPunct(":") {
+			j = nextJSToken(tokens, j+1)
+		}
+		entry.ValueStart = j
+
+		end, err := skipJSExpression(tokens, j)
+		if err != nil {
+			return nil, err
+		}
+		entry.ValueEnd = end
+		if entry.ValueEnd < entry.ValueStart {
+			entry.ValueEnd = entry.ValueStart
+		}
+
+		// end 指向终止的 , 或 }
+		k := end
+		for k < len(tokens) && tokens[k].Kind == JSTokenComment {
+			k++
+		}
+		if k < len(tokens) && tokens[k].IsPunct(",") {
+			entry.Comma = k
+		}
+
+		if !skip {
+			object.Entries = append(object.Entries, entry)
+		}
+		i = nextJSToken(tokens, k)
+	}
+
+	return nil, fmt.Errorf("unterminated object literal")
+}
+
+// skipJSExpression 跳过一个表达式，返回表达式后最后一个有效词法单元之后的位置（不包括结尾的注释）
+func skipJSExpression(tokens []JSToken, start int) (int, error) {
+	depth := 0
+	last := start
+	for i := start; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.Kind == JSTokenComment {
+			continue
+		}
+		if token.Kind == JSTokenPunct {
+			switch token.Text {
+			case "{", "[", "(":
+				depth++
+			case "}", "]", ")":
+				if depth == 0 {
+					return last, nil
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					return last, nil
+				}
+			}
+		}
+		last = i + 1
+	}
+	return 0, fmt.Errorf("unterminated expression")
+}
+
+// nextJSToken 跳过注释，返回下一个有效词法单元的下标
+func nextJSToken(tokens []JSToken, i int) int {
+	for i < len(tokens) && tokens[i].Kind == JSTokenComment {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,441 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONField 有序JSON对象中的一个字段
+type JSONField struct {
+	Key   string
+	Value interface{}
+}
+
+// JSONObject 保持字段顺序的JSON对象（encoding/json 的 map 会按键排序）
+type JSONObject []JSONField
+
+// MarshalJSON 按字段顺序输出JSON对象
+func (o JSONObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalJSONValue(field.Key, "", "")
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJSONValue(field.Value, "", "")
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(key)
+		buf.WriteByte(':')
+		buf.WriteString(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// JSONEditor 保持键顺序和原有格式的JSON编辑器
+// 只改写被操作的字段，其余内容（包括缩进和键顺序）原样保留；每次修改后都会校验结果是否为合法JSON
+type JSONEditor struct {
+	content string
+	indent  string // 文档使用的缩进单位
+	newline string // 文档使用的换行符
+}
+
+// jsonSpan JSON值在原文中的位置
+type jsonSpan struct {
+	start, end int // 值的范围 [start, end)
+	isObject   bool
+	fields     []jsonFieldSpan // 仅对象有效
+}
+
+// jsonFieldSpan 对象字段在原文中的位置
+type jsonFieldSpan struct {
+	key      string
+	keyStart int // 键的起始引号位置
+	value    *jsonSpan
+}
+
+// NewJSONEditor 创建JSON编辑器，内容不是合法JSON时返回错误
+func NewJSONEditor(content string) (*JSONEditor, error) {
+	if !json.Valid([]byte(content)) {
+		return nil, fmt.Errorf("content is not valid JSON")
+	}
+
+	editor := &JSONEditor{content: content, indent: "  ", newline: "\n"}
+	if strings.Contains(content, "\r\n") {
+		editor.newline = "\r\n"
+	}
+	root, err := editor.parse()
+	if err != nil {
+		return nil, err
+	}
+	if !root.isObject {
+		return nil, fmt.Errorf("root of JSON document must be an object")
+	}
+
+	// 根据根对象第一个字段的缩进推断缩进单位
+	if len(root.fields) > 0 {
+		if indent := lineIndentAt(content, root.fields[0].keyStart); indent != "" {
+			editor.indent = indent
+		}
+	}
+	return editor, nil
+}
+
+// String 获取编辑后的内容
+func (e *JSONEditor) String() string {
+	return e.content
+}
+
+// Has 判断指定路径的字段是否存在
+func (e *JSONEditor) Has(path ...string) bool {
+	root, err := e.parse()
+	if err != nil {
+		return false
+	}
+	_, err = findJSONPath(root, path)
+	return err == nil
+}
+
+// Set 设置指定路径字段的值：字段存在时替换其值，不存在时追加到父对象末尾（父对象必须存在）
+func (e *JSONEditor) Set(path []string, value interface{}) error {
+	if len(path) == 0 {
+		return fmt.Errorf("path is required")
+	}
+
+	root, err := e.parse()
+	if err != nil {
+		return err
+	}
+	parent, err := findJSONPath(root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	if !parent.isObject {
+		return fmt.Errorf("%s is not an object", strings.Join(path[:len(path)-1], "."))
+	}
+
+	key := path[len(path)-1]
+	for _, field := range parent.fields {
+		if field.key == key {
+			memberIndent := lineIndentAt(e.content, field.keyStart)
+			formatted, err := e.formatValue(value, memberIndent, e.isMultiline(parent))
+			if err != nil {
+				return err
+			}
+			return e.apply(e.content[:field.value.start] + formatted + e.content[field.value.end:])
+		}
+	}
+
+	return e.appendField(parent, key, value)
+}
+
+// Delete 删除指定路径的字段，返回字段是否存在
+func (e *JSONEditor) Delete(path ...string) (bool, error) {
+	if len(path) == 0 {
+		return false, fmt.Errorf("path is required")
+	}
+
+	root, err := e.parse()
+	if err != nil {
+		return false, err
+	}
+	parent, err := findJSONPath(root, path[:len(path)-1])
+	if err != nil || !parent.isObject {
+		return false, nil
+	}
+
+	key := path[len(path)-1]
+	for i, field := range parent.fields {
+		if field.key != key {
+			continue
+		}
+
+		var newContent string
+		switch {
+		case len(parent.fields) == 1:
+			// 唯一的字段：清空对象
+			newContent = e.content[:parent.start+1] + e.content[parent.end-1:]
+		case i < len(parent.fields)-1:
+			// 非最后一个字段：删除到下一个键为止（包括逗号和空白）
+			newContent = e.content[:field.keyStart] + e.content[parent.fields[i+1].keyStart:]
+		default:
+			// 最后一个字段：从上一个值的结尾开始删除（包括前面的逗号）
+			newContent = e.content[:parent.fields[i-1].value.end] + e.content[field.value.end:]
+		}
+		return true, e.apply(newContent)
+	}
+	return false, nil
+}
+
+// appendField 在对象末尾追加字段，沿用已有字段的缩进
+func (e *JSONEditor) appendField(parent *jsonSpan, key string, value interface{}) error {
+	keyText, err := marshalJSONValue(key, "", "")
+	if err != nil {
+		return err
+	}
+
+	if len(parent.fields) == 0 {
+		objectIndent := lineIndentAt(e.content, parent.start)
+		memberIndent := objectIndent + e.indent
+		formatted, err := e.formatValue(value, memberIndent, true)
+		if err != nil {
+			return err
+		}
+		fieldText := e.newline + memberIndent + keyText + ": " + formatted + e.newline + objectIndent
+		return e.apply(e.content[:parent.start+1] + fieldText + e.content[parent.end-1:])
+	}
+
+	last := parent.fields[len(parent.fields)-1]
+	leading := leadingWhitespace(e.content, last.keyStart)
+	multiline := strings.Contains(leading, "\n")
+
+	memberIndent := ""
+	if multiline {
+		memberIndent = leading[strings.LastIndex(leading, "\n")+1:]
+	}
+	formatted, err := e.formatValue(value, memberIndent, multiline)
+	if err != nil {
+		return err
+	}
+
+	separator := ": "
+	if !multiline {
+		separator = ":"
+	}
+	fieldText := "," + leading + keyText + separator + formatted
+	return e.apply(e.content[:last.value.end] + fieldText + e.content[last.value.end:])
+}
+
+// isMultiline 判断对象是否为多行格式
+func (e *JSONEditor) isMultiline(object *jsonSpan) bool {
+	return strings.Contains(e.content[object.start:object.end], "\n")
+}
+
+// formatValue 按文档缩进格式化新值
+func (e *JSONEditor) formatValue(value interface{}, memberIndent string, multiline bool) (string, error) {
+	if !multiline {
+		return marshalJSONValue(value, "", "")
+	}
+	formatted, err := marshalJSONValue(value, memberIndent, e.indent)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(formatted, "\n", e.newline), nil
+}
+
+// apply 校验并应用新内容，拒绝写入非法JSON
+func (e *JSONEditor) apply(newContent string) error {
+	if !json.Valid([]byte(newContent)) {
+		return fmt.Errorf("edit would produce invalid JSON")
+	}
+	e.content = newContent
+	return nil
+}
+
+// parse 解析当前内容，记录每个值和字段的位置
+func (e *JSONEditor) parse() (*jsonSpan, error) {
+	scanner := &jsonScanner{s: e.content}
+	root, err := scanner.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// findJSONPath 按键路径查找对象中的值
+func findJSONPath(root *jsonSpan, path []string) (*jsonSpan, error) {
+	current := root
+	for i, key := range path {
+		if !current.isObject {
+			return nil, fmt.Errorf("%s is not an object", strings.Join(path[:i], "."))
+		}
+		var next *jsonSpan
+		for _, field := range current.fields {
+			if field.key == key {
+				next = field.value
+				break
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("path %s not found", strings.Join(path[:i+1], "."))
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// marshalJSONValue 序列化值（不转义HTML字符，如脚本中的 &&）
+func marshalJSONValue(value interface{}, prefix, indent string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if indent != "" {
+		encoder.SetIndent(prefix, indent)
+	}
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// lineIndentAt 获取指定位置所在行的行首缩进
+func lineIndentAt(content string, pos int) string {
+	lineStart := strings.LastIndex(content[:pos], "\n") + 1
+	end := lineStart
+	for end < len(content) && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return content[lineStart:end]
+}
+
+// leadingWhitespace 获取指定位置之前的连续空白
+func leadingWhitespace(content string, pos int) string {
+	start := pos
+	for start > 0 && isJSONSpace(content[start-1]) {
+		start--
+	}
+	return content[start:pos]
+}
+
+// isJSONSpace 判断是否为JSON空白字符
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// jsonScanner 记录位置信息的JSON扫描器（输入已通过 json.Valid 校验）
+type jsonScanner struct {
+	s   string
+	pos int
+}
+
+// skipSpace 跳过空白字符
+func (p *jsonScanner) skipSpace() {
+	for p.pos < len(p.s) && isJSONSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+// parseValue 解析一个JSON值
+func (p *jsonScanner) parseValue() (*jsonSpan, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("unexpected end of JSON")
+	}
+
+	switch p.s[p.pos] {
+	case '{':
+		return p.parseObject()
+	case '[':
+		return p.parseArray()
+	case '"':
+		start := p.pos
+		if _, err := p.parseString(); err != nil {
+			return nil, err
+		}
+		return &jsonSpan{start: start, end: p.pos}, nil
+	default:
+		start := p.pos
+		for p.pos < len(p.s) && !isJSONSpace(p.s[p.pos]) && !strings.ContainsRune(",]}", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		return &jsonSpan{start: start, end: p.pos}, nil
+	}
+}
+
+// parseObject 解析对象并记录字段位置
+func (p *jsonScanner) parseObject() (*jsonSpan, error) {
+	span := &jsonSpan{start: p.pos, isObject: true}
+	p.pos++ // {
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("unexpected end of JSON object")
+		}
+		if p.s[p.pos] == '}' {
+			p.pos++
+			span.end = p.pos
+			return span, nil
+		}
+		if p.s[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+
+		keyStart := p.pos
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != ':' {
+			return nil, fmt.Errorf("expected ':' after key %q", key)
+		}
+		p.pos++
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		span.fields = append(span.fields, jsonFieldSpan{key: key, keyStart: keyStart, value: value})
+	}
+}
+
+// parseArray 解析数组（只记录整体位置）
+func (p *jsonScanner) parseArray() (*jsonSpan, error) {
+	span := &jsonSpan{start: p.pos}
+	p.pos++ // [
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("unexpected end of JSON array")
+		}
+		if p.s[p.pos] == ']' {
+			p.pos++
+			span.end = p.pos
+			return span, nil
+		}
+		if p.s[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if _, err := p.parseValue(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseString 解析字符串并返回解码后的值
+func (p *jsonScanner) parseString() (string, error) {
+	start := p.pos
+	if p.pos >= len(p.s) || p.s[p.pos] != '"' {
+		return "", fmt.Errorf("expected string at offset %d", p.pos)
+	}
+	p.pos++
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			var value string
+			if err := json.Unmarshal([]byte(p.s[start:p.pos]), &value); err != nil {
+				return "", err
+			}
+			return value, nil
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated string at offset %d", start)
+}
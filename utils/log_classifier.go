@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// 输出行的级别
+const (
+	LogSeverityError = "error"
+	LogSeverityWarn  = "warn"
+	LogSeverityInfo  = "info"
+)
+
+// 规则适用的输出流
+const (
+	LogStreamStdout = "stdout"
+	LogStreamStderr = "stderr"
+)
+
+// maxLogMatches LogSummary 中保留的错误行数，超出的只计数
+const maxLogMatches = 50
+
+// LogRule 输出分类规则，按顺序匹配，第一条命中的规则决定该行的级别
+type LogRule struct {
+	Name     string `json:"name"`
+	Pattern  string `json:"pattern"`          // 正则表达式（RE2语法），(?i) 表示忽略大小写
+	Severity string `json:"severity"`         // error、warn、info
+	Stream   string `json:"stream,omitempty"` // 只匹配 stdout 或 stderr，为空时都匹配
+
+	re *regexp.Regexp
+}
+
+// LogClassification 单行输出的分类结果
+type LogClassification struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule,omitempty"` // 命中的规则，未命中时为空（级别为 info）
+}
+
+// LogClassifier 构建和部署输出的分类器（规则由LogRuleService从配置文件加载）
+type LogClassifier struct {
+	rules []LogRule
+	mutex sync.RWMutex
+}
+
+var (
+	logClassifier     *LogClassifier
+	logClassifierOnce sync.Once
+)
+
+// GetLogClassifier 获取全局输出分类器，未加载配置文件时使用内置规则
+func GetLogClassifier() *LogClassifier {
+	logClassifierOnce.Do(func() {
+		logClassifier = &LogClassifier{}
+		if err := logClassifier.Replace(DefaultLogRules()); err != nil {
+			panic(err)
+		}
+	})
+	return logClassifier
+}
+
+// DefaultLogRules 内置的分类规则：先匹配明确的错误，再匹配警告和git、yarn的正常进度输出
+func DefaultLogRules() []LogRule {
+	return []LogRule{
+		{Name: "fatal", Pattern: `(?i)^\s*(fatal|error)(\[[^\]]*\])?:`, Severity: LogSeverityError},
+		{Name: "npm-error", Pattern: `(?i)^\s*npm (ERR!|error)`, Severity: LogSeverityError},
+		{Name: "yarn-error", Pattern: `^\s*error\s`, Severity: LogSeverityError},
+		{Name: "build-failed", Pattern: `(?i)\b(build|compilation|command|test) failed\b|\bfailed (with exit code|to)\b`, Severity: LogSeverityError},
+		{Name: "nginx-error", Pattern: `\[(emerg|alert|crit|error)\]`, Severity: LogSeverityError},
+		{Name: "error-label", Pattern: `(?i)\b(\w*error|failed|fatal)\s*[:：]`, Severity: LogSeverityError},
+		{Name: "missing-file", Pattern: `(?i)no such file or directory|cannot find module|command not found|permission denied`, Severity: LogSeverityError},
+		{Name: "crash", Pattern: `(?i)\b(panic:|segmentation fault|core dumped|traceback|unhandled( promise)? rejection|out of memory)`, Severity: LogSeverityError},
+		{Name: "timeout", Pattern: `(?i)\b(timed out|connection refused|killed)\b`, Severity: LogSeverityError},
+		{Name: "warning", Pattern: `(?i)^\s*(warning|warn)\b|\[warn\]|⚠️`, Severity: LogSeverityWarn},
+		{Name: "deprecated", Pattern: `(?i)\bdeprecated\b`, Severity: LogSeverityWarn},
+		{Name: "git-progress", Pattern: `(?i)^\s*(remote:|cloning into|receiving objects|resolving deltas|counting objects|compressing objects|writing objects|enumerating objects|already on|switched to|your branch is|from |head is now at)`, Severity: LogSeverityInfo},
+	}
+}
+
+// CompileLogRules 校验并编译规则
+func CompileLogRules(rules []LogRule) ([]LogRule, error) {
+	compiled := make([]LogRule, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d: name is required", i+1)
+		}
+		switch rule.Severity {
+		case LogSeverityError, LogSeverityWarn, LogSeverityInfo:
+		default:
+			return nil, fmt.Errorf("rule %s: invalid severity %q", rule.Name, rule.Severity)
+		}
+		switch rule.Stream {
+		case "", LogStreamStdout, LogStreamStderr:
+		default:
+			return nil, fmt.Errorf("rule %s: invalid stream %q", rule.Name, rule.Stream)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: invalid pattern: %v", rule.Name, err)
+		}
+		rule.re = re
+		compiled[i] = rule
+	}
+	return compiled, nil
+}
+
+// Replace 校验并替换全部规则
+func (c *LogClassifier) Replace(rules []LogRule) error {
+	compiled, err := CompileLogRules(rules)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rules = compiled
+	return nil
+}
+
+// Rules 获取当前规则
+func (c *LogClassifier) Rules() []LogRule {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return append([]LogRule(nil), c.rules...)
+}
+
+// Classify 对一行输出分类，没有规则命中时为 info
+func (c *LogClassifier) Classify(line string, isStderr bool) LogClassification {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return classifyLine(c.rules, line, isStderr)
+}
+
+// ClassifyWith 使用指定规则对一行输出分类（用于测试未保存的规则）
+func ClassifyWith(rules []LogRule, line string, isStderr bool) LogClassification {
+	return classifyLine(rules, line, isStderr)
+}
+
+// classifyLine 按顺序匹配规则
+func classifyLine(rules []LogRule, line string, isStderr bool) LogClassification {
+	stream := LogStreamStdout
+	if isStderr {
+		stream = LogStreamStderr
+	}
+	for _, rule := range rules {
+		if rule.Stream != "" && rule.Stream != stream {
+			continue
+		}
+		if rule.re.MatchString(line) {
+			return LogClassification{Severity: rule.Severity, Rule: rule.Name}
+		}
+	}
+	return LogClassification{Severity: LogSeverityInfo}
+}
+
+// LogMatch 命中错误规则的输出行
+type LogMatch struct {
+	Line string `json:"line"`
+	Rule string `json:"rule"`
+}
+
+// LogSummary 一次构建或部署的输出分类汇总，附加到任务结果
+type LogSummary struct {
+	Errors   int        `json:"errors"`
+	Warnings int        `json:"warnings"`
+	Matches  []LogMatch `json:"matches"` // 错误行（最多保留 maxLogMatches 条）
+
+	mutex sync.Mutex
+}
+
+// NewLogSummary 创建输出分类汇总
+func NewLogSummary() *LogSummary {
+	return &LogSummary{Matches: []LogMatch{}}
+}
+
+// Add 记录一行输出的分类结果，nil 时忽略
+func (s *LogSummary) Add(line string, classification LogClassification) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch classification.Severity {
+	case LogSeverityError:
+		s.Errors++
+		if len(s.Matches) < maxLogMatches {
+			s.Matches = append(s.Matches, LogMatch{Line: line, Rule: classification.Rule})
+		}
+	case LogSeverityWarn:
+		s.Warnings++
+	}
+}
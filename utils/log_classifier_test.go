@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// logFixture 一行真实的构建或部署输出及其期望的分类
+type logFixture struct {
+	line     string
+	stderr   bool
+	severity string
+	rule     string
+}
+
+// yarn install / uni build 的输出
+var yarnFixtures = []logFixture{
+	{line: "yarn install v1.22.19", severity: LogSeverityInfo},
+	{line: "[1/4] Resolving packages...", severity: LogSeverityInfo},
+	{line: "warning package.json: No license field", stderr: true, severity: LogSeverityWarn, rule: "warning"},
+	{line: `warning " > vue-loader@15.9.8" has unmet peer dependency "css-loader@*".`, stderr: true, severity: LogSeverityWarn, rule: "warning"},
+	{line: "npm WARN deprecated request@2.88.2: request has been deprecated, see https://github.com/request/request/issues/3142", stderr: true, severity: LogSeverityWarn, rule: "deprecated"},
+	{line: "(node:21874) [DEP0040] DeprecationWarning: The `punycode` module is deprecated.", stderr: true, severity: LogSeverityWarn, rule: "deprecated"},
+	// yarn-error 在 error-label 之前：同时包含 "Error:" 的行按 yarn-error 归类
+	{line: "error Error: Cannot find module '@dcloudio/vue-cli-plugin-uni/packages/webpack'", stderr: true, severity: LogSeverityError, rule: "yarn-error"},
+	{line: "error Command failed with exit code 1.", stderr: true, severity: LogSeverityError, rule: "yarn-error"},
+	{line: "info Visit https://yarnpkg.com/en/docs/cli/run for documentation about this command.", severity: LogSeverityInfo},
+	{line: "npm ERR! code ELIFECYCLE", stderr: true, severity: LogSeverityError, rule: "npm-error"},
+	{line: "Module build failed (from ./node_modules/sass-loader/dist/cjs.js):", severity: LogSeverityError, rule: "build-failed"},
+	{line: "SyntaxError: Unexpected token (12:4)", severity: LogSeverityError, rule: "error-label"},
+	{line: "TypeError: Cannot read properties of undefined (reading 'appid')", severity: LogSeverityError, rule: "error-label"},
+	{line: "[vite]: Rollup failed to resolve import \"@/utils/pay\" from \"src/pages/index/index.vue\".", severity: LogSeverityError, rule: "build-failed"},
+	{line: " DONE  Build complete. The dist/build/h5 directory is ready to be deployed.", severity: LogSeverityInfo},
+	{line: "Done in 86.41s.", severity: LogSeverityInfo},
+}
+
+// git 检出和推送的输出（git 把进度写到 stderr）
+var gitFixtures = []logFixture{
+	{line: "Cloning into 'funNovel'...", stderr: true, severity: LogSeverityInfo, rule: "git-progress"},
+	{line: "remote: Enumerating objects: 1532, done.", stderr: true, severity: LogSeverityInfo, rule: "git-progress"},
+	{line: "remote: Counting objects: 100% (1532/1532), done.", stderr: true, severity: LogSeverityInfo, rule: "git-progress"},
+	{line: "Receiving objects: 100% (1532/1532), 4.12 MiB | 8.20 MiB/s, done.", stderr: true, severity: LogSeverityInfo, rule: "git-progress"},
+	{line: "From git.example.com:front/funNovel", stderr: true, severity: LogSeverityInfo, rule: "git-progress"},
+	{line: "Switched to branch 'uni/funNovel/devNew'", stderr: true, severity: LogSeverityInfo, rule: "git-progress"},
+	{line: "Your branch is up to date with 'origin/uni/funNovel/devNew'.", severity: LogSeverityInfo, rule: "git-progress"},
+	// remote: 开头但内容是错误时，错误规则优先于 git-progress
+	{line: "remote: error: GH006: Protected branch update failed for refs/heads/master.", stderr: true, severity: LogSeverityError, rule: "error-label"},
+	{line: "fatal: could not read Username for 'https://git.example.com': terminal prompts disabled", stderr: true, severity: LogSeverityError, rule: "fatal"},
+	{line: "error: pathspec 'master_ks' did not match any file(s) known to git", stderr: true, severity: LogSeverityError, rule: "fatal"},
+	{line: "[master 3f2a9c1] build: tth5-xingchen 1.0.20261016", severity: LogSeverityInfo},
+}
+
+// nginx 配置检查的输出
+var nginxFixtures = []logFixture{
+	{line: "nginx: the configuration file /etc/nginx/nginx.conf syntax is ok", stderr: true, severity: LogSeverityInfo},
+	{line: "nginx: configuration file /etc/nginx/nginx.conf test is successful", stderr: true, severity: LogSeverityInfo},
+	{line: `nginx: [emerg] unknown directive "servr_name" in /etc/nginx/conf.d/xingchen.conf:3`, stderr: true, severity: LogSeverityError, rule: "nginx-error"},
+	{line: "nginx: configuration file /etc/nginx/nginx.conf test failed", stderr: true, severity: LogSeverityError, rule: "build-failed"},
+	{line: `nginx: [warn] conflicting server name "h5.example.com" on 0.0.0.0:80, ignored`, stderr: true, severity: LogSeverityWarn, rule: "warning"},
+}
+
+// 远程部署（ssh、rsync）的输出
+var deployFixtures = []logFixture{
+	{line: "sending incremental file list", severity: LogSeverityInfo},
+	{line: "sent 1,204,311 bytes  received 2,114 bytes  804,283.33 bytes/sec", severity: LogSeverityInfo},
+	// error-label 在 missing-file 之前：rsync 的 "failed: No such file" 按 error-label 归类
+	{line: `rsync: change_dir "/opt/website/tth5-xingchen" failed: No such file or directory (2)`, stderr: true, severity: LogSeverityError, rule: "error-label"},
+	{line: "ssh: connect to host 10.0.3.21 port 22: Connection refused", stderr: true, severity: LogSeverityError, rule: "timeout"},
+	{line: "root@10.0.3.21: Permission denied (publickey,password).", stderr: true, severity: LogSeverityError, rule: "missing-file"},
+	{line: "bash: pm2: command not found", stderr: true, severity: LogSeverityError, rule: "missing-file"},
+	{line: "⚠️ 远程目录已存在，覆盖部署", severity: LogSeverityWarn, rule: "warning"},
+}
+
+func TestDefaultLogRules(t *testing.T) {
+	rules, err := CompileLogRules(DefaultLogRules())
+	if err != nil {
+		t.Fatalf("default rules do not compile: %v", err)
+	}
+
+	groups := map[string][]logFixture{
+		"yarn":   yarnFixtures,
+		"git":    gitFixtures,
+		"nginx":  nginxFixtures,
+		"deploy": deployFixtures,
+	}
+	for name, fixtures := range groups {
+		t.Run(name, func(t *testing.T) {
+			for _, fixture := range fixtures {
+				got := ClassifyWith(rules, fixture.line, fixture.stderr)
+				if got.Severity != fixture.severity || got.Rule != fixture.rule {
+					t.Errorf("%q: got %s/%s, want %s/%s", fixture.line, got.Severity, got.Rule, fixture.severity, fixture.rule)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyStream(t *testing.T) {
+	rules, err := CompileLogRules([]LogRule{
+		{Name: "stderr-only", Pattern: `(?i)oops`, Severity: LogSeverityError, Stream: LogStreamStderr},
+		{Name: "any", Pattern: `(?i)oops`, Severity: LogSeverityWarn},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ClassifyWith(rules, "oops", true); got.Rule != "stderr-only" {
+		t.Errorf("stderr: got rule %q, want stderr-only", got.Rule)
+	}
+	if got := ClassifyWith(rules, "oops", false); got.Rule != "any" {
+		t.Errorf("stdout: got rule %q, want any", got.Rule)
+	}
+}
+
+func TestLogClassifierReplace(t *testing.T) {
+	classifier := &LogClassifier{}
+	if err := classifier.Replace([]LogRule{{Name: "custom", Pattern: `^BOOM`, Severity: LogSeverityError}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := classifier.Classify("BOOM at step 3", false); got.Rule != "custom" {
+		t.Errorf("got rule %q, want custom", got.Rule)
+	}
+
+	// 无效规则不替换当前规则
+	if err := classifier.Replace([]LogRule{{Name: "bad", Pattern: `(`, Severity: LogSeverityError}}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+	if got := classifier.Classify("BOOM at step 3", false); got.Rule != "custom" {
+		t.Errorf("rules replaced by invalid rules: got rule %q", got.Rule)
+	}
+}
+
+func TestCompileLogRulesRejectsInvalid(t *testing.T) {
+	cases := map[string]LogRule{
+		"empty name":   {Pattern: `x`, Severity: LogSeverityError},
+		"bad severity": {Name: "r", Pattern: `x`, Severity: "fatal"},
+		"bad stream":   {Name: "r", Pattern: `x`, Severity: LogSeverityError, Stream: "stdin"},
+		"bad pattern":  {Name: "r", Pattern: `(?P<x`, Severity: LogSeverityError},
+	}
+	for name, rule := range cases {
+		if _, err := CompileLogRules([]LogRule{rule}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLogSummary(t *testing.T) {
+	summary := NewLogSummary()
+	for i := 0; i < maxLogMatches+10; i++ {
+		summary.Add(fmt.Sprintf("error line %d", i), LogClassification{Severity: LogSeverityError, Rule: "fatal"})
+	}
+	summary.Add("warning line", LogClassification{Severity: LogSeverityWarn, Rule: "warning"})
+	summary.Add("info line", LogClassification{Severity: LogSeverityInfo})
+
+	if summary.Errors != maxLogMatches+10 {
+		t.Errorf("errors: got %d, want %d", summary.Errors, maxLogMatches+10)
+	}
+	if summary.Warnings != 1 {
+		t.Errorf("warnings: got %d, want 1", summary.Warnings)
+	}
+	if len(summary.Matches) != maxLogMatches {
+		t.Fatalf("matches: got %d, want %d", len(summary.Matches), maxLogMatches)
+	}
+	if last := summary.Matches[maxLogMatches-1]; !strings.HasSuffix(last.Line, fmt.Sprintf(" %d", maxLogMatches-1)) || last.Rule != "fatal" {
+		t.Errorf("last kept match: got %+v", last)
+	}
+
+	// nil 汇总忽略
+	var none *LogSummary
+	none.Add("error line", LogClassification{Severity: LogSeverityError})
+}
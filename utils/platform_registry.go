@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"brand-config-api/models"
+)
+
+// PlatformRegistry 端类型注册表（内存缓存，由PlatformService从数据库加载）
+type PlatformRegistry struct {
+	platforms map[string]models.Platform
+	mutex     sync.RWMutex
+}
+
+var (
+	platformRegistry     *PlatformRegistry
+	platformRegistryOnce sync.Once
+)
+
+// GetPlatformRegistry 获取全局端类型注册表
+func GetPlatformRegistry() *PlatformRegistry {
+	platformRegistryOnce.Do(func() {
+		platformRegistry = &PlatformRegistry{
+			platforms: make(map[string]models.Platform),
+		}
+		platformRegistry.Replace(DefaultPlatforms())
+	})
+	return platformRegistry
+}
+
+// DefaultPlatforms 内置的端类型定义（数据库为空时用于初始化）
+func DefaultPlatforms() []models.Platform {
+	return []models.Platform{
+		{
+			Code:         "h5",
+			Name:         "H5",
+			UniPlatform:  "h5",
+			DefineMacros: []string{"MP-H5"},
+			Enabled:      true,
+		},
+		{
+			Code:             "tth5",
+			Name:             "抖音H5",
+			UniPlatform:      "h5",
+			DefineMacros:     []string{"MP-TTH5"},
+			ExtraHost:        "tt",
+			RequiredSections: []string{"novel_config"},
+			RequiredFields:   []string{"novel_config.tt_jump_home_url", "novel_config.tt_login_callback_domain"},
+			Enabled:          true,
+		},
+		{
+			Code:         "ksh5",
+			Name:         "快手H5",
+			UniPlatform:  "h5",
+			DefineMacros: []string{"MP-KSH5"},
+			ExtraHost:    "ks",
+			Enabled:      true,
+		},
+		{
+			Code:        "tt",
+			Name:        "抖音小程序",
+			UniPlatform: "mp-toutiao",
+			IsExtra:     true,
+			Enabled:     true,
+		},
+		{
+			Code:        "ks",
+			Name:        "快手小程序",
+			UniPlatform: "mp-kuaishou",
+			IsExtra:     true,
+			Enabled:     true,
+		},
+		{
+			Code:        "wx",
+			Name:        "微信小程序",
+			UniPlatform: "mp-weixin",
+			IsExtra:     true,
+			Enabled:     true,
+		},
+		{
+			Code:        "bd",
+			Name:        "百度小程序",
+			UniPlatform: "mp-baidu",
+			IsExtra:     true,
+			Enabled:     true,
+		},
+	}
+}
+
+// Replace 用新的端类型列表替换注册表内容
+func (r *PlatformRegistry) Replace(platforms []models.Platform) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.platforms = make(map[string]models.Platform, len(platforms))
+	for _, platform := range platforms {
+		r.platforms[platform.Code] = platform
+	}
+}
+
+// Get 获取指定host的端类型定义
+func (r *PlatformRegistry) Get(host string) (models.Platform, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	platform, exists := r.platforms[host]
+	return platform, exists
+}
+
+// IsCreatableHost 判断host是否可以直接创建网站（已启用且不是额外端）
+func (r *PlatformRegistry) IsCreatableHost(host string) bool {
+	platform, exists := r.Get(host)
+	return exists && platform.Enabled && !platform.IsExtra
+}
+
+// CreatableHosts 获取所有可直接创建网站的host（按字母排序）
+func (r *PlatformRegistry) CreatableHosts() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var hosts []string
+	for code, platform := range r.platforms {
+		if platform.Enabled && !platform.IsExtra {
+			hosts = append(hosts, code)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// UniPlatform 获取host对应的uni平台标识，未注册时返回h5
+func (r *PlatformRegistry) UniPlatform(host string) string {
+	if platform, exists := r.Get(host); exists && platform.UniPlatform != "" {
+		return platform.UniPlatform
+	}
+	return "h5"
+}
+
+// DefineMacros 获取host对应的平台宏
+func (r *PlatformRegistry) DefineMacros(host string) []string {
+	if platform, exists := r.Get(host); exists {
+		return platform.DefineMacros
+	}
+	return []string{"MP-" + strings.ToUpper(host)}
+}
+
+// ExtraHost 获取host对应的额外端，没有时返回空字符串
+func (r *PlatformRegistry) ExtraHost(host string) string {
+	if platform, exists := r.Get(host); exists {
+		return platform.ExtraHost
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package rollback
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// directorySize 统计目录下所有普通文件的总大小
+func directorySize(root string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// snapshotDirectory 将目录完整复制到快照位置，超过大小上限（字节，0表示不限制）时返回错误
+func snapshotDirectory(src, dst string, limit int64) error {
+	size, err := directorySize(src)
+	if err != nil {
+		return fmt.Errorf("failed to measure directory %s: %v", src, err)
+	}
+	if limit > 0 && size > limit {
+		return fmt.Errorf("directory %s is too large to snapshot: %d MB exceeds the limit of %d MB (ROLLBACK_SNAPSHOT_LIMIT_MB)", src, size>>20, limit>>20)
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return fmt.Errorf("failed to snapshot directory %s: %v", src, err)
+	}
+	return nil
+}
+
+// restoreDirectory 用快照替换目标目录，恢复后与快照完全一致
+func restoreDirectory(snapshot, target string) error {
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to clear directory %s: %v", target, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory of %s: %v", target, err)
+	}
+	if err := copyTree(snapshot, target); err != nil {
+		return fmt.Errorf("failed to restore directory %s: %v", target, err)
+	}
+	return nil
+}
+
+// copyTree 递归复制目录，保留文件内容、权限、修改时间和符号链接
+func copyTree(src, dst string) error {
+	type dirMode struct {
+		path string
+		info fs.FileInfo
+	}
+	var dirs []dirMode
+
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			// 先以可写权限创建，复制完成后再恢复原权限
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirMode{path: target, info: info})
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := copyRegularFile(path, target, info); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported file type: %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 由深到浅恢复目录权限和修改时间
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].info.Mode().Perm()); err != nil {
+			return err
+		}
+		if err := os.Chtimes(dirs[i].path, dirs[i].info.ModTime(), dirs[i].info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRegularFile 复制普通文件并保留权限和修改时间
+func copyRegularFile(src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"brand-config-api/config"
@@ -23,7 +25,10 @@ type FileRollbacker interface {
 type FileRollback struct {
 	config       *config.Config
 	backupFiles  map[string]string // 文件路径 -> 原始内容
+	backupDirs   map[string]string // 目录路径 -> 快照目录
 	createdFiles []string          // 新创建的文件列表
+	journal      *Journal          // 磁盘上的预写日志，第一次备份时创建
+	snapshotDir  string            // 未启用预写日志时存放目录快照的临时目录
 }
 
 // NewFileRollback 创建文件回滚实例
@@ -31,6 +36,7 @@ func NewFileRollback(cfg *config.Config) *FileRollback {
 	return &FileRollback{
 		config:       cfg,
 		backupFiles:  make(map[string]string),
+		backupDirs:   make(map[string]string),
 		createdFiles: make([]string, 0),
 	}
 }
@@ -39,24 +45,47 @@ func NewFileRollback(cfg *config.Config) *FileRollback {
 func (fr *FileRollback) Backup(path, content string) error {
 	log.Printf("🔄 文件回滚器：备份文件 %s", path)
 
+	// 同一事务中重复备份时保留第一次的状态，回滚时才能恢复到事务开始前
+	if _, exists := fr.backupFiles[path]; exists {
+		return nil
+	}
+	if _, exists := fr.backupDirs[path]; exists {
+		return nil
+	}
+	for _, createdPath := range fr.createdFiles {
+		if createdPath == path {
+			return nil
+		}
+	}
+
 	// 检查路径是否存在
 	stat, err := os.Stat(path)
 	if err == nil {
 		if stat.IsDir() {
-			// 是目录，标记为需要删除的目录
-			fr.createdFiles = append(fr.createdFiles, path)
-			log.Printf("📝 标记为需要删除的目录: %s", path)
+			// 是目录，完整快照到临时区域，回滚时按快照恢复
+			snapshot, err := fr.snapshotDirectory(path)
+			if err != nil {
+				return err
+			}
+			fr.backupDirs[path] = snapshot
+			log.Printf("✅ 目录快照成功: %s", path)
 		} else {
 			// 是文件，读取原始内容作为备份
 			originalContent, err := os.ReadFile(path)
 			if err != nil {
 				return fmt.Errorf("failed to read file for backup: %v", err)
 			}
+			if err := fr.journalSnapshot(path, originalContent); err != nil {
+				return err
+			}
 			fr.backupFiles[path] = string(originalContent)
 			log.Printf("✅ 文件备份成功: %s (内容长度: %d)", path, len(originalContent))
 		}
 	} else {
 		// 路径不存在，标记为新创建的文件
+		if err := fr.journalCreated(path); err != nil {
+			return err
+		}
 		fr.createdFiles = append(fr.createdFiles, path)
 		log.Printf("📝 标记为新创建文件: %s", path)
 	}
@@ -64,6 +93,93 @@ func (fr *FileRollback) Backup(path, content string) error {
 	return nil
 }
 
+// ensureJournal 第一次备份时在磁盘上创建预写日志，未配置日志目录时不记录
+func (fr *FileRollback) ensureJournal() (*Journal, error) {
+	if fr.journal != nil || fr.config == nil || fr.config.RollbackJournalDir == "" {
+		return fr.journal, nil
+	}
+	journal, err := newJournal(fr.config.RollbackJournalDir)
+	if err != nil {
+		return nil, err
+	}
+	fr.journal = journal
+	return journal, nil
+}
+
+// journalSnapshot 修改文件前把原始内容写入预写日志
+func (fr *FileRollback) journalSnapshot(path string, content []byte) error {
+	journal, err := fr.ensureJournal()
+	if err != nil || journal == nil {
+		return err
+	}
+	return journal.recordSnapshot(path, content)
+}
+
+// journalCreated 创建文件前把路径写入预写日志
+func (fr *FileRollback) journalCreated(path string) error {
+	journal, err := fr.ensureJournal()
+	if err != nil || journal == nil {
+		return err
+	}
+	return journal.recordCreated(path)
+}
+
+// snapshotDirectory 为已存在的目录创建快照，启用预写日志时快照保存在日志目录中
+func (fr *FileRollback) snapshotDirectory(path string) (string, error) {
+	var limit int64
+	if fr.config != nil {
+		limit = int64(fr.config.RollbackSnapshotLimitMB) << 20
+	}
+
+	journal, err := fr.ensureJournal()
+	if err != nil {
+		return "", err
+	}
+	if journal != nil {
+		return journal.recordDirSnapshot(path, limit)
+	}
+
+	if fr.snapshotDir == "" {
+		dir, err := os.MkdirTemp("", "rollback-snapshot-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create snapshot directory: %v", err)
+		}
+		fr.snapshotDir = dir
+	}
+	snapshot := filepath.Join(fr.snapshotDir, fmt.Sprintf("%04d.dir", len(fr.backupDirs)))
+	if err := snapshotDirectory(path, snapshot, limit); err != nil {
+		return "", err
+	}
+	return snapshot, nil
+}
+
+// markJournal 更新预写日志状态
+func (fr *FileRollback) markJournal(status, message string) {
+	if fr.journal == nil {
+		return
+	}
+	if err := fr.journal.setStatus(status, message); err != nil {
+		log.Printf("⚠️ 更新回滚日志状态失败: %v", err)
+	}
+}
+
+// closeJournal 事务结束（提交或回滚成功）后删除预写日志和目录快照
+func (fr *FileRollback) closeJournal() {
+	if fr.snapshotDir != "" {
+		if err := os.RemoveAll(fr.snapshotDir); err != nil {
+			log.Printf("⚠️ 删除目录快照失败: %v", err)
+		}
+		fr.snapshotDir = ""
+	}
+	if fr.journal == nil {
+		return
+	}
+	if err := fr.journal.remove(); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	fr.journal = nil
+}
+
 // Restore 恢复文件内容
 func (fr *FileRollback) Restore(path string) error {
 	// 检查是否有备份
@@ -75,6 +191,13 @@ func (fr *FileRollback) Restore(path string) error {
 			return fmt.Errorf("failed to restore file: %v", err)
 		}
 		delete(fr.backupFiles, path)
+	} else if snapshot, hasSnapshot := fr.backupDirs[path]; hasSnapshot {
+		// 用快照替换目录
+		log.Printf("📁 恢复目录: %s", path)
+		if err := restoreDirectory(snapshot, path); err != nil {
+			return err
+		}
+		delete(fr.backupDirs, path)
 	} else {
 		// 检查是否是新创建的文件或目录
 		for i, createdPath := range fr.createdFiles {
@@ -106,7 +229,7 @@ func (fr *FileRollback) Restore(path string) error {
 // Rollback 执行文件回滚
 func (fr *FileRollback) Rollback() error {
 	log.Printf("🔄 文件回滚器：开始回滚所有文件操作")
-	log.Printf("📊 需要回滚的文件数量: 备份文件=%d, 新创建文件=%d", len(fr.backupFiles), len(fr.createdFiles))
+	log.Printf("📊 需要回滚的文件数量: 备份文件=%d, 目录快照=%d, 新创建文件=%d", len(fr.backupFiles), len(fr.backupDirs), len(fr.createdFiles))
 
 	// 打印备份文件列表
 	if len(fr.backupFiles) > 0 {
@@ -127,6 +250,23 @@ func (fr *FileRollback) Rollback() error {
 	var errors []error
 	var successCount int
 
+	// 先由浅到深恢复目录快照，再恢复单独备份的文件（文件备份可能早于所在目录的快照）
+	dirs := make([]string, 0, len(fr.backupDirs))
+	for path := range fr.backupDirs {
+		dirs = append(dirs, path)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], string(os.PathSeparator)) < strings.Count(dirs[j], string(os.PathSeparator))
+	})
+	for _, path := range dirs {
+		if err := fr.Restore(path); err != nil {
+			errors = append(errors, fmt.Errorf("failed to restore directory %s: %v", path, err))
+			log.Printf("❌ 目录恢复失败: %s - %v", path, err)
+		} else {
+			successCount++
+		}
+	}
+
 	// 恢复所有备份的文件
 	for path := range fr.backupFiles {
 		if err := fr.Restore(path); err != nil {
@@ -225,13 +365,15 @@ func (fr *FileRollback) sortPathsByDepth(paths []string) []string {
 // Clear 清空所有备份信息
 func (fr *FileRollback) Clear() error {
 	fr.backupFiles = make(map[string]string)
+	fr.backupDirs = make(map[string]string)
 	fr.createdFiles = fr.createdFiles[:0]
+	fr.closeJournal()
 	return nil
 }
 
 // GetBackupCount 获取备份文件数量
 func (fr *FileRollback) GetBackupCount() int {
-	return len(fr.backupFiles)
+	return len(fr.backupFiles) + len(fr.backupDirs)
 }
 
 // GetCreatedFileCount 获取新创建文件数量
@@ -241,6 +383,9 @@ func (fr *FileRollback) GetCreatedFileCount() int {
 
 // HasBackup 检查指定文件是否已有备份
 func (fr *FileRollback) HasBackup(filePath string) bool {
+	if _, exists := fr.backupDirs[filePath]; exists {
+		return true
+	}
 	_, exists := fr.backupFiles[filePath]
 	return exists
 }
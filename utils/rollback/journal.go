@@ -0,0 +1,332 @@
+package rollback
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"brand-config-api/config"
+)
+
+// 回滚日志状态
+const (
+	JournalStatusActive         = "active"          // 事务进行中，进程退出时文件改动需要回滚
+	JournalStatusCommitting     = "committing"      // 数据库正在提交，是否已提交需要人工确认
+	JournalStatusRollbackFailed = "rollback_failed" // 回滚未能完成，需要人工处理
+)
+
+// 回滚日志记录的动作
+const (
+	JournalActionRestore    = "restore"     // 用快照恢复原始内容
+	JournalActionRestoreDir = "restore_dir" // 用目录快照替换整个目录
+	JournalActionDelete     = "delete"      // 删除新创建的文件或目录
+)
+
+const (
+	journalFileName        = "journal.json"  // 日志状态，状态变化时整体重写
+	journalEntriesFileName = "entries.jsonl" // 文件记录，每次备份追加一行
+)
+
+// currentInstance 当前进程实例标识，用于区分本进程正在使用的日志和遗留日志
+var currentInstance = newJournalID()
+
+// JournalEntry 回滚日志中的一条文件记录
+type JournalEntry struct {
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	Snapshot string `json:"snapshot,omitempty"` // 原始内容的快照文件名（相对于日志目录）
+}
+
+// Journal 文件回滚预写日志
+// 每次修改文件前先把原始内容（或新建路径）追加写入磁盘，进程中途退出后可以据此回滚
+type Journal struct {
+	ID        string         `json:"id"`
+	Instance  string         `json:"instance"`
+	PID       int            `json:"pid"`
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	StartedAt time.Time      `json:"started_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Entries   []JournalEntry `json:"entries"`
+
+	dir string
+}
+
+// newJournalID 生成日志ID：时间戳加随机后缀
+func newJournalID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// newJournal 在日志目录下创建一个新的回滚日志
+func newJournal(baseDir string) (*Journal, error) {
+	now := time.Now()
+	journal := &Journal{
+		ID:        newJournalID(),
+		Instance:  currentInstance,
+		PID:       os.Getpid(),
+		Status:    JournalStatusActive,
+		StartedAt: now,
+		UpdatedAt: now,
+		Entries:   make([]JournalEntry, 0),
+	}
+	journal.dir = filepath.Join(baseDir, journal.ID)
+
+	if err := os.MkdirAll(journal.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create rollback journal directory: %v", err)
+	}
+	if err := journal.save(); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// IsPending 判断日志是否需要处理：非本进程遗留的日志，或回滚失败的日志
+func (j *Journal) IsPending() bool {
+	return j.Instance != currentInstance || j.Status == JournalStatusRollbackFailed
+}
+
+// recordSnapshot 记录文件的原始内容，快照落盘后才更新日志
+func (j *Journal) recordSnapshot(path string, content []byte) error {
+	name := fmt.Sprintf("%04d.snapshot", len(j.Entries))
+	if err := writeFileSync(filepath.Join(j.dir, name), content); err != nil {
+		return fmt.Errorf("failed to write rollback snapshot: %v", err)
+	}
+	return j.appendEntry(JournalEntry{Path: path, Action: JournalActionRestore, Snapshot: name})
+}
+
+// recordDirSnapshot 复制目录快照，快照完成后才更新日志，返回快照路径
+func (j *Journal) recordDirSnapshot(path string, limit int64) (string, error) {
+	name := fmt.Sprintf("%04d.dir", len(j.Entries))
+	snapshot := filepath.Join(j.dir, name)
+	if err := snapshotDirectory(path, snapshot, limit); err != nil {
+		return "", err
+	}
+	if err := j.appendEntry(JournalEntry{Path: path, Action: JournalActionRestoreDir, Snapshot: name}); err != nil {
+		return "", err
+	}
+	return snapshot, nil
+}
+
+// recordCreated 记录回滚时需要删除的路径
+func (j *Journal) recordCreated(path string) error {
+	return j.appendEntry(JournalEntry{Path: path, Action: JournalActionDelete})
+}
+
+// appendEntry 把一条记录追加到记录文件并刷盘，每次备份只写一行，不重写整个日志
+func (j *Journal) appendEntry(entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollback journal entry: %v", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(j.dir, journalEntriesFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write rollback journal: %v", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write rollback journal: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write rollback journal: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write rollback journal: %v", err)
+	}
+
+	j.Entries = append(j.Entries, entry)
+	j.UpdatedAt = time.Now()
+	return nil
+}
+
+// setStatus 更新日志状态
+func (j *Journal) setStatus(status, message string) error {
+	j.Status = status
+	j.Error = message
+	return j.save()
+}
+
+// remove 删除日志及快照
+func (j *Journal) remove() error {
+	if err := os.RemoveAll(j.dir); err != nil {
+		return fmt.Errorf("failed to remove rollback journal %s: %v", j.ID, err)
+	}
+	return nil
+}
+
+// save 原子写入日志状态（先写临时文件再重命名），文件记录保存在记录文件中
+func (j *Journal) save() error {
+	j.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(struct {
+		*Journal
+		Entries []JournalEntry `json:"entries,omitempty"` // 覆盖 Journal.Entries，不写入状态文件
+	}{Journal: j}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollback journal: %v", err)
+	}
+
+	target := filepath.Join(j.dir, journalFileName)
+	tmp := target + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("failed to write rollback journal: %v", err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return fmt.Errorf("failed to write rollback journal: %v", err)
+	}
+	return nil
+}
+
+// Rollback 按日志回滚文件改动，成功后删除日志，失败时标记为回滚失败
+func (j *Journal) Rollback() error {
+	files := &FileRollback{
+		backupFiles:  make(map[string]string),
+		backupDirs:   make(map[string]string),
+		createdFiles: make([]string, 0),
+	}
+	for _, entry := range j.Entries {
+		switch entry.Action {
+		case JournalActionRestore:
+			content, err := os.ReadFile(filepath.Join(j.dir, entry.Snapshot))
+			if err != nil {
+				j.setStatus(JournalStatusRollbackFailed, "failed to read snapshot: "+err.Error())
+				return fmt.Errorf("failed to read snapshot of %s: %v", entry.Path, err)
+			}
+			files.backupFiles[entry.Path] = string(content)
+		case JournalActionRestoreDir:
+			files.backupDirs[entry.Path] = filepath.Join(j.dir, entry.Snapshot)
+		case JournalActionDelete:
+			files.createdFiles = append(files.createdFiles, entry.Path)
+		}
+	}
+
+	log.Printf("🔄 按回滚日志恢复文件: %s (记录数: %d)", j.ID, len(j.Entries))
+	if err := files.Rollback(); err != nil {
+		j.setStatus(JournalStatusRollbackFailed, err.Error())
+		return err
+	}
+	return j.remove()
+}
+
+// Discard 保留文件当前状态，删除日志
+func (j *Journal) Discard() error {
+	log.Printf("🗑️ 丢弃回滚日志: %s", j.ID)
+	return j.remove()
+}
+
+// ListJournals 列出日志目录下的所有回滚日志，按开始时间排序
+func ListJournals(cfg *config.Config) ([]*Journal, error) {
+	journals := make([]*Journal, 0)
+	if cfg.RollbackJournalDir == "" {
+		return journals, nil
+	}
+
+	entries, err := os.ReadDir(cfg.RollbackJournalDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return journals, nil
+		}
+		return nil, fmt.Errorf("failed to read rollback journal directory: %v", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		journal, err := LoadJournal(cfg, entry.Name())
+		if err != nil {
+			log.Printf("⚠️ 跳过无法读取的回滚日志 %s: %v", entry.Name(), err)
+			continue
+		}
+		journals = append(journals, journal)
+	}
+
+	sort.Slice(journals, func(i, k int) bool {
+		return journals[i].StartedAt.Before(journals[k].StartedAt)
+	})
+	return journals, nil
+}
+
+// LoadJournal 读取指定ID的回滚日志
+func LoadJournal(cfg *config.Config, id string) (*Journal, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid journal id: %s", id)
+	}
+
+	dir := filepath.Join(cfg.RollbackJournalDir, id)
+	data, err := os.ReadFile(filepath.Join(dir, journalFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to parse rollback journal: %v", err)
+	}
+	journal.dir = dir
+	if err := journal.loadEntries(); err != nil {
+		return nil, err
+	}
+	return &journal, nil
+}
+
+// loadEntries 读取记录文件；进程退出时写了一半的最后一行忽略（对应的文件还没有修改）
+func (j *Journal) loadEntries() error {
+	path := filepath.Join(j.dir, journalEntriesFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read rollback journal entries: %v", err)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return fmt.Errorf("failed to parse rollback journal entry %d: %v", i+1, err)
+		}
+		j.Entries = append(j.Entries, entry)
+	}
+	if j.Entries == nil {
+		j.Entries = make([]JournalEntry, 0)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.ModTime().After(j.UpdatedAt) {
+		j.UpdatedAt = info.ModTime()
+	}
+	return nil
+}
+
+// writeFileSync 写入文件并刷盘
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -51,6 +51,8 @@ func (rm *RollbackManager) ExecuteWithTransaction(operation func(*TransactionCon
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("❌ 发生panic: %v", r)
+			// 文件改动未回滚，保留日志供人工处理
+			ctx.Files.markJournal(JournalStatusRollbackFailed, fmt.Sprintf("panic: %v", r))
 			if progressCallback != nil {
 				progressCallback(0, "系统错误", "发生系统错误，开始回滚操作")
 			}
@@ -102,11 +104,13 @@ func (rm *RollbackManager) ExecuteWithTransaction(operation func(*TransactionCon
 
 		fileRollbackErr := ctx.Files.Rollback()
 		if fileRollbackErr != nil {
+			ctx.Files.markJournal(JournalStatusRollbackFailed, fileRollbackErr.Error())
 			log.Printf("❌ 文件回滚失败: %v", fileRollbackErr)
 			if progressCallback != nil {
 				progressCallback(0, "回滚失败", "文件回滚失败: "+fileRollbackErr.Error())
 			}
 		} else {
+			ctx.Files.closeJournal()
 			log.Printf("✅ 文件操作回滚成功")
 			if progressCallback != nil {
 				progressCallback(0, "回滚完成", "文件操作回滚成功")
@@ -115,13 +119,18 @@ func (rm *RollbackManager) ExecuteWithTransaction(operation func(*TransactionCon
 		return fmt.Errorf("操作失败，已回滚: %v", err)
 	}
 
+	// 提交前标记日志：提交过程中进程退出时，无法确定数据库是否已提交，需要人工确认
+	ctx.Files.markJournal(JournalStatusCommitting, "")
 	if err := tx.Commit().Error; err != nil {
+		// 数据库未提交而文件已修改，保留日志供人工回滚
+		ctx.Files.markJournal(JournalStatusRollbackFailed, "commit failed: "+err.Error())
 		log.Printf("❌ 数据库事务提交失败: %v", err)
 		if progressCallback != nil {
 			progressCallback(0, "提交失败", "数据库事务提交失败: "+err.Error())
 		}
 		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
+	ctx.Files.closeJournal()
 	log.Printf("✅ 数据库事务提交成功")
 	if progressCallback != nil {
 		progressCallback(0, "提交成功", "数据库事务提交成功")
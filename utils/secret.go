@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// secretPrefix 加密后的值的前缀，用于区分未加密的值和以后更换的加密方式
+const secretPrefix = "enc:v1:"
+
+// ErrSecretKeyMissing 未配置加密密钥
+var ErrSecretKeyMissing = errors.New("secret key is not configured")
+
+// EncryptSecret 使用 AES-256-GCM 加密保存到数据库的密码等敏感字段，密钥为任意字符串（取SHA-256）
+func EncryptSecret(key string, plaintext string) (string, error) {
+	gcm, err := secretCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 生成的值
+func DecryptSecret(key string, value string) (string, error) {
+	payload, ok := strings.CutPrefix(value, secretPrefix)
+	if !ok {
+		return "", errors.New("value is not encrypted")
+	}
+	gcm, err := secretCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value (wrong secret key?): %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// secretCipher 由密钥字符串创建 AES-256-GCM
+func secretCipher(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, ErrSecretKeyMissing
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
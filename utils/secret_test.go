@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	encrypted, err := EncryptSecret("server-key", "p@ssw0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, secretPrefix) || strings.Contains(encrypted, "p@ssw0rd") {
+		t.Fatalf("unexpected encrypted value %q", encrypted)
+	}
+
+	plaintext, err := DecryptSecret("server-key", encrypted)
+	if err != nil || plaintext != "p@ssw0rd" {
+		t.Fatalf("decrypt: got %q, %v", plaintext, err)
+	}
+
+	if _, err := DecryptSecret("other-key", encrypted); err == nil {
+		t.Error("expected error for wrong key")
+	}
+	if _, err := DecryptSecret("server-key", "p@ssw0rd"); err == nil {
+		t.Error("expected error for plaintext value")
+	}
+	if _, err := EncryptSecret("", "p@ssw0rd"); err != ErrSecretKeyMissing {
+		t.Errorf("empty key: got %v, want ErrSecretKeyMissing", err)
+	}
+}
@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ReadTableFile 按扩展名读取CSV或XLSX表格，返回所有行（XLSX只读取第一个工作表）
+func ReadTableFile(fileName string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return ReadCSVRows(data)
+	case ".xlsx":
+		return ReadXLSXRows(data)
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s (only .csv and .xlsx are supported)", filepath.Ext(fileName))
+	}
+}
+
+// ReadCSVRows 读取CSV内容，兼容Excel导出时带的UTF-8 BOM
+func ReadCSVRows(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv: %v", err)
+	}
+	return rows, nil
+}
+
+// xlsxRelationships workbook.xml.rels 中的关系
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxWorkbook workbook.xml 中的工作表列表
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRichText 共享字符串或内联字符串（纯文本或富文本片段）
+type xlsxRichText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+// xlsxSharedStrings sharedStrings.xml
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+// xlsxWorksheet 工作表数据
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string        `xml:"r,attr"`
+			T  string        `xml:"t,attr"`
+			V  string        `xml:"v"`
+			IS *xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// text 拼接富文本片段
+func (t xlsxRichText) text() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var builder strings.Builder
+	for _, run := range t.Runs {
+		builder.WriteString(run.T)
+	}
+	return builder.String()
+}
+
+// ReadXLSXRows 读取XLSX第一个工作表的所有行，空单元格以空字符串补齐
+func ReadXLSXRows(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open xlsx: %v", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := xlsxFirstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(file, &sharedStrings); err != nil {
+			return nil, fmt.Errorf("failed to parse shared strings: %v", err)
+		}
+	}
+
+	sheetFile, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("worksheet not found in xlsx: %s", sheetPath)
+	}
+	var sheet xlsxWorksheet
+	if err := decodeZipXML(sheetFile, &sheet); err != nil {
+		return nil, fmt.Errorf("failed to parse worksheet: %v", err)
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// 行号不连续时补齐中间的空行，保证行号与表格一致
+		if row.R > 0 {
+			for len(rows) < row.R-1 {
+				rows = append(rows, nil)
+			}
+		}
+
+		var values []string
+		for i, cell := range row.Cells {
+			column := i
+			if cell.R != "" {
+				if index, err := xlsxColumnIndex(cell.R); err == nil {
+					column = index
+				}
+			}
+
+			var value string
+			switch cell.T {
+			case "s":
+				index, err := strconv.Atoi(strings.TrimSpace(cell.V))
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("invalid shared string index in cell %s", cell.R)
+				}
+				value = sharedStrings.Items[index].text()
+			case "inlineStr":
+				if cell.IS != nil {
+					value = cell.IS.text()
+				}
+			default:
+				value = cell.V
+			}
+
+			for len(values) < column {
+				values = append(values, "")
+			}
+			if column < len(values) {
+				values[column] = value
+			} else {
+				values = append(values, value)
+			}
+		}
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+// xlsxFirstSheetPath 通过workbook关系找到第一个工作表的路径
+func xlsxFirstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	workbookFile, ok := files["xl/workbook.xml"]
+	relsFile, hasRels := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !hasRels {
+		return fallback, nil
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(workbookFile, &workbook); err != nil {
+		return "", fmt.Errorf("failed to parse workbook: %v", err)
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(relsFile, &rels); err != nil {
+		return "", fmt.Errorf("failed to parse workbook relationships: %v", err)
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("xlsx contains no worksheet")
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			return strings.TrimPrefix(target, "/"), nil
+		}
+		return path.Join("xl", target), nil
+	}
+	return fallback, nil
+}
+
+// xlsxColumnIndex 将单元格引用（如 AB12）转换为从0开始的列序号
+func xlsxColumnIndex(ref string) (int, error) {
+	index := 0
+	letters := 0
+	for _, ch := range ref {
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		index = index*26 + int(ch-'A'+1)
+		letters++
+	}
+	if letters == 0 {
+		return 0, fmt.Errorf("invalid cell reference: %s", ref)
+	}
+	return index - 1, nil
+}
+
+// decodeZipXML 解码压缩包中的XML文件
+func decodeZipXML(file *zip.File, target interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(content, target)
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,73 +16,285 @@ import (
 type TaskStatus string
 
 const (
-	TaskStatusPending   TaskStatus = "pending"
-	TaskStatusRunning   TaskStatus = "running"
-	TaskStatusCompleted TaskStatus = "completed"
-	TaskStatusFailed    TaskStatus = "failed"
+	TaskStatusPending     TaskStatus = "pending"
+	TaskStatusRunning     TaskStatus = "running"
+	TaskStatusCompleted   TaskStatus = "completed"
+	TaskStatusFailed      TaskStatus = "failed"
+	TaskStatusCancelled   TaskStatus = "cancelled"
+	TaskStatusInterrupted TaskStatus = "interrupted" // 服务重启时仍未结束的任务
 )
 
+// 任务类型
+const (
+	TaskTypeWebsiteCreate = "website_create"
+	TaskTypeWebsiteClone  = "website_clone"
+	TaskTypeWebsiteBulk   = "website_bulk"
+	TaskTypeBuild         = "build"
+	TaskTypeDeploy        = "deploy"
+)
+
+// TaskInfo 创建任务时的描述信息
+type TaskInfo struct {
+	Type        string      // 任务类型
+	Requester   string      // 发起人
+	Params      interface{} // 任务参数，保存前会隐藏密码等敏感字段
+	Cancellable bool        // 是否可以通过 CancelTask 取消，执行方通过 CancelContext 获取取消上下文
+}
+
 // Task 任务信息
 type Task struct {
-	ID        string     `json:"id"`
-	Status    TaskStatus `json:"status"`
-	Progress  int        `json:"progress"`
-	Message   string     `json:"message"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	Error     string     `json:"error,omitempty"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Requester  string          `json:"requester"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Status     TaskStatus      `json:"status"`
+	Progress   int             `json:"progress"`
+	Message    string          `json:"message"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	// 排队中的任务在队列中的位置（从1开始），开始执行后为0
+	QueuePosition int `json:"queue_position,omitempty"`
+
+	pendingLog []string           // 尚未写入存储的输出
+	dirty      bool               // 有尚未写入存储的变更
+	ctx        context.Context    // 支持取消的任务的上下文
+	cancel     context.CancelFunc // 支持取消的任务的取消函数，任务结束时释放
+}
+
+// TaskStore 任务持久化接口（由服务层基于数据库实现）
+type TaskStore interface {
+	SaveTask(task Task) error
+	AppendTaskLog(taskID string, text string) error
+	LoadTask(taskID string) (*Task, error)
+	LoadTaskLog(taskID string) (string, error)
 }
 
+// taskFlushInterval 进度和输出日志批量写入存储的间隔
+const taskFlushInterval = 2 * time.Second
+
 // TaskManager 任务管理器
 type TaskManager struct {
 	tasks map[string]*Task
 	mutex sync.RWMutex
 	// 限制同时运行的任务数量
 	maxConcurrentTasks int32
-	currentTasks       int32
+	maxQueuedTasks     int            // 排队任务数上限
+	typeLimits         map[string]int // 各任务类型同时运行的数量上限
+
+	// 调度状态（由mutex保护）
+	queue         []*queuedTask
+	reserved      map[string]struct{} // 已创建但尚未入队的任务，计入排队上限
+	running       int
+	runningByType map[string]int
+	queueNotifier QueueNotifier
+
+	store     TaskStore
+	persistMu sync.Mutex // 保证写入存储的顺序与状态变更顺序一致
+	flushOnce sync.Once
 }
 
 // NewTaskManager 创建新的任务管理器
-func NewTaskManager(maxConcurrent int32) *TaskManager {
+// maxConcurrent 为同时运行的任务总数，maxQueued 为排队任务数上限，typeLimits 为各任务类型的并发上限
+func NewTaskManager(maxConcurrent int32, maxQueued int, typeLimits map[string]int) *TaskManager {
 	return &TaskManager{
 		tasks:              make(map[string]*Task),
 		maxConcurrentTasks: maxConcurrent,
+		maxQueuedTasks:     maxQueued,
+		typeLimits:         typeLimits,
+		reserved:           make(map[string]struct{}),
+		runningByType:      make(map[string]int),
 	}
 }
 
-// CreateTask 创建新任务
-func (tm *TaskManager) CreateTask() (*Task, error) {
-	// 检查是否超过最大并发数
-	if atomic.LoadInt32(&tm.currentTasks) >= tm.maxConcurrentTasks {
-		return nil, ErrTooManyTasks
-	}
+// SetStore 设置任务持久化存储，并启动定时写入
+func (tm *TaskManager) SetStore(store TaskStore) {
+	tm.store = store
+	tm.flushOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(taskFlushInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				tm.flush("")
+			}
+		}()
+	})
+}
 
+// CreateTask 创建新任务，创建后通过 Enqueue 排队执行
+func (tm *TaskManager) CreateTask(info TaskInfo) (*Task, error) {
 	taskID := uuid.New().String()
 	task := &Task{
 		ID:        taskID,
+		Type:      info.Type,
+		Requester: info.Requester,
+		Params:    RedactSecrets(info.Params),
 		Status:    TaskStatusPending,
 		Progress:  0,
 		Message:   "任务已创建，等待执行...",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+		dirty:     true,
+	}
+	// 创建时即注册取消函数，任务从排队到执行的任何时刻都可以取消
+	if info.Cancellable {
+		task.ctx, task.cancel = context.WithCancel(context.Background())
 	}
 
+	// 排队上限检查与占位在同一把锁内完成，避免并发创建时超出上限
 	tm.mutex.Lock()
+	if len(tm.queue)+len(tm.reserved) >= tm.maxQueuedTasks {
+		tm.mutex.Unlock()
+		return nil, ErrTooManyTasks
+	}
 	tm.tasks[taskID] = task
+	tm.reserved[taskID] = struct{}{}
 	tm.mutex.Unlock()
 
-	atomic.AddInt32(&tm.currentTasks, 1)
-	log.Printf("任务已创建: %s", taskID)
+	log.Printf("任务已创建: %s (%s)", taskID, info.Type)
+	tm.flush(taskID)
 
 	return task, nil
 }
 
-// GetTask 获取任务信息
+// GetTask 获取任务信息，内存中没有时从存储中读取（如服务重启前的任务）
 func (tm *TaskManager) GetTask(taskID string) (*Task, bool) {
+	tm.mutex.RLock()
+	task, exists := tm.tasks[taskID]
+	tm.mutex.RUnlock()
+	if exists || tm.store == nil {
+		return task, exists
+	}
+
+	stored, err := tm.store.LoadTask(taskID)
+	if err != nil {
+		return nil, false
+	}
+	return stored, true
+}
+
+// IsTaskFinished 判断任务是否已结束（完成、失败、取消或中断）
+func (tm *TaskManager) IsTaskFinished(taskID string) bool {
+	tm.mutex.RLock()
+	task, exists := tm.tasks[taskID]
+	if exists {
+		finished := task.FinishedAt != nil
+		tm.mutex.RUnlock()
+		return finished
+	}
+	tm.mutex.RUnlock()
+
+	stored, ok := tm.GetTask(taskID)
+	return ok && stored.Status != TaskStatusPending && stored.Status != TaskStatusRunning
+}
+
+// GetTaskLog 获取任务的完整输出日志
+func (tm *TaskManager) GetTaskLog(taskID string) (string, error) {
+	if tm.store == nil {
+		return "", errors.New("task store is not configured")
+	}
+	tm.flush(taskID)
+	return tm.store.LoadTaskLog(taskID)
+}
+
+// AppendLog 追加任务输出日志
+func (tm *TaskManager) AppendLog(taskID string, text string) {
+	if text == "" {
+		return
+	}
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if task, exists := tm.tasks[taskID]; exists {
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		task.pendingLog = append(task.pendingLog, text)
+		task.dirty = true
+	}
+}
+
+// SetTaskResult 设置任务结果
+func (tm *TaskManager) SetTaskResult(taskID string, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("任务结果序列化失败 [%s]: %v", taskID, err)
+		return
+	}
+
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if task, exists := tm.tasks[taskID]; exists {
+		task.Result = data
+		task.UpdatedAt = time.Now()
+		task.dirty = true
+	}
+}
+
+// CancelContext 获取任务的取消上下文，CancelTask 取消任务时结束；
+// 创建时未指定 Cancellable 的任务返回不会被取消的上下文
+func (tm *TaskManager) CancelContext(taskID string) context.Context {
 	tm.mutex.RLock()
 	defer tm.mutex.RUnlock()
+
+	if task, exists := tm.tasks[taskID]; exists && task.ctx != nil {
+		return task.ctx
+	}
+	return context.Background()
+}
+
+// releaseCancelLocked 任务结束时释放取消上下文和排队占位（调用方需持有mutex）
+func (tm *TaskManager) releaseCancelLocked(task *Task) {
+	delete(tm.reserved, task.ID)
+	if task.cancel != nil {
+		task.cancel()
+		task.cancel = nil
+	}
+}
+
+// CancelTask 取消任务：排队中的任务直接移出队列（dequeued 为 true），
+// 正在执行的任务标记为已取消并通知执行方停止
+func (tm *TaskManager) CancelTask(taskID string) (dequeued bool, err error) {
+	tm.mutex.Lock()
 	task, exists := tm.tasks[taskID]
-	return task, exists
+	if !exists {
+		tm.mutex.Unlock()
+		if stored, ok := tm.GetTask(taskID); ok && stored.Status != TaskStatusPending && stored.Status != TaskStatusRunning {
+			return false, ErrTaskFinished
+		}
+		return false, ErrTaskNotFound
+	}
+	if task.Status != TaskStatusPending && task.Status != TaskStatusRunning {
+		tm.mutex.Unlock()
+		return false, ErrTaskFinished
+	}
+
+	now := time.Now()
+	dequeued = tm.removeQueuedLocked(taskID)
+	if !dequeued && task.cancel == nil {
+		tm.mutex.Unlock()
+		return false, ErrTaskNotCancellable
+	}
+
+	task.Status = TaskStatusCancelled
+	task.Message = "任务已取消"
+	task.UpdatedAt = now
+	if dequeued {
+		task.FinishedAt = &now
+	}
+	task.dirty = true
+	tm.releaseCancelLocked(task)
+	positions := tm.queuePositionsLocked()
+	tm.mutex.Unlock()
+
+	log.Printf("任务已取消: %s", taskID)
+	tm.notifyQueue(positions)
+	tm.flush(taskID)
+	return dequeued, nil
 }
 
 // UpdateTaskProgress 更新任务进度
@@ -91,46 +306,60 @@ func (tm *TaskManager) UpdateTaskProgress(taskID string, progress int, message s
 		task.Progress = progress
 		task.Message = message
 		task.UpdatedAt = time.Now()
+		task.dirty = true
 		log.Printf("任务进度更新 [%s]: %d%% - %s", taskID, progress, message)
 	}
 }
 
-// CompleteTask 完成任务
+// CompleteTask 完成任务（任务已结束时不再修改状态）
 func (tm *TaskManager) CompleteTask(taskID string, message string) {
 	tm.mutex.Lock()
-	defer tm.mutex.Unlock()
-
-	if task, exists := tm.tasks[taskID]; exists {
-		task.Status = TaskStatusCompleted
-		task.Progress = 100
-		task.Message = message
-		task.UpdatedAt = time.Now()
-		atomic.AddInt32(&tm.currentTasks, -1)
-		log.Printf("任务已完成: %s", taskID)
+	if task, exists := tm.tasks[taskID]; exists && task.FinishedAt == nil {
+		now := time.Now()
+		// 已取消的任务保留取消状态，只记录结束时间
+		if task.Status != TaskStatusCancelled {
+			task.Status = TaskStatusCompleted
+			task.Progress = 100
+			task.Message = message
+			task.UpdatedAt = now
+			log.Printf("任务已完成: %s", taskID)
+		}
+		task.FinishedAt = &now
+		task.dirty = true
+		tm.releaseCancelLocked(task)
 	}
+	tm.mutex.Unlock()
+
+	tm.flush(taskID)
 }
 
-// FailTask 任务失败
+// FailTask 任务失败（任务已结束时不再修改状态）
 func (tm *TaskManager) FailTask(taskID string, error string) {
 	tm.mutex.Lock()
-	defer tm.mutex.Unlock()
-
-	if task, exists := tm.tasks[taskID]; exists {
-		task.Status = TaskStatusFailed
-		task.Message = "任务执行失败"
-		task.Error = error
-		task.UpdatedAt = time.Now()
-		atomic.AddInt32(&tm.currentTasks, -1)
-		log.Printf("任务失败: %s - %s", taskID, error)
+	if task, exists := tm.tasks[taskID]; exists && task.FinishedAt == nil {
+		now := time.Now()
+		// 已取消的任务保留取消状态，只记录结束时间
+		if task.Status != TaskStatusCancelled {
+			task.Status = TaskStatusFailed
+			task.Message = "任务执行失败"
+			task.Error = error
+			task.UpdatedAt = now
+			log.Printf("任务失败: %s - %s", taskID, error)
+		}
+		task.FinishedAt = &now
+		task.dirty = true
+		tm.releaseCancelLocked(task)
 	}
+	tm.mutex.Unlock()
+
+	tm.flush(taskID)
 }
 
 // StartTask 开始任务
 func (tm *TaskManager) StartTask(taskID string, message ...string) {
 	tm.mutex.Lock()
-	defer tm.mutex.Unlock()
-
-	if task, exists := tm.tasks[taskID]; exists {
+	if task, exists := tm.tasks[taskID]; exists && task.Status != TaskStatusCancelled {
+		now := time.Now()
 		task.Status = TaskStatusRunning
 
 		// 如果提供了消息参数，使用它；否则使用默认消息
@@ -140,18 +369,69 @@ func (tm *TaskManager) StartTask(taskID string, message ...string) {
 			task.Message = "任务正在执行..."
 		}
 
-		task.UpdatedAt = time.Now()
+		task.UpdatedAt = now
+		task.StartedAt = &now
+		task.dirty = true
 		log.Printf("任务已开始: %s - %s", taskID, task.Message)
 	}
+	tm.mutex.Unlock()
+
+	tm.flush(taskID)
 }
 
-// CleanupOldTasks 清理旧任务（可选，用于内存管理）
+// flush 将有变更的任务写入存储，taskID 为空时写入所有任务
+func (tm *TaskManager) flush(taskID string) {
+	if tm.store == nil {
+		return
+	}
+
+	tm.persistMu.Lock()
+	defer tm.persistMu.Unlock()
+
+	type pendingWrite struct {
+		task Task
+		log  string
+	}
+
+	tm.mutex.Lock()
+	var writes []pendingWrite
+	for id, task := range tm.tasks {
+		if (taskID != "" && id != taskID) || !task.dirty {
+			continue
+		}
+		snapshot := *task
+		snapshot.pendingLog = nil
+		writes = append(writes, pendingWrite{task: snapshot, log: strings.Join(task.pendingLog, "")})
+		task.pendingLog = nil
+		task.dirty = false
+	}
+	tm.mutex.Unlock()
+
+	for _, write := range writes {
+		if err := tm.store.SaveTask(write.task); err != nil {
+			log.Printf("⚠️ 保存任务失败 [%s]: %v", write.task.ID, err)
+		}
+		if write.log == "" {
+			continue
+		}
+		if err := tm.store.AppendTaskLog(write.task.ID, write.log); err != nil {
+			log.Printf("⚠️ 保存任务日志失败 [%s]: %v", write.task.ID, err)
+		}
+	}
+}
+
+// CleanupOldTasks 从内存中清理已结束的旧任务（已持久化的任务仍可从存储中查询）
 func (tm *TaskManager) CleanupOldTasks(maxAge time.Duration) {
+	tm.flush("")
+
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
 	now := time.Now()
 	for taskID, task := range tm.tasks {
+		if task.FinishedAt == nil {
+			continue
+		}
 		if now.Sub(task.UpdatedAt) > maxAge {
 			delete(tm.tasks, taskID)
 			log.Printf("清理旧任务: %s", taskID)
@@ -159,12 +439,85 @@ func (tm *TaskManager) CleanupOldTasks(maxAge time.Duration) {
 	}
 }
 
-// GetCurrentTaskCount 获取当前任务数量
+// StartCleanup 定时清理内存中已结束的旧任务
+func (tm *TaskManager) StartCleanup(interval, maxAge time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			tm.CleanupOldTasks(maxAge)
+		}
+	}()
+}
+
+// GetCurrentTaskCount 获取当前正在执行的任务数量
 func (tm *TaskManager) GetCurrentTaskCount() int32 {
-	return atomic.LoadInt32(&tm.currentTasks)
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	return int32(tm.running)
+}
+
+// GetQueueLength 获取排队中的任务数量
+func (tm *TaskManager) GetQueueLength() int {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	return len(tm.queue)
 }
 
 // GetMaxConcurrentTasks 获取最大并发任务数
 func (tm *TaskManager) GetMaxConcurrentTasks() int32 {
 	return tm.maxConcurrentTasks
 }
+
+// RedactSecrets 将参数转换为JSON，并隐藏密码、密钥、令牌等敏感字段
+func RedactSecrets(params interface{}) json.RawMessage {
+	if params == nil {
+		return nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return data
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+// redactValue 递归隐藏敏感字段的值
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSecretKey(key) {
+				if item != nil && item != "" {
+					v[key] = "******"
+				}
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// isSecretKey 判断字段名是否为敏感字段
+func isSecretKey(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, word := range []string{"password", "passwd", "secret", "token", "privatekey", "credential"} {
+		if strings.Contains(normalized, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// QueueNotifier 排队位置变化时的回调，position 从1开始
+type QueueNotifier func(taskID string, position int, queueLength int)
+
+// queuedTask 等待执行的任务
+type queuedTask struct {
+	task *Task
+	run  func()
+}
+
+// SetQueueNotifier 设置排队位置变化的回调（如通过WebSocket推送给前端）
+func (tm *TaskManager) SetQueueNotifier(notifier QueueNotifier) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.queueNotifier = notifier
+}
+
+// Enqueue 将任务加入FIFO队列，有空闲执行槽位时在新的goroutine中执行 run
+// 同类型任务按入队顺序执行；某类型达到并发上限时，后面其他类型的任务可以先执行
+func (tm *TaskManager) Enqueue(taskID string, run func()) {
+	tm.mutex.Lock()
+	task, exists := tm.tasks[taskID]
+	delete(tm.reserved, taskID)
+	if !exists || task.Status != TaskStatusPending {
+		tm.mutex.Unlock()
+		return
+	}
+	tm.queue = append(tm.queue, &queuedTask{task: task, run: run})
+	tm.dispatchLocked()
+	positions := tm.queuePositionsLocked()
+	tm.mutex.Unlock()
+
+	tm.notifyQueue(positions)
+}
+
+// dispatchLocked 按队列顺序启动可以执行的任务（调用方需持有mutex）
+func (tm *TaskManager) dispatchLocked() {
+	for i := 0; i < len(tm.queue) && tm.running < int(tm.maxConcurrentTasks); {
+		item := tm.queue[i]
+		if limit, ok := tm.typeLimits[item.task.Type]; ok && tm.runningByType[item.task.Type] >= limit {
+			i++
+			continue
+		}
+
+		tm.queue = append(tm.queue[:i], tm.queue[i+1:]...)
+		tm.running++
+		tm.runningByType[item.task.Type]++
+		item.task.QueuePosition = 0
+		go tm.execute(item)
+	}
+}
+
+// execute 执行任务，结束后释放执行槽位并调度后续任务
+func (tm *TaskManager) execute(item *queuedTask) {
+	taskID := item.task.ID
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ 任务执行异常 [%s]: %v", taskID, r)
+			tm.FailTask(taskID, fmt.Sprintf("任务执行异常: %v", r))
+		}
+
+		// 执行函数返回时任务应已结束，未结束的按失败处理，保证每个任务只释放一次槽位
+		tm.mutex.RLock()
+		unfinished := item.task.FinishedAt == nil
+		tm.mutex.RUnlock()
+		if unfinished {
+			tm.FailTask(taskID, "任务未正常结束")
+		}
+
+		tm.mutex.Lock()
+		tm.running--
+		tm.runningByType[item.task.Type]--
+		tm.dispatchLocked()
+		positions := tm.queuePositionsLocked()
+		tm.mutex.Unlock()
+
+		tm.notifyQueue(positions)
+	}()
+
+	item.run()
+}
+
+// removeQueuedLocked 从队列中移除任务，返回任务是否在队列中（调用方需持有mutex）
+func (tm *TaskManager) removeQueuedLocked(taskID string) bool {
+	for i, item := range tm.queue {
+		if item.task.ID == taskID {
+			tm.queue = append(tm.queue[:i], tm.queue[i+1:]...)
+			item.task.QueuePosition = 0
+			return true
+		}
+	}
+	return false
+}
+
+// queuePosition 排队位置
+type queuePosition struct {
+	taskID   string
+	position int
+}
+
+// queuePositionsLocked 更新排队任务的位置，返回位置有变化的任务（调用方需持有mutex）
+func (tm *TaskManager) queuePositionsLocked() []queuePosition {
+	var changed []queuePosition
+	for i, item := range tm.queue {
+		position := i + 1
+		if item.task.QueuePosition == position {
+			continue
+		}
+		item.task.QueuePosition = position
+		item.task.Message = fmt.Sprintf("任务排队中，前面还有 %d 个任务", i)
+		item.task.UpdatedAt = time.Now()
+		item.task.dirty = true
+		changed = append(changed, queuePosition{taskID: item.task.ID, position: position})
+	}
+	return changed
+}
+
+// notifyQueue 通知排队位置变化
+func (tm *TaskManager) notifyQueue(positions []queuePosition) {
+	tm.mutex.RLock()
+	notifier := tm.queueNotifier
+	queueLength := len(tm.queue)
+	tm.mutex.RUnlock()
+
+	if notifier == nil {
+		return
+	}
+	for _, item := range positions {
+		notifier(item.taskID, item.position, queueLength)
+	}
+}
@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BasePathEntry vite.config.js 中 basePathMap 的一条记录
+type BasePathEntry struct {
+	Key      string `json:"key"`       // 平台标识，如 h5-jinse
+	Value    string `json:"value"`     // 字符串值；非字符串值时为原始表达式
+	IsString bool   `json:"is_string"` // 值是否为字符串字面量
+	Line     int    `json:"line"`      // 所在行号（从1开始）
+}
+
+// BasePathMapEditor vite.config.js 中 basePathMap 对象的编辑器
+// 基于JS词法分析定位属性，支持嵌套对象和注释，只改写被操作的属性
+type BasePathMapEditor struct {
+	content string
+}
+
+// NewBasePathMapEditor 创建basePathMap编辑器，找不到basePathMap时返回错误
+func NewBasePathMapEditor(content string) (*BasePathMapEditor, error) {
+	editor := &BasePathMapEditor{content: content}
+	if _, _, err := editor.locate(); err != nil {
+		return nil, err
+	}
+	return editor, nil
+}
+
+// Content 获取编辑后的内容
+func (e *BasePathMapEditor) Content() string {
+	return e.content
+}
+
+// locate 定位 basePathMap = { ... } 对象字面量
+func (e *BasePathMapEditor) locate() ([]JSToken, *JSObjectLiteral, error) {
+	tokens, err := TokenizeJS(e.content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to tokenize vite.config.js: %v", err)
+	}
+
+	for i, token := range tokens {
+		if token.Kind != JSTokenIdent || token.Text != "basePathMap" {
+			continue
+		}
+		assign := nextJSToken(tokens, i+1)
+		if assign >= len(tokens) || !tokens[assign].IsPunct("=") {
+			continue
+		}
+		open := nextJSToken(tokens, assign+1)
+		if open >= len(tokens) || !tokens[open].IsPunct("{") {
+			continue
+		}
+
+		object, err := ParseJSObjectLiteral(tokens, open)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse basePathMap: %v", err)
+		}
+		return tokens, object, nil
+	}
+
+	return nil, nil, fmt.Errorf("cannot find basePathMap in vite.config.js")
+}
+
+// List 列出basePathMap中的所有记录
+func (e *BasePathMapEditor) List() ([]BasePathEntry, error) {
+	tokens, object, err := e.locate()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]BasePathEntry, 0, len(object.Entries))
+	for _, entry := range object.Entries {
+		entries = append(entries, e.toBasePathEntry(tokens, entry))
+	}
+	return entries, nil
+}
+
+// Get 获取指定平台标识的记录
+func (e *BasePathMapEditor) Get(key string) (*BasePathEntry, error) {
+	tokens, object, err := e.locate()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range object.Entries {
+		if entry.Key == key {
+			result := e.toBasePathEntry(tokens, entry)
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
+
+// Set 新增或更新记录，返回内容是否发生变化
+func (e *BasePathMapEditor) Set(key, value string) (bool, error) {
+	tokens, object, err := e.locate()
+	if err != nil {
+		return false, err
+	}
+
+	keyQuote, valueQuote := detectQuotes(tokens, object)
+
+	// 已存在：只替换值，保留原有引号风格
+	for _, entry := range object.Entries {
+		if entry.Key != key {
+			continue
+		}
+
+		quote := valueQuote
+		if entry.ValueEnd-entry.ValueStart == 1 && tokens[entry.ValueStart].Kind == JSTokenString {
+			if tokens[entry.ValueStart].StringValue() == value {
+				return false, nil
+			}
+			quote = tokens[entry.ValueStart].Quote()
+		}
+
+		if entry.ValueEnd == entry.ValueStart {
+			return false, fmt.Errorf("basePathMap entry %s has no value", key)
+		}
+		start := tokens[entry.ValueStart].Start
+		end := tokens[entry.ValueEnd-1].End
+		e.content = e.content[:start] + QuoteJSString(value, quote) + e.content[end:]
+		return true, nil
+	}
+
+	// 不存在：追加到最后一条记录之后，沿用其缩进
+	newline := "\n"
+	if strings.Contains(e.content, "\r\n") {
+		newline = "\r\n"
+	}
+	entryText := QuoteJSString(key, keyQuote) + ": " + QuoteJSString(value, valueQuote)
+
+	if len(object.Entries) == 0 {
+		openEnd := tokens[object.Open].End
+		closeStart := tokens[object.Close].Start
+		closeIndent := lineIndentAt(e.content, closeStart)
+		if strings.Contains(e.content[openEnd:closeStart], "\n") {
+			// 多行空对象：插入到左花括号之后
+			e.content = e.content[:openEnd] + newline + closeIndent + "  " + entryText + "," + e.content[openEnd:]
+		} else {
+			// 单行空对象：展开为多行
+			e.content = e.content[:openEnd] + newline + closeIndent + "  " + entryText + "," + newline + closeIndent + e.content[closeStart:]
+		}
+		return true, nil
+	}
+
+	last := object.Entries[len(object.Entries)-1]
+	indent := lineIndentAt(e.content, last.KeyToken.Start)
+
+	insertAt := jsEntryEnd(tokens, last)
+	if last.Comma < 0 {
+		// 最后一条记录没有逗号，先补上
+		e.content = e.content[:insertAt] + "," + e.content[insertAt:]
+		insertAt++
+	}
+
+	// 记录后面只有空白或注释时插入到下一行，否则（单行对象）直接跟在后面
+	lineEnd := strings.IndexByte(e.content[insertAt:], '\n')
+	if lineEnd >= 0 {
+		rest := strings.TrimSpace(e.content[insertAt : insertAt+lineEnd])
+		if rest == "" || strings.HasPrefix(rest, "//") {
+			insertAt += lineEnd
+			if insertAt > 0 && e.content[insertAt-1] == '\r' {
+				insertAt--
+			}
+			e.content = e.content[:insertAt] + newline + indent + entryText + "," + e.content[insertAt:]
+			return true, nil
+		}
+	}
+
+	e.content = e.content[:insertAt] + " " + entryText + e.content[insertAt:]
+	return true, nil
+}
+
+// Remove 删除指定平台标识的记录，返回记录是否存在
+func (e *BasePathMapEditor) Remove(key string) (bool, error) {
+	tokens, object, err := e.locate()
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range object.Entries {
+		if entry.Key != key {
+			continue
+		}
+
+		// 记录独占一行时连同行尾注释和换行一起删除
+		start, end := jsEntryRemovalRange(e.content, entry.KeyToken.Start, jsEntryEnd(tokens, entry))
+		e.content = e.content[:start] + e.content[end:]
+		return true, nil
+	}
+	return false, nil
+}
+
+// toBasePathEntry 转换为对外的记录结构
+func (e *BasePathMapEditor) toBasePathEntry(tokens []JSToken, entry JSObjectEntry) BasePathEntry {
+	result := BasePathEntry{
+		Key:  entry.Key,
+		Line: strings.Count(e.content[:entry.KeyToken.Start], "\n") + 1,
+	}
+
+	if entry.ValueEnd-entry.ValueStart == 1 && tokens[entry.ValueStart].Kind == JSTokenString {
+		result.Value = tokens[entry.ValueStart].StringValue()
+		result.IsString = true
+	} else if entry.ValueEnd > entry.ValueStart {
+		result.Value = e.content[tokens[entry.ValueStart].Start:tokens[entry.ValueEnd-1].End]
+	}
+	return result
+}
+
+// detectQuotes 根据已有记录推断键和值使用的引号，默认使用单引号
+func detectQuotes(tokens []JSToken, object *JSObjectLiteral) (byte, byte) {
+	keyQuote, valueQuote := byte(0), byte(0)
+	for _, entry := range object.Entries {
+		if keyQuote == 0 && entry.KeyToken.Kind == JSTokenString {
+			keyQuote = entry.KeyToken.Quote()
+		}
+		if valueQuote == 0 && entry.ValueEnd-entry.ValueStart == 1 && tokens[entry.ValueStart].Kind == JSTokenString {
+			valueQuote = tokens[entry.ValueStart].Quote()
+		}
+	}
+
+	if valueQuote == 0 {
+		valueQuote = '\''
+	}
+	if keyQuote == 0 {
+		keyQuote = valueQuote
+	}
+	return keyQuote, valueQuote
+}
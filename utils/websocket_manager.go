@@ -9,129 +9,260 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// WebSocketManager WebSocket连接管理器
+const (
+	wsHistorySize = 1000             // 每个任务保留的历史消息条数
+	wsWriteWait   = 10 * time.Second // 写入超时
+	wsPongWait    = 60 * time.Second // 超过该时间未收到pong视为连接已断开
+	wsPingPeriod  = 25 * time.Second // 发送ping的间隔，需小于wsPongWait
+)
+
+// StreamMessage 任务消息流中的一条消息，Data 为带 seq 字段的JSON
+type StreamMessage struct {
+	Seq  int64  // 历史外的消息（如补发后的当前状态）为0
+	Type string // 消息的 type 字段，如 task_status、deploy_output
+	Data []byte
+}
+
+// Subscription 任务消息订阅（WebSocket连接、SSE请求等）
+type Subscription struct {
+	taskID    string
+	messages  chan StreamMessage
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Messages 订阅收到的消息，按 seq 递增
+func (s *Subscription) Messages() <-chan StreamMessage {
+	return s.messages
+}
+
+// Done 订阅结束（取消订阅或消费过慢被断开）时关闭
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// close 结束订阅
+func (s *Subscription) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// taskStream 单个任务的消息流：环形历史缓冲和当前订阅者
+type taskStream struct {
+	seq         int64
+	history     []StreamMessage // 环形缓冲
+	start       int             // 最旧消息在history中的位置
+	subscribers map[*Subscription]struct{}
+	updatedAt   time.Time
+}
+
+// append 追加消息到历史缓冲，超过容量时覆盖最旧的消息
+func (ts *taskStream) append(msg StreamMessage) {
+	if len(ts.history) < wsHistorySize {
+		ts.history = append(ts.history, msg)
+		return
+	}
+	ts.history[ts.start] = msg
+	ts.start = (ts.start + 1) % wsHistorySize
+}
+
+// since 返回 seq 大于 since 的历史消息，以及历史中最旧消息的 seq
+func (ts *taskStream) since(since int64) ([]StreamMessage, int64) {
+	if len(ts.history) == 0 {
+		return nil, 0
+	}
+	messages := make([]StreamMessage, 0, len(ts.history))
+	for i := 0; i < len(ts.history); i++ {
+		msg := ts.history[(ts.start+i)%len(ts.history)]
+		if msg.Seq > since {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, ts.history[ts.start].Seq
+}
+
+// WebSocketManager 任务消息管理器
+// 每个任务的消息带递增的 seq 并保留最近的历史，同一任务可以有多个订阅者，
+// 后加入的订阅者先收到历史消息，断线后可以通过 since 从指定 seq 之后继续接收
 type WebSocketManager struct {
-	connections  map[string]*websocket.Conn
-	messageQueue map[string][]interface{} // 消息队列，存储未发送的消息
-	mutex        sync.RWMutex             // 读写锁，保护connections和messageQueue
-	writeMutex   map[string]*sync.Mutex   // 每个连接的写锁，防止并发写入
+	streams map[string]*taskStream
+	mutex   sync.Mutex // 保护streams，发送和订阅在同一把锁下进行，保证不丢消息、不乱序
 }
 
 // NewWebSocketManager 创建新的WebSocket管理器
 func NewWebSocketManager() *WebSocketManager {
 	return &WebSocketManager{
-		connections:  make(map[string]*websocket.Conn),
-		messageQueue: make(map[string][]interface{}),
-		writeMutex:   make(map[string]*sync.Mutex),
+		streams: make(map[string]*taskStream),
 	}
 }
 
-// AddConnection 添加连接
-func (wm *WebSocketManager) AddConnection(taskID string, conn *websocket.Conn) {
-	wm.mutex.Lock()
-	defer wm.mutex.Unlock()
-
-	wm.connections[taskID] = conn
-	wm.writeMutex[taskID] = &sync.Mutex{} // 为每个连接创建独立的写锁
-	log.Printf("WebSocket连接已添加: %s", taskID)
-
-	// 发送队列中的消息
-	if messages, exists := wm.messageQueue[taskID]; exists {
-		log.Printf("发送队列中的 %d 条消息给任务 %s", len(messages), taskID)
-		// 在goroutine中发送队列消息，避免阻塞
-		go func() {
-			for _, msg := range messages {
-				wm.sendMessageToConnection(taskID, conn, msg)
-			}
-		}()
-		// 清空队列
-		delete(wm.messageQueue, taskID)
+// stream 获取任务的消息流，不存在时创建（调用方需持有mutex）
+func (wm *WebSocketManager) stream(taskID string) *taskStream {
+	ts, exists := wm.streams[taskID]
+	if !exists {
+		ts = &taskStream{
+			subscribers: make(map[*Subscription]struct{}),
+			updatedAt:   time.Now(),
+		}
+		wm.streams[taskID] = ts
 	}
+	return ts
 }
 
-// RemoveConnection 移除连接
-func (wm *WebSocketManager) RemoveConnection(taskID string) {
+// SendMessage 发送消息到指定任务：记录到历史并推送给当前所有订阅者
+func (wm *WebSocketManager) SendMessage(taskID string, message interface{}) {
 	wm.mutex.Lock()
 	defer wm.mutex.Unlock()
 
-	if conn, exists := wm.connections[taskID]; exists {
-		conn.Close()
-		delete(wm.connections, taskID)
-		delete(wm.writeMutex, taskID) // 清理写锁
-		log.Printf("WebSocket连接已移除: %s", taskID)
+	ts := wm.stream(taskID)
+	data, err := encodeWithSeq(message, ts.seq+1)
+	if err != nil {
+		log.Printf("消息序列化失败: %v", err)
+		return
 	}
-}
 
-// SendMessage 发送消息到指定任务
-func (wm *WebSocketManager) SendMessage(taskID string, message interface{}) {
-	wm.mutex.RLock()
-	conn, exists := wm.connections[taskID]
-	wm.mutex.RUnlock()
+	ts.seq++
+	msg := StreamMessage{Seq: ts.seq, Type: messageType(data), Data: data}
+	ts.append(msg)
+	ts.updatedAt = time.Now()
 
-	if !exists {
-		// 连接不存在，将消息加入队列
-		wm.mutex.Lock()
-		if wm.messageQueue[taskID] == nil {
-			wm.messageQueue[taskID] = make([]interface{}, 0)
-		}
-		wm.messageQueue[taskID] = append(wm.messageQueue[taskID], message)
-		wm.mutex.Unlock()
-		log.Printf("📥 消息已加入队列 [%s]，等待连接建立", taskID)
-		return
+	for sub := range ts.subscribers {
+		wm.deliverLocked(ts, sub, msg)
 	}
-
-	// 在独立的goroutine中发送消息，避免阻塞调用方
-	go wm.sendMessageToConnection(taskID, conn, message)
 }
 
-// sendMessageToConnection 发送消息到指定连接
-func (wm *WebSocketManager) sendMessageToConnection(taskID string, conn *websocket.Conn, message interface{}) {
-	// 获取该连接的写锁
-	wm.mutex.RLock()
-	writeMutex, exists := wm.writeMutex[taskID]
-	wm.mutex.RUnlock()
+// deliverLocked 投递消息给订阅者，订阅者缓冲已满时断开该订阅者（调用方需持有mutex）
+func (wm *WebSocketManager) deliverLocked(ts *taskStream, sub *Subscription, msg StreamMessage) {
+	select {
+	case sub.messages <- msg:
+	default:
+		log.Printf("⚠️ 订阅者接收过慢，断开连接 [%s]", sub.taskID)
+		delete(ts.subscribers, sub)
+		sub.close()
+	}
+}
 
-	if !exists {
-		log.Printf("连接不存在，无法发送消息 [%s]", taskID)
-		return
+// Subscribe 订阅任务消息，先收到 seq 大于 since 的历史消息，再收到新消息
+// 请求的历史已被覆盖，或 since 超过当前 seq（此时从历史开头补发）时先收到一条 replay_truncated 消息；
+// initial 不为空时在历史消息之后、新消息之前单独发给该订阅者（如当前任务状态），不计入历史
+func (wm *WebSocketManager) Subscribe(taskID string, since int64, initial interface{}) *Subscription {
+	var initialMsg *StreamMessage
+	if initial != nil {
+		if data, err := json.Marshal(initial); err != nil {
+			log.Printf("消息序列化失败: %v", err)
+		} else {
+			initialMsg = &StreamMessage{Type: messageType(data), Data: data}
+		}
 	}
 
-	// 锁定写操作，确保同一时间只有一个goroutine写入
-	writeMutex.Lock()
-	defer writeMutex.Unlock()
+	sub := &Subscription{
+		taskID:   taskID,
+		messages: make(chan StreamMessage, wsHistorySize+256),
+		done:     make(chan struct{}),
+	}
 
-	// 检查连接是否仍然存在（在获取锁期间可能被移除）
-	wm.mutex.RLock()
-	_, stillExists := wm.connections[taskID]
-	wm.mutex.RUnlock()
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
 
-	if !stillExists {
-		log.Printf("连接已被移除，跳过消息发送 [%s]", taskID)
-		return
+	ts := wm.stream(taskID)
+	// since 超过当前 seq（如服务重启后 seq 从0重新开始）时客户端的位置已失效，从历史开头补发
+	reset := since > ts.seq
+	from := since
+	if reset {
+		from = 0
 	}
+	history, oldest := ts.since(from)
+	if reset || oldest > since+1 {
+		data, _ := json.Marshal(map[string]interface{}{
+			"type": "replay_truncated",
+			"data": map[string]interface{}{
+				"since":      since,
+				"oldest_seq": oldest,
+				"reset":      reset, // 为 true 时客户端应丢弃已收到的消息，按补发的消息重建
+			},
+		})
+		sub.messages <- StreamMessage{Type: "replay_truncated", Data: data}
+	}
+	for _, msg := range history {
+		sub.messages <- msg
+	}
+	if initialMsg != nil {
+		sub.messages <- *initialMsg
+	}
+	ts.subscribers[sub] = struct{}{}
 
-	// 设置写入超时
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	log.Printf("订阅任务消息: %s (since=%d, 补发 %d 条, 订阅者 %d 个)", taskID, since, len(history), len(ts.subscribers))
+	return sub
+}
 
-	// 序列化消息
-	data, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("消息序列化失败: %v", err)
-		return
+// Unsubscribe 取消订阅
+func (wm *WebSocketManager) Unsubscribe(sub *Subscription) {
+	wm.mutex.Lock()
+	if ts, exists := wm.streams[sub.taskID]; exists {
+		delete(ts.subscribers, sub)
 	}
+	wm.mutex.Unlock()
 
-	// 发送消息
-	log.Printf("📤 发送WebSocket消息 [%s]: %s", taskID, string(data))
-	err = conn.WriteMessage(websocket.TextMessage, data)
-	if err != nil {
-		log.Printf("发送WebSocket消息失败: %v", err)
-		wm.RemoveConnection(taskID)
-	} else {
-		log.Printf("✅ WebSocket消息发送成功 [%s]", taskID)
+	sub.close()
+}
+
+// ServeWebSocket 将WebSocket连接作为任务订阅者，直到连接断开
+// initial 为补发历史后单独发给该连接的消息（如当前任务状态），不计入历史
+func (wm *WebSocketManager) ServeWebSocket(taskID string, conn *websocket.Conn, since int64, initial interface{}) {
+	sub := wm.Subscribe(taskID, since, initial)
+	defer wm.Unsubscribe(sub)
+	defer conn.Close()
+
+	// 读取循环：处理pong并检测连接断开
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				log.Printf("WebSocket连接已断开 [%s]: %v", taskID, err)
+				return
+			}
+		}
+	}()
+
+	// 写入循环：同一连接只在这里写入，无需额外的写锁
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case msg := <-sub.Messages():
+			if err := writeWebSocket(conn, websocket.TextMessage, msg.Data); err != nil {
+				log.Printf("发送WebSocket消息失败 [%s]: %v", taskID, err)
+				return
+			}
+		case <-ticker.C:
+			if err := writeWebSocket(conn, websocket.PingMessage, nil); err != nil {
+				log.Printf("WebSocket心跳失败 [%s]: %v", taskID, err)
+				return
+			}
+		case <-sub.Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber too slow"),
+				time.Now().Add(wsWriteWait))
+			return
+		case <-closed:
+			return
+		}
 	}
 }
 
-// BroadcastMessage 广播消息到所有连接
+// writeWebSocket 带超时写入一条WebSocket消息
+func writeWebSocket(conn *websocket.Conn, messageType int, data []byte) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return conn.WriteMessage(messageType, data)
+}
+
+// BroadcastMessage 广播消息到所有订阅者（不计入任务历史）
 func (wm *WebSocketManager) BroadcastMessage(message interface{}) {
 	// 序列化消息
 	data, err := json.Marshal(message)
@@ -140,40 +271,67 @@ func (wm *WebSocketManager) BroadcastMessage(message interface{}) {
 		return
 	}
 
-	wm.mutex.RLock()
-	connections := make(map[string]*websocket.Conn)
-	for taskID, conn := range wm.connections {
-		connections[taskID] = conn
-	}
-	wm.mutex.RUnlock()
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
 
-	// 逐个发送消息
-	for taskID, conn := range connections {
-		go func(tID string, c *websocket.Conn) {
-			wm.mutex.RLock()
-			writeMutex, exists := wm.writeMutex[tID]
-			wm.mutex.RUnlock()
+	for _, ts := range wm.streams {
+		for sub := range ts.subscribers {
+			wm.deliverLocked(ts, sub, StreamMessage{Type: messageType(data), Data: data})
+		}
+	}
+}
 
-			if !exists {
-				return
+// StartCleanup 定时清理长时间没有新消息且没有订阅者的任务消息流（全局事件流除外）
+func (wm *WebSocketManager) StartCleanup(interval, maxAge time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			wm.mutex.Lock()
+			now := time.Now()
+			for taskID, ts := range wm.streams {
+				if taskID != EventStreamID && len(ts.subscribers) == 0 && now.Sub(ts.updatedAt) > maxAge {
+					delete(wm.streams, taskID)
+				}
 			}
+			wm.mutex.Unlock()
+		}
+	}()
+}
 
-			writeMutex.Lock()
-			defer writeMutex.Unlock()
+// GetConnectionCount 获取订阅者数量
+func (wm *WebSocketManager) GetConnectionCount() int {
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
 
-			c.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			err := c.WriteMessage(websocket.TextMessage, data)
-			if err != nil {
-				log.Printf("广播消息失败 [%s]: %v", tID, err)
-				wm.RemoveConnection(tID)
-			}
-		}(taskID, conn)
+	count := 0
+	for _, ts := range wm.streams {
+		count += len(ts.subscribers)
 	}
+	return count
 }
 
-// GetConnectionCount 获取连接数量
-func (wm *WebSocketManager) GetConnectionCount() int {
-	wm.mutex.RLock()
-	defer wm.mutex.RUnlock()
-	return len(wm.connections)
+// encodeWithSeq 序列化消息并加入 seq 字段
+func encodeWithSeq(message interface{}, seq int64) ([]byte, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		// 非对象消息包装后再加入 seq
+		return json.Marshal(map[string]interface{}{"seq": seq, "data": json.RawMessage(data)})
+	}
+	fields["seq"], _ = json.Marshal(seq)
+	return json.Marshal(fields)
+}
+
+// messageType 读取消息JSON中的 type 字段
+func messageType(data []byte) string {
+	var message struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(data, &message)
+	return message.Type
 }
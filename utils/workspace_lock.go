@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 工作区锁的键
+const (
+	LockKeyWorkspace    = "workspace"     // 整个funNovel工作区（git reset/pull等），与其他所有锁互斥
+	LockKeyProject      = "project"       // 项目共享文件：package.json、vite.config.js、pages.json、novelConfig.js
+	LockKeyRepoBranches = "repo-branches" // 分支管理仓库目录
+)
+
+// BrandLockKey 品牌相关文件（各类配置文件、prebuild、static图片目录）的锁键
+func BrandLockKey(brandCode string) string {
+	return "brand:" + brandCode
+}
+
+// LockHolder 锁的持有者或等待者
+type LockHolder struct {
+	Owner     string    `json:"owner"`
+	Keys      []string  `json:"keys"`
+	Since     time.Time `json:"since"`
+	WaitingMs int64     `json:"waiting_ms,omitempty"`
+}
+
+// WorkspaceLockStatus 当前锁状态
+type WorkspaceLockStatus struct {
+	Holders []LockHolder `json:"holders"`
+	Waiters []LockHolder `json:"waiters"`
+}
+
+// WorkspaceLockManager 工作区锁管理器
+// 一次性获取一组键（全部可用时才获取，避免死锁），workspace 键与其他所有键互斥
+type WorkspaceLockManager struct {
+	mu      sync.Mutex
+	held    map[string]*LockHolder // 锁键 -> 持有者
+	waiters map[*LockHolder]struct{}
+	changed chan struct{} // 有锁释放时关闭并替换，用于唤醒等待者
+}
+
+var (
+	workspaceLockManager     *WorkspaceLockManager
+	workspaceLockManagerOnce sync.Once
+)
+
+// GetWorkspaceLockManager 获取全局工作区锁管理器
+func GetWorkspaceLockManager() *WorkspaceLockManager {
+	workspaceLockManagerOnce.Do(func() {
+		workspaceLockManager = &WorkspaceLockManager{
+			held:    make(map[string]*LockHolder),
+			waiters: make(map[*LockHolder]struct{}),
+			changed: make(chan struct{}),
+		}
+	})
+	return workspaceLockManager
+}
+
+// Acquire 获取一组锁，超时未获取到时返回错误；成功时返回释放函数
+func (m *WorkspaceLockManager) Acquire(owner string, timeout time.Duration, keys ...string) (func(), error) {
+	keys = normalizeLockKeys(keys)
+	request := &LockHolder{Owner: owner, Keys: keys, Since: time.Now()}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	m.mu.Lock()
+	m.waiters[request] = struct{}{}
+	for {
+		blocker := m.conflict(keys)
+		if blocker == nil {
+			delete(m.waiters, request)
+			request.Since = time.Now()
+			for _, key := range keys {
+				m.held[key] = request
+			}
+			m.mu.Unlock()
+			return m.releaseFunc(request), nil
+		}
+
+		changed := m.changed
+		m.mu.Unlock()
+
+		select {
+		case <-changed:
+			m.mu.Lock()
+		case <-deadline.C:
+			m.mu.Lock()
+			delete(m.waiters, request)
+			blocker = m.conflict(keys)
+			m.mu.Unlock()
+			if blocker == nil {
+				return nil, fmt.Errorf("timed out waiting for workspace lock %s", strings.Join(keys, ", "))
+			}
+			return nil, fmt.Errorf("timed out after %s waiting for workspace lock %s: held by %s since %s",
+				timeout, strings.Join(keys, ", "), blocker.Owner, blocker.Since.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+// conflict 返回阻塞本次获取的持有者，没有冲突时返回nil（调用方需持有mu）
+func (m *WorkspaceLockManager) conflict(keys []string) *LockHolder {
+	if holder, ok := m.held[LockKeyWorkspace]; ok {
+		return holder
+	}
+	for _, key := range keys {
+		if key == LockKeyWorkspace {
+			for _, holder := range m.held {
+				return holder
+			}
+		}
+		if holder, ok := m.held[key]; ok {
+			return holder
+		}
+	}
+	return nil
+}
+
+// releaseFunc 生成只生效一次的释放函数
+func (m *WorkspaceLockManager) releaseFunc(holder *LockHolder) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			for _, key := range holder.Keys {
+				if m.held[key] == holder {
+					delete(m.held, key)
+				}
+			}
+			close(m.changed)
+			m.changed = make(chan struct{})
+		})
+	}
+}
+
+// Status 获取当前锁的持有者和等待者
+func (m *WorkspaceLockManager) Status() WorkspaceLockStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := WorkspaceLockStatus{
+		Holders: make([]LockHolder, 0),
+		Waiters: make([]LockHolder, 0, len(m.waiters)),
+	}
+
+	seen := make(map[*LockHolder]bool)
+	for _, holder := range m.held {
+		if seen[holder] {
+			continue
+		}
+		seen[holder] = true
+		status.Holders = append(status.Holders, *holder)
+	}
+	now := time.Now()
+	for waiter := range m.waiters {
+		item := *waiter
+		item.WaitingMs = now.Sub(waiter.Since).Milliseconds()
+		status.Waiters = append(status.Waiters, item)
+	}
+
+	sort.Slice(status.Holders, func(i, j int) bool { return status.Holders[i].Since.Before(status.Holders[j].Since) })
+	sort.Slice(status.Waiters, func(i, j int) bool { return status.Waiters[i].Since.Before(status.Waiters[j].Since) })
+	return status
+}
+
+// normalizeLockKeys 去重并排序锁键
+func normalizeLockKeys(keys []string) []string {
+	seen := make(map[string]bool, len(keys))
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}